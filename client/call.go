@@ -58,12 +58,30 @@ func (client *Client) Ping(ctx context.Context, request *protocol.PingRequest) (
 	return &result, nil
 }
 
+// ListPrompts lists all the prompts of the server, following the pages of the results
 func (client *Client) ListPrompts(ctx context.Context) (*protocol.ListPromptsResult, error) {
+	prompts, err := listAllPages(ctx, func(ctx context.Context, cursor protocol.Cursor) ([]*protocol.Prompt, protocol.Cursor, error) {
+		result, err := client.ListPromptsPage(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.Prompts, result.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.ListPromptsResult{Prompts: prompts}, nil
+}
+
+// ListPromptsPage lists the page of the prompts of the server following cursor, empty for the first page
+func (client *Client) ListPromptsPage(ctx context.Context, cursor protocol.Cursor) (*protocol.ListPromptsResult, error) {
 	if client.serverCapabilities.Prompts == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.PromptsList, protocol.NewListPromptsRequest())
+	request := protocol.NewListPromptsRequest()
+	request.Cursor = cursor
+	response, err := client.callServer(ctx, protocol.PromptsList, request)
 	if err != nil {
 		return nil, err
 	}
@@ -93,12 +111,30 @@ func (client *Client) GetPrompt(ctx context.Context, request *protocol.GetPrompt
 	return &result, nil
 }
 
+// ListResources lists all the resources of the server, following the pages of the results
 func (client *Client) ListResources(ctx context.Context) (*protocol.ListResourcesResult, error) {
+	resources, err := listAllPages(ctx, func(ctx context.Context, cursor protocol.Cursor) ([]*protocol.Resource, protocol.Cursor, error) {
+		result, err := client.ListResourcesPage(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.Resources, result.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.ListResourcesResult{Resources: resources}, nil
+}
+
+// ListResourcesPage lists the page of the resources of the server following cursor, empty for the first page
+func (client *Client) ListResourcesPage(ctx context.Context, cursor protocol.Cursor) (*protocol.ListResourcesResult, error) {
 	if client.serverCapabilities.Resources == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ResourcesList, protocol.NewListResourcesRequest())
+	request := protocol.NewListResourcesRequest()
+	request.Cursor = cursor
+	response, err := client.callServer(ctx, protocol.ResourcesList, request)
 	if err != nil {
 		return nil, err
 	}
@@ -110,12 +146,30 @@ func (client *Client) ListResources(ctx context.Context) (*protocol.ListResource
 	return &result, err
 }
 
+// ListResourceTemplates lists all the resource templates of the server, following the pages of the results
 func (client *Client) ListResourceTemplates(ctx context.Context) (*protocol.ListResourceTemplatesResult, error) {
+	templates, err := listAllPages(ctx, func(ctx context.Context, cursor protocol.Cursor) ([]*protocol.ResourceTemplate, protocol.Cursor, error) {
+		result, err := client.ListResourceTemplatesPage(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.ResourceTemplates, result.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.ListResourceTemplatesResult{ResourceTemplates: templates}, nil
+}
+
+// ListResourceTemplatesPage lists the page of the resource templates of the server following cursor, empty for the first page
+func (client *Client) ListResourceTemplatesPage(ctx context.Context, cursor protocol.Cursor) (*protocol.ListResourceTemplatesResult, error) {
 	if client.serverCapabilities.Resources == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ResourceListTemplates, protocol.NewListResourceTemplatesRequest())
+	request := protocol.NewListResourceTemplatesRequest()
+	request.Cursor = cursor
+	response, err := client.callServer(ctx, protocol.ResourceListTemplates, request)
 	if err != nil {
 		return nil, err
 	}
@@ -182,12 +236,30 @@ func (client *Client) UnSubscribeResourceChange(ctx context.Context, request *pr
 	return &result, nil
 }
 
+// ListTools lists all the tools of the server, following the pages of the results
 func (client *Client) ListTools(ctx context.Context) (*protocol.ListToolsResult, error) {
+	tools, err := listAllPages(ctx, func(ctx context.Context, cursor protocol.Cursor) ([]*protocol.Tool, protocol.Cursor, error) {
+		result, err := client.ListToolsPage(ctx, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return result.Tools, result.NextCursor, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &protocol.ListToolsResult{Tools: tools}, nil
+}
+
+// ListToolsPage lists the page of the tools of the server following cursor, empty for the first page
+func (client *Client) ListToolsPage(ctx context.Context, cursor protocol.Cursor) (*protocol.ListToolsResult, error) {
 	if client.serverCapabilities.Tools == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	response, err := client.callServer(ctx, protocol.ToolsList, protocol.NewListToolsRequest())
+	request := protocol.NewListToolsRequest()
+	request.Cursor = cursor
+	response, err := client.callServer(ctx, protocol.ToolsList, request)
 	if err != nil {
 		return nil, err
 	}
@@ -259,6 +331,12 @@ func (client *Client) callServer(ctx context.Context, method protocol.Method, pa
 		return nil, errors.New("callServer: client not ready")
 	}
 
+	if _, ok := ctx.Deadline(); !ok && client.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.callTimeout)
+		defer cancel()
+	}
+
 	requestID := strconv.FormatInt(atomic.AddInt64(&client.requestID, 1), 10)
 	respChan := make(chan *protocol.JSONRPCResponse, 1)
 	client.reqID2respChan.Set(requestID, respChan)
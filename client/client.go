@@ -39,6 +39,14 @@ func WithInitTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithCallTimeout bounds the requests to the server whose context has no deadline, so that a hung server
+// doesn't block the calls forever. By default the requests wait as long as their context.
+func WithCallTimeout(timeout time.Duration) Option {
+	return func(s *Client) {
+		s.callTimeout = timeout
+	}
+}
+
 func WithLogger(logger pkg.Logger) Option {
 	return func(s *Client) {
 		s.logger = logger
@@ -70,6 +78,7 @@ type Client struct {
 	serverInstructions string
 
 	initTimeout time.Duration
+	callTimeout time.Duration
 
 	closed chan struct{}
 
@@ -4,10 +4,12 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
@@ -313,3 +315,184 @@ func testClientInit(t *testing.T, in io.ReadWriteCloser, out io.ReadWriter, outS
 	<-ch
 	return client
 }
+
+func TestClientCallRPCError(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
+		}
+
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	client := testClientInit(t, in, out, outScan)
+
+	go func() {
+		if !outScan.Scan() {
+			t.Errorf("outScan: %+v", outScan.Err())
+			return
+		}
+		jsonrpcReq := &protocol.JSONRPCRequest{}
+		if err := pkg.JSONUnmarshal(outScan.Bytes(), &jsonrpcReq); err != nil {
+			t.Errorf("Json Unmarshal: %+v", err)
+			return
+		}
+
+		resp := protocol.NewJSONRPCErrorResponse(jsonrpcReq.ID, protocol.InvalidParams, "invalid arguments")
+		resp.Error.Data = map[string]interface{}{"path": "/city"}
+		respBytes, err := json.Marshal(resp)
+		if err != nil {
+			t.Errorf("Json Marshal: %+v", err)
+			return
+		}
+		if _, err := in.Write(append(respBytes, "\n"...)); err != nil {
+			t.Errorf("in Write: %+v", err)
+		}
+	}()
+
+	_, err := client.CallTool(context.Background(), protocol.NewCallToolRequest("weather", map[string]interface{}{}))
+	var rpcErr *protocol.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("CallTool() error = %v, want a *protocol.RPCError", err)
+	}
+	want := protocol.NewRPCError(protocol.InvalidParams, "invalid arguments", map[string]interface{}{"path": "/city"})
+	if !reflect.DeepEqual(rpcErr, want) {
+		t.Errorf("CallTool() error = %+v, want %+v", rpcErr, want)
+	}
+}
+
+func TestClientToolHelpers(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+
+	var (
+		in io.ReadWriteCloser = struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{
+			Reader: reader1,
+			Writer: writer1,
+			Closer: reader1,
+		}
+
+		out io.ReadWriter = struct {
+			io.Reader
+			io.Writer
+		}{
+			Reader: reader2,
+			Writer: writer2,
+		}
+
+		outScan = bufio.NewScanner(out)
+	)
+
+	client := testClientInit(t, in, out, outScan)
+
+	// the server answers the requests in order, a nil result is never answered
+	answers := []func(params json.RawMessage) interface{}{
+		func(json.RawMessage) interface{} {
+			return protocol.NewListToolsResult([]*protocol.Tool{{Name: "a"}}, "page2")
+		},
+		func(params json.RawMessage) interface{} {
+			var req protocol.ListToolsRequest
+			if err := pkg.JSONUnmarshal(params, &req); err != nil || req.Cursor != "page2" {
+				t.Errorf("second tools/list cursor = %q, %v, want page2", req.Cursor, err)
+			}
+			return protocol.NewListToolsResult([]*protocol.Tool{{Name: "b"}}, "")
+		},
+		func(params json.RawMessage) interface{} {
+			var req protocol.CallToolRequest
+			if err := pkg.JSONUnmarshal(params, &req); err != nil || string(req.RawArguments) != `{"city":"Paris"}` {
+				t.Errorf("tools/call arguments = %s, %v, want {\"city\":\"Paris\"}", req.RawArguments, err)
+			}
+			return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "sunny"}}, false)
+		},
+		func(json.RawMessage) interface{} {
+			return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "unknown city"}}, true)
+		},
+		func(json.RawMessage) interface{} {
+			return nil
+		},
+	}
+	go func() {
+		for _, answer := range answers {
+			if !outScan.Scan() {
+				return
+			}
+			jsonrpcReq := &protocol.JSONRPCRequest{}
+			if err := pkg.JSONUnmarshal(outScan.Bytes(), &jsonrpcReq); err != nil {
+				t.Errorf("Json Unmarshal: %+v", err)
+				return
+			}
+			result := answer(jsonrpcReq.RawParams)
+			if result == nil {
+				continue
+			}
+			respBytes, err := json.Marshal(protocol.NewJSONRPCSuccessResponse(jsonrpcReq.ID, result))
+			if err != nil {
+				t.Errorf("Json Marshal: %+v", err)
+				return
+			}
+			if _, err := in.Write(append(respBytes, "\n"...)); err != nil {
+				t.Errorf("in Write: %+v", err)
+				return
+			}
+		}
+		// drain the cancellation notification
+		outScan.Scan()
+	}()
+
+	tools, err := client.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if len(tools.Tools) != 2 || tools.Tools[0].Name != "a" || tools.Tools[1].Name != "b" {
+		t.Errorf("ListTools() = %v, want the tools a and b of both pages", tools.Tools)
+	}
+
+	result, err := client.CallToolWithArgs(context.Background(), "weather", struct {
+		City string `json:"city"`
+	}{City: "Paris"})
+	if err != nil {
+		t.Fatalf("CallToolWithArgs() error = %v", err)
+	}
+	if text := result.Content[0].(*protocol.TextContent).Text; text != "sunny" {
+		t.Errorf("CallToolWithArgs() = %q, want sunny", text)
+	}
+
+	result, err = client.CallToolWithArgs(context.Background(), "weather", map[string]string{"city": "Atlantis"})
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) || result == nil || !result.IsError {
+		t.Fatalf("CallToolWithArgs() of a failed call = %v, %v, want the result and a *ToolError", result, err)
+	}
+	if want := "tool weather failed: unknown city"; toolErr.Error() != want {
+		t.Errorf("ToolError = %q, want %q", toolErr.Error(), want)
+	}
+	var rpcErr *protocol.RPCError
+	if errors.As(err, &rpcErr) {
+		t.Errorf("CallToolWithArgs() of a failed call error = %v, want no *protocol.RPCError", err)
+	}
+
+	client.callTimeout = 50 * time.Millisecond
+	if _, err = client.CallToolWithArgs(context.Background(), "hang", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CallToolWithArgs() of a hung server error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
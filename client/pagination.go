@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// listAllPages collects the elements of all the pages listed by listPage, starting from the first page.
+// A cursor returned twice fails the listing rather than looping forever on a misbehaving server.
+func listAllPages[T any](ctx context.Context, listPage func(context.Context, protocol.Cursor) ([]T, protocol.Cursor, error)) ([]T, error) {
+	var (
+		all    = make([]T, 0)
+		cursor protocol.Cursor
+		seen   = make(map[protocol.Cursor]bool)
+	)
+	for {
+		elements, next, err := listPage(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, elements...)
+		if next == "" {
+			return all, nil
+		}
+		if seen[next] {
+			return nil, fmt.Errorf("cursor %s was already returned", next)
+		}
+		seen[next] = true
+		cursor = next
+	}
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// ToolError is returned by CallToolWithArgs along with the result of a call the tool reported as failed,
+// i.e. with isError set, as opposed to the *protocol.RPCError of the requests the server rejected.
+type ToolError struct {
+	Name   string
+	Result *protocol.CallToolResult
+}
+
+func (e *ToolError) Error() string {
+	texts := make([]string, 0, len(e.Result.Content))
+	for _, content := range e.Result.Content {
+		if text, ok := content.(*protocol.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return fmt.Sprintf("tool %s failed: %s", e.Name, strings.Join(texts, "\n"))
+}
+
+// CallToolWithArgs calls the tool name with the arguments args, marshaled to a JSON object.
+// The requests rejected by the server fail with a *protocol.RPCError, while the calls the tool reported
+// as failed return their result along with a *ToolError.
+func (client *Client) CallToolWithArgs(ctx context.Context, name string, args any) (*protocol.CallToolResult, error) {
+	arguments := json.RawMessage("{}")
+	if args != nil {
+		data, err := json.Marshal(args)
+		if err != nil {
+			return nil, fmt.Errorf("marshal arguments of tool %s: %w", name, err)
+		}
+		if string(data) != "null" {
+			arguments = data
+		}
+	}
+
+	result, err := client.CallTool(ctx, protocol.NewCallToolRequestWithRawArguments(name, arguments))
+	if err != nil {
+		return nil, err
+	}
+	if result.IsError {
+		return result, &ToolError{Name: name, Result: result}
+	}
+	return result, nil
+}
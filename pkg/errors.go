@@ -20,10 +20,16 @@ var (
 	ErrRateLimitExceeded         = errors.New("rate limit exceeded")
 )
 
+// ResponseError is the error of a JSON-RPC response, see protocol.RPCError
 type ResponseError struct {
-	Code    int
-	Message string
-	Data    interface{}
+	// The error type that occurred.
+	Code int `json:"code"`
+	// A short description of the error. The message SHOULD be limited
+	// to a concise single sentence.
+	Message string `json:"message"`
+	// Additional information about the error. The value of this member
+	// is defined by the sender (e.g. detailed error information, nested errors etc.).
+	Data interface{} `json:"data,omitempty"`
 }
 
 func NewResponseError(code int, message string, data interface{}) *ResponseError {
@@ -33,6 +33,13 @@ var DebugLogger Logger = &defaultLogger{
 	errLog:   log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile),
 }
 
+// StderrLogger logs everything to Stderr, keeping Stdout free for the messages of the stdio transport
+var StderrLogger Logger = &defaultLogger{
+	logLevel: LogLevelInfo,
+	infoLog:  log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile),
+	errLog:   log.New(os.Stderr, "", log.LstdFlags|log.Lshortfile),
+}
+
 type defaultLogger struct {
 	logLevel LogLevel
 	infoLog  *log.Logger
@@ -9,6 +9,15 @@ type CompleteRequest struct {
 	Ref interface{} `json:"ref"` // Can be PromptReference or ResourceReference
 }
 
+// The types of the references of completion requests
+const (
+	RefPrompt   = "ref/prompt"
+	RefResource = "ref/resource"
+)
+
+// MaxCompletionValues is the maximum number of values of a completion result
+const MaxCompletionValues = 100
+
 // Reference types
 type PromptReference struct {
 	Type string `json:"type"`
@@ -30,8 +30,8 @@ type Implementation struct {
 // ClientCapabilities capabilities
 type ClientCapabilities struct {
 	// Experimental map[string]interface{} `json:"experimental,omitempty"`
-	// Roots        *RootsCapability       `json:"roots,omitempty"`
-	Sampling interface{} `json:"sampling,omitempty"`
+	Roots    *RootsCapability `json:"roots,omitempty"`
+	Sampling interface{}      `json:"sampling,omitempty"`
 }
 
 type RootsCapability struct {
@@ -40,10 +40,13 @@ type RootsCapability struct {
 
 type ServerCapabilities struct {
 	// Experimental map[string]interface{} `json:"experimental,omitempty"`
-	// Logging      interface{}            `json:"logging,omitempty"`
+	// Logging is set when the server answers logging/setLevel requests, storing the level requested by the session
+	Logging   *LoggingCapability   `json:"logging,omitempty"`
 	Prompts   *PromptsCapability   `json:"prompts,omitempty"`
 	Resources *ResourcesCapability `json:"resources,omitempty"`
 	Tools     *ToolsCapability     `json:"tools,omitempty"`
+	// Completions is set when the server answers completion/complete requests
+	Completions *CompletionsCapability `json:"completions,omitempty"`
 }
 
 type PromptsCapability struct {
@@ -59,6 +62,10 @@ type ToolsCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
 
+type CompletionsCapability struct{}
+
+type LoggingCapability struct{}
+
 // InitializedNotification represents the notification sent from client to server after initialization
 type InitializedNotification struct {
 	Meta map[string]interface{} `json:"_meta,omitempty"`
@@ -1,6 +1,7 @@
 package protocol
 
 import (
+	"bytes"
 	"encoding/json"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
@@ -54,6 +55,12 @@ func (r *JSONRPCRequest) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// IsBatchMessage reports whether msg is a JSON-RPC batch, i.e. an array of requests, notifications or responses
+func IsBatchMessage(msg []byte) bool {
+	trimmed := bytes.TrimSpace(msg)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
 // IsValid checks if the request is valid according to JSON-RPC 2.0 spec
 func (r *JSONRPCRequest) IsValid() bool {
 	return r.JSONRPC == jsonrpcVersion && r.Method != "" && r.ID != nil
@@ -65,18 +72,48 @@ type JSONRPCResponse struct {
 	ID        RequestID       `json:"id"`
 	Result    interface{}     `json:"result,omitempty"`
 	RawResult json.RawMessage `json:"-"`
-	Error     *responseErr    `json:"error,omitempty"`
+	Error     *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is the error of a JSON-RPC response. The calls of the client and the server failing with an error response
+// return it, callers branch on its Code with errors.As:
+//
+//	var rpcErr *protocol.RPCError
+//	if errors.As(err, &rpcErr) && rpcErr.Code == protocol.MethodNotFound {
+//		...
+//	}
+//
+// Handlers may return it as well to reply with a specific code and data instead of InternalError.
+type RPCError = pkg.ResponseError
+
+// NewRPCError creates a JSON-RPC error with the code, message and optional data
+func NewRPCError(code int, message string, data interface{}) *RPCError {
+	return pkg.NewResponseError(code, message, data)
+}
+
+// NewParseError creates a JSON-RPC error for an invalid JSON message
+func NewParseError(message string) *RPCError {
+	return NewRPCError(ParseError, message, nil)
+}
+
+// NewInvalidRequestError creates a JSON-RPC error for a message which is not a valid request
+func NewInvalidRequestError(message string) *RPCError {
+	return NewRPCError(InvalidRequest, message, nil)
+}
+
+// NewMethodNotFoundError creates a JSON-RPC error for a method which does not exist or is not available
+func NewMethodNotFoundError(message string) *RPCError {
+	return NewRPCError(MethodNotFound, message, nil)
+}
+
+// NewInvalidParamsError creates a JSON-RPC error for invalid method parameters
+func NewInvalidParamsError(message string) *RPCError {
+	return NewRPCError(InvalidParams, message, nil)
 }
 
-type responseErr struct {
-	// The error type that occurred.
-	Code int `json:"code"`
-	// A short description of the error. The message SHOULD be limited
-	// to a concise single sentence.
-	Message string `json:"message"`
-	// Additional information about the error. The value of this member
-	// is defined by the sender (e.g. detailed error information, nested errors etc.).
-	Data interface{} `json:"data,omitempty"`
+// NewInternalError creates a JSON-RPC error for an internal error
+func NewInternalError(message string) *RPCError {
+	return NewRPCError(InternalError, message, nil)
 }
 
 func (r *JSONRPCResponse) UnmarshalJSON(data []byte) error {
@@ -158,10 +195,7 @@ func NewJSONRPCErrorResponse(id RequestID, code int, message string) *JSONRPCRes
 	err := &JSONRPCResponse{
 		JSONRPC: jsonrpcVersion,
 		ID:      id,
-		Error: &responseErr{
-			Code:    code,
-			Message: message,
-		},
+		Error:   NewRPCError(code, message, nil),
 	}
 	return err
 }
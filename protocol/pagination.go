@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"encoding/base64"
+	"fmt"
 	"sort"
 )
 
@@ -12,20 +13,35 @@ type Named interface {
 	GetName() string
 }
 
+// PaginationKeyer can be implemented by the paginated elements whose name is not unique,
+// to be paginated by a unique key instead, e.g. the URI of resources.
+type PaginationKeyer interface {
+	PaginationKey() string
+}
+
+func paginationKey[T Named](element T) string {
+	if keyer, ok := any(element).(PaginationKeyer); ok {
+		return keyer.PaginationKey()
+	}
+	return element.GetName()
+}
+
+// PaginationLimit returns the page of at most limit elements following cursor, sorting allElements by their key,
+// and the cursor of the next page, empty for the last page. A cursor holds the key of the last element of its page,
+// so that the following pages are stable when elements are added or removed concurrently.
 func PaginationLimit[T Named](allElements []T, cursor Cursor, limit int) ([]T, Cursor, error) {
 	sort.Slice(allElements, func(i, j int) bool {
-		return allElements[i].GetName() < allElements[j].GetName()
+		return paginationKey(allElements[i]) < paginationKey(allElements[j])
 	})
 	startPos := 0
 	if cursor != "" {
 		c, err := base64.StdEncoding.DecodeString(string(cursor))
 		if err != nil {
-			return nil, "", err
+			return nil, "", NewInvalidParamsError(fmt.Sprintf("invalid cursor %q", cursor))
 		}
 		cString := string(c)
 		startPos = sort.Search(len(allElements), func(i int) bool {
-			nc := allElements[i].GetName()
-			return nc > cString
+			return paginationKey(allElements[i]) > cString
 		})
 	}
 	endPos := len(allElements)
@@ -33,17 +49,12 @@ func PaginationLimit[T Named](allElements []T, cursor Cursor, limit int) ([]T, C
 		endPos = startPos + limit
 	}
 	elementsToReturn := allElements[startPos:endPos]
-	// set the next cursor
-	nextCursor := func() Cursor {
-		if len(elementsToReturn) < limit {
-			return ""
-		}
-		element := elementsToReturn[len(elementsToReturn)-1]
-		nc := element.GetName()
-		toString := base64.StdEncoding.EncodeToString([]byte(nc))
-		return Cursor(toString)
-	}()
-	return elementsToReturn, nextCursor, nil
+	// set the next cursor when elements remain
+	if endPos == len(allElements) || len(elementsToReturn) == 0 {
+		return elementsToReturn, "", nil
+	}
+	nc := paginationKey(elementsToReturn[len(elementsToReturn)-1])
+	return elementsToReturn, Cursor(base64.StdEncoding.EncodeToString([]byte(nc))), nil
 }
 
 // PaginatedRequest represents a request that supports pagination
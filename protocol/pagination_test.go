@@ -2,12 +2,79 @@ package protocol
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
 	"testing"
 )
 
+func TestPaginationLimit(t *testing.T) {
+	names := func(tools []*Tool) []string {
+		list := make([]string, 0, len(tools))
+		for _, tool := range tools {
+			list = append(list, tool.Name)
+		}
+		return list
+	}
+
+	tools := getTools(4)
+	page, cursor, err := PaginationLimit(tools, "", 2)
+	if err != nil {
+		t.Fatalf("PaginationLimit() error = %v", err)
+	}
+	if got, want := names(page), []string{"tool0", "tool1"}; !reflect.DeepEqual(got, want) || cursor == "" {
+		t.Fatalf("first page = %v, cursor %q, want %v and a cursor", got, cursor, want)
+	}
+
+	// tools added before or removed from the listed pages don't shift the next pages
+	tools = append(getTools(4)[1:], &Tool{Name: "tool00"}, &Tool{Name: "tool10"})
+	page, cursor, err = PaginationLimit(tools, cursor, 2)
+	if err != nil {
+		t.Fatalf("PaginationLimit() error = %v", err)
+	}
+	if got, want := names(page), []string{"tool10", "tool2"}; !reflect.DeepEqual(got, want) || cursor == "" {
+		t.Fatalf("second page = %v, cursor %q, want %v and a cursor", got, cursor, want)
+	}
+	page, cursor, err = PaginationLimit(tools, cursor, 2)
+	if err != nil {
+		t.Fatalf("PaginationLimit() error = %v", err)
+	}
+	if got, want := names(page), []string{"tool3"}; !reflect.DeepEqual(got, want) || cursor != "" {
+		t.Fatalf("last page = %v, cursor %q, want %v without cursor", got, cursor, want)
+	}
+
+	// a full last page has no next cursor either
+	if _, cursor, _ = PaginationLimit(getTools(2), "", 2); cursor != "" {
+		t.Errorf("full last page cursor = %q, want none", cursor)
+	}
+
+	// resources sharing a name are paginated by URI
+	resources := []*Resource{{Name: "readme", URI: "file:///b"}, {Name: "readme", URI: "file:///a"}, {Name: "readme", URI: "file:///c"}}
+	var uris []string
+	cursor = ""
+	for i := 0; i < len(resources); i++ {
+		var resourcesPage []*Resource
+		if resourcesPage, cursor, err = PaginationLimit(resources, cursor, 1); err != nil {
+			t.Fatalf("PaginationLimit() error = %v", err)
+		}
+		for _, resource := range resourcesPage {
+			uris = append(uris, resource.URI)
+		}
+		if cursor == "" {
+			break
+		}
+	}
+	if want := []string{"file:///a", "file:///b", "file:///c"}; !reflect.DeepEqual(uris, want) {
+		t.Errorf("paginated resources = %v, want %v", uris, want)
+	}
+
+	var rpcErr *RPCError
+	if _, _, err = PaginationLimit(getTools(2), "not base64!", 2); !errors.As(err, &rpcErr) || rpcErr.Code != InvalidParams {
+		t.Errorf("PaginationLimit() with an invalid cursor error = %v, want an InvalidParams error", err)
+	}
+}
+
 func BenchmarkPaginationLimitForReflect(b *testing.B) {
 	list := getTools(10000)
 	for i := 0; i < b.N; i++ {
@@ -101,6 +101,11 @@ func (r *Resource) GetName() string {
 	return r.Name
 }
 
+// PaginationKey paginates the resources by URI, as their names are not unique
+func (r *Resource) PaginationKey() string {
+	return r.URI
+}
+
 type ResourceTemplate struct {
 	Annotated
 	Name              string                `json:"name"`
@@ -114,6 +119,11 @@ func (t *ResourceTemplate) GetName() string {
 	return t.Name
 }
 
+// PaginationKey paginates the resource templates by URI template, as their names are not unique
+func (t *ResourceTemplate) PaginationKey() string {
+	return t.URITemplate
+}
+
 func (t *ResourceTemplate) UnmarshalJSON(data []byte) error {
 	type Alias ResourceTemplate
 	aux := &struct {
@@ -0,0 +1,127 @@
+package protocol
+
+// Clone returns a deep copy of the schema, which can be modified without affecting s.
+func (s *InputSchema) Clone() *InputSchema {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Properties = cloneProperties(s.Properties)
+	c.Required = cloneSlice(s.Required)
+	c.AdditionalProperties = cloneAdditionalProperties(s.AdditionalProperties)
+	c.AllOf = cloneSchemas(s.AllOf)
+	c.UnevaluatedProperties = clonePointer(s.UnevaluatedProperties)
+	c.Defs = cloneProperties(s.Defs)
+	if s.DependentSchemas != nil {
+		c.DependentSchemas = make(map[string]*InputSchema, len(s.DependentSchemas))
+		for name, dependent := range s.DependentSchemas {
+			c.DependentSchemas[name] = dependent.Clone()
+		}
+	}
+	return &c
+}
+
+// Clone returns a deep copy of the property, which can be modified without affecting p.
+func (p *Property) Clone() *Property {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	c.Items = p.Items.Clone()
+	c.Properties = cloneProperties(p.Properties)
+	c.Required = cloneSlice(p.Required)
+	c.Enum = cloneValue(p.Enum)
+	c.Const = cloneValue(p.Const)
+	c.Default = cloneValue(p.Default)
+	c.Examples = cloneValue(p.Examples)
+	c.ContentSchema = p.ContentSchema.Clone()
+	c.PropertyNames = p.PropertyNames.Clone()
+	c.MinLength = clonePointer(p.MinLength)
+	c.MaxLength = clonePointer(p.MaxLength)
+	c.MultipleOf = clonePointer(p.MultipleOf)
+	c.Minimum = clonePointer(p.Minimum)
+	c.Maximum = clonePointer(p.Maximum)
+	c.MinItems = clonePointer(p.MinItems)
+	c.MaxItems = clonePointer(p.MaxItems)
+	c.OneOf = cloneSchemas(p.OneOf)
+	c.AllOf = cloneSchemas(p.AllOf)
+	c.AdditionalProperties = cloneAdditionalProperties(p.AdditionalProperties)
+	c.UnevaluatedProperties = clonePointer(p.UnevaluatedProperties)
+	c.Extensions = cloneValue(p.Extensions)
+	return &c
+}
+
+func cloneProperties(properties map[string]*Property) map[string]*Property {
+	if properties == nil {
+		return nil
+	}
+	c := make(map[string]*Property, len(properties))
+	for name, p := range properties {
+		c[name] = p.Clone()
+	}
+	return c
+}
+
+func cloneSchemas(schemas []*Property) []*Property {
+	if schemas == nil {
+		return nil
+	}
+	c := make([]*Property, len(schemas))
+	for i, p := range schemas {
+		c[i] = p.Clone()
+	}
+	return c
+}
+
+func cloneAdditionalProperties(additional any) any {
+	if p, ok := additional.(*Property); ok {
+		return p.Clone()
+	}
+	return additional
+}
+
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+func clonePointer[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	c := *p
+	return &c
+}
+
+// cloneValue deep copies the maps and slices of the JSON like value v, other values are shared.
+func cloneValue[T any](v T) T {
+	c, _ := cloneAny(v).(T)
+	return c
+}
+
+func cloneAny(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		c := make(map[string]any, len(v))
+		for key, value := range v {
+			c[key] = cloneAny(value)
+		}
+		return c
+	case []any:
+		if v == nil {
+			return v
+		}
+		c := make([]any, len(v))
+		for i, value := range v {
+			c[i] = cloneAny(value)
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,508 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// ToTypeScript generates TypeScript declarations for the schema: objects become interfaces named
+// after name and the path of their property, enums become union types, arrays use the T[] notation
+// and optional properties are marked with "?". Definitions are declared under their own name
+// and the properties of allOf schemas are merged into the composed interface.
+func (s *InputSchema) ToTypeScript(name string) (string, error) {
+	e := &tsEmitter{defs: s.Defs, names: make(map[string]bool), refs: make(map[string]string)}
+	root, err := flattenAllOf(s.Defs, s.object())
+	if err != nil {
+		return "", err
+	}
+	// arguments described by additionalProperties alone are a record, objects can't also have a schema for them
+	if additional, ok := root.AdditionalProperties.(*Property); ok {
+		if len(root.Properties) > 0 {
+			return "", fmt.Errorf("unsupported additionalProperties schema along with properties")
+		}
+		name = e.reserve(name)
+		e.decls = append(e.decls, "")
+		value, err := e.typeOf(name+"Value", additional)
+		if err != nil {
+			return "", err
+		}
+		e.decls[0] = fmt.Sprintf("export type %s = Record<string, %s>;\n", name, value)
+		return strings.Join(e.decls, "\n"), nil
+	}
+	if _, err = e.declareInterface(e.reserve(name), root); err != nil {
+		return "", err
+	}
+	return strings.Join(e.decls, "\n"), nil
+}
+
+type tsEmitter struct {
+	defs map[string]*Property
+	// names are the declared names, refs the names declared for the definitions
+	names map[string]bool
+	refs  map[string]string
+	decls []string
+}
+
+// reserve returns a declaration name based on name which isn't used yet
+func (e *tsEmitter) reserve(name string) string {
+	reserved := name
+	for i := 2; e.names[reserved]; i++ {
+		reserved = fmt.Sprintf("%s%d", name, i)
+	}
+	e.names[reserved] = true
+	return reserved
+}
+
+// declareInterface declares the object p as an interface under the reserved name
+func (e *tsEmitter) declareInterface(name string, p *Property) (string, error) {
+	p, err := flattenAllOf(e.defs, p)
+	if err != nil {
+		return "", err
+	}
+	// reserve the position so that declarations follow the order of their first use
+	index := len(e.decls)
+	e.decls = append(e.decls, "")
+
+	required := make(map[string]bool, len(p.Required))
+	for _, r := range p.Required {
+		required[r] = true
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "export interface %s {\n", name)
+	for _, propName := range sortedPropertyNames(p.Properties) {
+		prop := p.Properties[propName]
+		typ, err := e.typeOf(name+pascalCase(propName), prop)
+		if err != nil {
+			return "", fmt.Errorf("property %s: %w", propName, err)
+		}
+		if prop.Description != "" {
+			fmt.Fprintf(&b, "  /** %s */\n", strings.ReplaceAll(prop.Description, "*/", "*\\/"))
+		}
+		key := propName
+		if !tsIdentifier.MatchString(key) {
+			key = fmt.Sprintf("%q", key)
+		}
+		optional := "?"
+		if required[propName] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "  %s%s: %s;\n", key, optional, typ)
+	}
+	b.WriteString("}\n")
+
+	e.decls[index] = b.String()
+	return name, nil
+}
+
+func (e *tsEmitter) typeOf(hint string, p *Property) (string, error) {
+	if p.Ref != "" {
+		return e.typeOfRef(p.Ref)
+	}
+
+	if p.Nullable && len(p.Enum) == 0 {
+		nonNull := *p
+		nonNull.Nullable = false
+		typ, err := e.typeOf(hint, &nonNull)
+		if err != nil {
+			return "", err
+		}
+		return typ + " | null", nil
+	}
+
+	if len(p.Enum) > 0 {
+		literals := make([]string, len(p.Enum))
+		for i, v := range p.Enum {
+			literal, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			literals[i] = string(literal)
+		}
+		return strings.Join(literals, " | "), nil
+	}
+
+	if len(p.OneOf) > 0 {
+		variants := make([]string, len(p.OneOf))
+		for i, variant := range p.OneOf {
+			typ, err := e.typeOf(fmt.Sprintf("%sVariant%d", hint, i+1), variant)
+			if err != nil {
+				return "", err
+			}
+			variants[i] = typ
+		}
+		return strings.Join(variants, " | "), nil
+	}
+
+	switch p.Type {
+	case String:
+		return "string", nil
+	case Integer, Number:
+		return "number", nil
+	case Boolean:
+		return "boolean", nil
+	case Null:
+		return "null", nil
+	case Array:
+		if p.Items == nil {
+			return "unknown[]", nil
+		}
+		item, err := e.typeOf(hint+"Item", p.Items)
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(item, " | ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]", nil
+	case ObjectT:
+		if len(p.Properties) > 0 || len(p.AllOf) > 0 {
+			return e.declareInterface(e.reserve(hint), p)
+		}
+		if additional, ok := p.AdditionalProperties.(*Property); ok {
+			value, err := e.typeOf(hint+"Value", additional)
+			if err != nil {
+				return "", err
+			}
+			return "Record<string, " + value + ">", nil
+		}
+		return "Record<string, unknown>", nil
+	case "":
+		return "unknown", nil
+	default:
+		return "", fmt.Errorf("unsupported type %q", p.Type)
+	}
+}
+
+func (e *tsEmitter) typeOfRef(ref string) (string, error) {
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	if declared, ok := e.refs[name]; ok {
+		return declared, nil
+	}
+	def, ok := e.defs[name]
+	if !ok {
+		return "", fmt.Errorf("unresolved reference %s", ref)
+	}
+
+	// register the declared name before emitting the definition, which may reference itself
+	declared := e.reserve(name)
+	e.refs[name] = declared
+	if def.Type == ObjectT && (len(def.Properties) > 0 || len(def.AllOf) > 0) && len(def.Enum) == 0 {
+		return e.declareInterface(declared, def)
+	}
+
+	index := len(e.decls)
+	e.decls = append(e.decls, "")
+	typ, err := e.typeOf(declared, def)
+	if err != nil {
+		return "", err
+	}
+	e.decls[index] = fmt.Sprintf("export type %s = %s;\n", declared, typ)
+	return declared, nil
+}
+
+// flattenAllOf returns the object p with the properties and the required properties of its allOf schemas,
+// which are resolved against the definitions and must be objects themselves.
+func flattenAllOf(defs map[string]*Property, p *Property) (*Property, error) {
+	if len(p.AllOf) == 0 {
+		return p, nil
+	}
+	merged := *p
+	merged.AllOf = nil
+	merged.Properties = make(map[string]*Property, len(p.Properties))
+	for name, prop := range p.Properties {
+		merged.Properties[name] = prop
+	}
+	merged.Required = append([]string(nil), p.Required...)
+	for i, sub := range p.AllOf {
+		part := resolveDef(defs, sub)
+		if part == nil {
+			return nil, fmt.Errorf("allOf %d: unresolved reference %s", i, sub.Ref)
+		}
+		if (part.Type != ObjectT && part.Type != "") || len(part.OneOf) > 0 {
+			return nil, fmt.Errorf("allOf %d: unsupported schema, only objects can be composed", i)
+		}
+		part, err := flattenAllOf(defs, part)
+		if err != nil {
+			return nil, fmt.Errorf("allOf %d: %w", i, err)
+		}
+		for name, prop := range part.Properties {
+			if _, ok := merged.Properties[name]; !ok {
+				merged.Properties[name] = prop
+			}
+		}
+		merged.Required = append(merged.Required, part.Required...)
+	}
+	return &merged, nil
+}
+
+func sortedPropertyNames(properties map[string]*Property) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pascalCase converts a property name such as "user_info" or "userInfo" to "UserInfo"
+func pascalCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// goInitialisms are the words spelled upper case in Go identifiers
+var goInitialisms = map[string]bool{
+	"API": true, "HTTP": true, "ID": true, "IP": true, "JSON": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// GoStructFromSchema generates the Go source of a struct named typeName matching the schema, to scaffold
+// the request types of external tools. Properties become fields with json tags, nested objects become
+// separate struct types, descriptions and enums are written as comments and definitions are declared
+// under their own name. Optional and recursive objects are pointers, and the properties of allOf schemas
+// are merged into the composed struct.
+func GoStructFromSchema(s *InputSchema, typeName string) (string, error) {
+	e := &goEmitter{
+		defs:      s.Defs,
+		names:     make(map[string]bool),
+		refs:      make(map[string]string),
+		structs:   make(map[string]bool),
+		declaring: make(map[string]bool),
+	}
+	root, err := flattenAllOf(s.Defs, s.object())
+	if err != nil {
+		return "", err
+	}
+	// arguments described by additionalProperties alone are a map, structs can't also hold the additional properties
+	if additional, ok := root.AdditionalProperties.(*Property); ok {
+		if len(root.Properties) > 0 {
+			return "", fmt.Errorf("unsupported additionalProperties schema along with properties")
+		}
+		typeName = e.reserve(typeName)
+		e.decls = append(e.decls, "")
+		value, err := e.typeOf(typeName+"Value", additional, false)
+		if err != nil {
+			return "", err
+		}
+		e.decls[0] = fmt.Sprintf("type %s map[string]%s\n", typeName, value)
+	} else if _, err = e.declareStruct(e.reserve(typeName), root); err != nil {
+		return "", err
+	}
+
+	source, err := format.Source([]byte(strings.Join(e.decls, "\n")))
+	if err != nil {
+		return "", err
+	}
+	return string(source), nil
+}
+
+type goEmitter struct {
+	defs map[string]*Property
+	// names are the declared type names, refs the names declared for the definitions
+	names map[string]bool
+	refs  map[string]string
+	// structs are the definitions declared as structs, declaring those whose declaration is in progress
+	structs   map[string]bool
+	declaring map[string]bool
+	decls     []string
+}
+
+func (e *goEmitter) reserve(name string) string {
+	reserved := name
+	for i := 2; e.names[reserved]; i++ {
+		reserved = fmt.Sprintf("%s%d", name, i)
+	}
+	e.names[reserved] = true
+	return reserved
+}
+
+// declareStruct declares the object p as a struct under the reserved name
+func (e *goEmitter) declareStruct(name string, p *Property) (string, error) {
+	p, err := flattenAllOf(e.defs, p)
+	if err != nil {
+		return "", err
+	}
+	// reserve the position so that declarations follow the order of their first use
+	index := len(e.decls)
+	e.decls = append(e.decls, "")
+
+	required := make(map[string]bool, len(p.Required))
+	for _, r := range p.Required {
+		required[r] = true
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	fields := make(map[string]bool)
+	for _, propName := range sortedPropertyNames(p.Properties) {
+		prop := p.Properties[propName]
+
+		field := goIdentifier(propName)
+		for i := 2; fields[field]; i++ {
+			field = fmt.Sprintf("%s%d", goIdentifier(propName), i)
+		}
+		fields[field] = true
+
+		typ, err := e.typeOf(name+field, prop, !required[propName])
+		if err != nil {
+			return "", fmt.Errorf("property %s: %w", propName, err)
+		}
+
+		writeGoComment(&b, "\t", prop)
+		tag := propName
+		if !required[propName] {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:%q`\n", field, typ, tag)
+	}
+	b.WriteString("}\n")
+
+	e.decls[index] = b.String()
+	return name, nil
+}
+
+func (e *goEmitter) typeOf(hint string, p *Property, optional bool) (string, error) {
+	if p.Ref != "" {
+		name, err := e.typeOfRef(p.Ref)
+		if err != nil {
+			return "", err
+		}
+		// like inline objects, optional structs are pointers, as well as the recursive ones which couldn't be values
+		if e.structs[name] && (optional || e.declaring[name]) {
+			name = "*" + name
+		}
+		return name, nil
+	}
+	if len(p.OneOf) > 0 {
+		return "any", nil
+	}
+
+	switch p.Type {
+	case String:
+		return "string", nil
+	case Integer:
+		return "int", nil
+	case Number:
+		return "float64", nil
+	case Boolean:
+		return "bool", nil
+	case Null, "":
+		return "any", nil
+	case Array:
+		if p.Items == nil {
+			return "[]any", nil
+		}
+		item, err := e.typeOf(hint+"Item", p.Items, false)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case ObjectT:
+		if len(p.Properties) > 0 || len(p.AllOf) > 0 {
+			name, err := e.declareStruct(e.reserve(hint), p)
+			if optional {
+				name = "*" + name
+			}
+			return name, err
+		}
+		if additional, ok := p.AdditionalProperties.(*Property); ok {
+			value, err := e.typeOf(hint+"Value", additional, false)
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + value, nil
+		}
+		return "map[string]any", nil
+	default:
+		return "", fmt.Errorf("unsupported type %q", p.Type)
+	}
+}
+
+func (e *goEmitter) typeOfRef(ref string) (string, error) {
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	if declared, ok := e.refs[name]; ok {
+		return declared, nil
+	}
+	def, ok := e.defs[name]
+	if !ok {
+		return "", fmt.Errorf("unresolved reference %s", ref)
+	}
+
+	// register the declared name before emitting the definition, which may reference itself
+	declared := e.reserve(goIdentifier(name))
+	e.refs[name] = declared
+	if def.Type == ObjectT && (len(def.Properties) > 0 || len(def.AllOf) > 0) {
+		e.structs[declared] = true
+		e.declaring[declared] = true
+		defer delete(e.declaring, declared)
+		return e.declareStruct(declared, def)
+	}
+
+	index := len(e.decls)
+	e.decls = append(e.decls, "")
+	typ, err := e.typeOf(declared, def, false)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	writeGoComment(&b, "", def)
+	fmt.Fprintf(&b, "type %s %s\n", declared, typ)
+	e.decls[index] = b.String()
+	return declared, nil
+}
+
+// writeGoComment writes the description and the enum of p as a comment
+func writeGoComment(b *strings.Builder, indent string, p *Property) {
+	if p.Description != "" {
+		for _, line := range strings.Split(p.Description, "\n") {
+			fmt.Fprintf(b, "%s// %s\n", indent, line)
+		}
+	}
+	if len(p.Enum) > 0 {
+		values := make([]string, len(p.Enum))
+		for i, v := range p.Enum {
+			value, _ := json.Marshal(v)
+			values[i] = string(value)
+		}
+		fmt.Fprintf(b, "%s// Enum: %s\n", indent, strings.Join(values, ", "))
+	}
+}
+
+// goIdentifier converts a property name to an exported Go identifier, e.g. "user_id" to "UserID"
+func goIdentifier(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if upper := strings.ToUpper(word); goInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(pascalCase(word))
+	}
+
+	identifier := b.String()
+	if identifier == "" || !unicode.IsLetter([]rune(identifier)[0]) {
+		identifier = "F" + identifier
+	}
+	return identifier
+}
@@ -0,0 +1,294 @@
+package protocol
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+func TestInputSchema_ToTypeScript(t *testing.T) {
+	schema := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name":     {Type: String, Description: "user name"},
+			"priority": {Type: Integer, Enum: []any{1, 2, 3}},
+			"tags":     {Type: Array, Items: &Property{Type: String, Enum: []any{"red", "green"}}},
+			"status":   {Ref: "#/$defs/Status"},
+			"user": {
+				Type: ObjectT,
+				Properties: map[string]*Property{
+					"age":    {Type: Integer},
+					"active": {Type: Boolean},
+				},
+				Required: []string{"active"},
+			},
+			"labels":     {Type: ObjectT, AdditionalProperties: &Property{Type: String}},
+			"x-trace-id": {Type: String},
+		},
+		Required: []string{"name", "user"},
+		Defs: map[string]*Property{
+			"Status": {Type: String, Enum: []any{"active", "inactive"}},
+		},
+	}
+
+	got, err := schema.ToTypeScript("SearchRequest")
+	if err != nil {
+		t.Fatalf("ToTypeScript() error = %v", err)
+	}
+	want := `export interface SearchRequest {
+  labels?: Record<string, string>;
+  /** user name */
+  name: string;
+  priority?: 1 | 2 | 3;
+  status?: Status;
+  tags?: ("red" | "green")[];
+  user: SearchRequestUser;
+  "x-trace-id"?: string;
+}
+
+export type Status = "active" | "inactive";
+
+export interface SearchRequestUser {
+  active: boolean;
+  age?: number;
+}
+`
+	if got != want {
+		t.Errorf("ToTypeScript() got:\n%s\nwant:\n%s", got, want)
+	}
+
+	schema.Properties["broken"] = &Property{Ref: "#/$defs/Missing"}
+	if _, err = schema.ToTypeScript("SearchRequest"); err == nil {
+		t.Errorf("ToTypeScript() with unresolved reference error = nil, wantErr")
+	}
+}
+
+func TestGoStructFromSchema(t *testing.T) {
+	schema := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name":     {Type: String, Description: "user name"},
+			"priority": {Type: Integer, Enum: []any{1, 2, 3}},
+			"score":    {Type: Number},
+			"tags":     {Type: Array, Items: &Property{Type: String}},
+			"status":   {Ref: "#/$defs/Status"},
+			"user_id":  {Type: String},
+			"address": {
+				Type: ObjectT,
+				Properties: map[string]*Property{
+					"city":   {Type: String},
+					"street": {Type: String},
+				},
+				Required: []string{"city"},
+			},
+			"items": {
+				Type: Array,
+				Items: &Property{
+					Type:       ObjectT,
+					Properties: map[string]*Property{"sku": {Type: String}},
+					Required:   []string{"sku"},
+				},
+			},
+			"labels": {Type: ObjectT, AdditionalProperties: &Property{Type: Boolean}},
+		},
+		Required: []string{"name", "user_id"},
+		Defs: map[string]*Property{
+			"Status": {Type: String, Enum: []any{"active", "inactive"}},
+		},
+	}
+
+	got, err := GoStructFromSchema(schema, "SearchRequest")
+	if err != nil {
+		t.Fatalf("GoStructFromSchema() error = %v", err)
+	}
+	want := "type SearchRequest struct {\n" +
+		"\tAddress *SearchRequestAddress    `json:\"address,omitempty\"`\n" +
+		"\tItems   []SearchRequestItemsItem `json:\"items,omitempty\"`\n" +
+		"\tLabels  map[string]bool          `json:\"labels,omitempty\"`\n" +
+		"\t// user name\n" +
+		"\tName string `json:\"name\"`\n" +
+		"\t// Enum: 1, 2, 3\n" +
+		"\tPriority int      `json:\"priority,omitempty\"`\n" +
+		"\tScore    float64  `json:\"score,omitempty\"`\n" +
+		"\tStatus   Status   `json:\"status,omitempty\"`\n" +
+		"\tTags     []string `json:\"tags,omitempty\"`\n" +
+		"\tUserID   string   `json:\"user_id\"`\n" +
+		"}\n\n" +
+		"type SearchRequestAddress struct {\n" +
+		"\tCity   string `json:\"city\"`\n" +
+		"\tStreet string `json:\"street,omitempty\"`\n" +
+		"}\n\n" +
+		"type SearchRequestItemsItem struct {\n" +
+		"\tSku string `json:\"sku\"`\n" +
+		"}\n\n" +
+		"// Enum: \"active\", \"inactive\"\n" +
+		"type Status string\n"
+	if got != want {
+		t.Errorf("GoStructFromSchema() got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err = GoStructFromSchema(&InputSchema{
+		Type:       Object,
+		Properties: map[string]*Property{"broken": {Type: "tuple"}},
+	}, "Broken"); err == nil {
+		t.Errorf("GoStructFromSchema() with unsupported type error = nil, wantErr")
+	}
+}
+
+func TestInputSchema_ToTypeScriptComposedRoot(t *testing.T) {
+	schema := &InputSchema{
+		Type:       Object,
+		Properties: map[string]*Property{"query": {Type: String}},
+		Required:   []string{"query"},
+		AllOf: []*Property{
+			{Ref: "#/$defs/Page"},
+			{Type: ObjectT, Properties: map[string]*Property{"sort": {Type: String}}},
+		},
+		Defs: map[string]*Property{
+			"Page": {Type: ObjectT, Properties: map[string]*Property{"cursor": {Type: String}}, Required: []string{"cursor"}},
+		},
+	}
+
+	got, err := schema.ToTypeScript("SearchRequest")
+	if err != nil {
+		t.Fatalf("ToTypeScript() error = %v", err)
+	}
+	want := `export interface SearchRequest {
+  cursor: string;
+  query: string;
+  sort?: string;
+}
+`
+	if got != want {
+		t.Errorf("ToTypeScript() got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got, err = (&InputSchema{Type: Object, AdditionalProperties: &Property{Type: Integer}}).ToTypeScript("Counts")
+	if err != nil {
+		t.Fatalf("ToTypeScript() error = %v", err)
+	}
+	if want = "export type Counts = Record<string, number>;\n"; got != want {
+		t.Errorf("ToTypeScript() got:\n%s\nwant:\n%s", got, want)
+	}
+
+	schema.AdditionalProperties = &Property{Type: Integer}
+	if _, err = schema.ToTypeScript("SearchRequest"); err == nil {
+		t.Errorf("ToTypeScript() with properties and an additionalProperties schema error = nil, wantErr")
+	}
+}
+
+func TestGoStructFromSchemaRecursive(t *testing.T) {
+	schema := &InputSchema{
+		Type:       Object,
+		Properties: map[string]*Property{"root": {Ref: "#/$defs/Node"}},
+		Required:   []string{"root"},
+		Defs: map[string]*Property{
+			"Node": {
+				Type: ObjectT,
+				Properties: map[string]*Property{
+					"value":    {Type: Integer},
+					"next":     {Ref: "#/$defs/Node"},
+					"children": {Type: Array, Items: &Property{Ref: "#/$defs/Node"}},
+					"owner":    {Ref: "#/$defs/Owner"},
+				},
+				Required: []string{"value", "owner"},
+			},
+			"Owner": {
+				Type:       ObjectT,
+				Properties: map[string]*Property{"name": {Type: String}, "node": {Ref: "#/$defs/Node"}},
+				Required:   []string{"name", "node"},
+			},
+		},
+	}
+
+	got, err := GoStructFromSchema(schema, "TreeRequest")
+	if err != nil {
+		t.Fatalf("GoStructFromSchema() error = %v", err)
+	}
+	info := typeCheckGo(t, got)
+	fields := map[string]string{
+		"TreeRequest.Root": "p.Node",
+		"Node.Next":        "*p.Node",
+		"Node.Children":    "[]*p.Node",
+		"Node.Owner":       "p.Owner",
+		"Owner.Node":       "*p.Node",
+	}
+	for field, want := range fields {
+		if typ := info[field]; typ != want {
+			t.Errorf("GoStructFromSchema() field %s type = %s, want %s in:\n%s", field, typ, want, got)
+		}
+	}
+}
+
+func TestGoStructFromSchemaComposedRoot(t *testing.T) {
+	schema := &InputSchema{
+		Type:       Object,
+		Properties: map[string]*Property{"query": {Type: String}},
+		Required:   []string{"query"},
+		AllOf: []*Property{
+			{Ref: "#/$defs/Page"},
+			{Type: ObjectT, Properties: map[string]*Property{"sort": {Type: String}}},
+		},
+		Defs: map[string]*Property{
+			"Page": {Type: ObjectT, Properties: map[string]*Property{"cursor": {Type: String}}, Required: []string{"cursor"}},
+		},
+	}
+
+	got, err := GoStructFromSchema(schema, "SearchRequest")
+	if err != nil {
+		t.Fatalf("GoStructFromSchema() error = %v", err)
+	}
+	want := "type SearchRequest struct {\n" +
+		"\tCursor string `json:\"cursor\"`\n" +
+		"\tQuery  string `json:\"query\"`\n" +
+		"\tSort   string `json:\"sort,omitempty\"`\n" +
+		"}\n"
+	if got != want {
+		t.Errorf("GoStructFromSchema() got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got, err = GoStructFromSchema(&InputSchema{Type: Object, AdditionalProperties: &Property{Type: Integer}}, "Counts")
+	if err != nil {
+		t.Fatalf("GoStructFromSchema() error = %v", err)
+	}
+	if want = "type Counts map[string]int\n"; got != want {
+		t.Errorf("GoStructFromSchema() got:\n%s\nwant:\n%s", got, want)
+	}
+
+	schema.AdditionalProperties = &Property{Type: Integer}
+	if _, err = GoStructFromSchema(schema, "SearchRequest"); err == nil {
+		t.Errorf("GoStructFromSchema() with properties and an additionalProperties schema error = nil, wantErr")
+	}
+	if _, err = GoStructFromSchema(&InputSchema{Type: Object, AllOf: []*Property{{Type: String}}}, "Broken"); err == nil {
+		t.Errorf("GoStructFromSchema() with a non-object allOf schema error = nil, wantErr")
+	}
+}
+
+// typeCheckGo type-checks the generated declarations as the package p
+// and returns the types of the struct fields by "Type.Field"
+func typeCheckGo(t *testing.T, source string) map[string]string {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", "package p\n\n"+source, 0)
+	if err != nil {
+		t.Fatalf("parser.ParseFile() error = %v in:\n%s", err, source)
+	}
+	pkg, err := (&types.Config{}).Check("p", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("types.Check() error = %v in:\n%s", err, source)
+	}
+
+	fields := make(map[string]string)
+	for _, name := range pkg.Scope().Names() {
+		st, ok := pkg.Scope().Lookup(name).Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			fields[name+"."+st.Field(i).Name()] = st.Field(i).Type().String()
+		}
+	}
+	return fields
+}
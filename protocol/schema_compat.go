@@ -0,0 +1,224 @@
+package protocol
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// IsBackwardCompatible reports whether every input valid under the old schema is still valid under the updated one,
+// so that existing callers of a tool keep working after its schema changed.
+// Breaking changes, e.g. a new required property or a narrowed enum, are described by the returned reasons,
+// each prefixed with the JSON pointer of the affected property as in Walk.
+// Widening changes, e.g. a new optional property, an extended enum or integer becoming number, are compatible.
+func IsBackwardCompatible(old, updated *InputSchema) (bool, []string) {
+	c := &compatChecker{
+		oldDefs: old.Defs,
+		newDefs: updated.Defs,
+		visited: make(map[[2]string]bool),
+	}
+	c.compare("", old.object(), updated.object())
+	return len(c.reasons) == 0, c.reasons
+}
+
+type compatChecker struct {
+	oldDefs, newDefs map[string]*Property
+	// visited holds the pairs of references already compared, terminating recursive definitions
+	visited map[[2]string]bool
+	reasons []string
+}
+
+func (c *compatChecker) breaking(path, format string, args ...any) {
+	if path == "" {
+		path = "/"
+	}
+	c.reasons = append(c.reasons, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (c *compatChecker) compare(path string, old, updated *Property) {
+	if old.Ref != "" || updated.Ref != "" {
+		key := [2]string{old.Ref, updated.Ref}
+		if c.visited[key] {
+			return
+		}
+		c.visited[key] = true
+		old, updated = resolveDef(c.oldDefs, old), resolveDef(c.newDefs, updated)
+		if old == nil || updated == nil {
+			c.breaking(path, "unresolved reference")
+			return
+		}
+	}
+
+	if len(old.OneOf) > 0 || len(updated.OneOf) > 0 {
+		c.compareOneOf(path, old, updated)
+		return
+	}
+
+	if old.Type != updated.Type && !(old.Type == Integer && updated.Type == Number) {
+		c.breaking(path, "type changed from %s to %s", old.Type, updated.Type)
+		return
+	}
+	if old.Nullable && !updated.Nullable {
+		c.breaking(path, "null is no longer accepted")
+	}
+	c.compareEnum(path, old.Enum, updated.Enum)
+	if updated.Const != nil && (old.Const == nil || !containsEnumValue([]any{updated.Const}, old.Const)) {
+		c.breaking(path, "value restricted to const %v", updated.Const)
+	}
+
+	if updated.MinLength != nil && (old.MinLength == nil || *old.MinLength < *updated.MinLength) {
+		c.breaking(path, "minLength raised to %d", *updated.MinLength)
+	}
+	if updated.MaxLength != nil && (old.MaxLength == nil || *old.MaxLength > *updated.MaxLength) {
+		c.breaking(path, "maxLength lowered to %d", *updated.MaxLength)
+	}
+	if updated.Pattern != "" && updated.Pattern != old.Pattern {
+		c.breaking(path, "pattern changed to %s", updated.Pattern)
+	}
+	if updated.MultipleOf != nil && (old.MultipleOf == nil || *old.MultipleOf != *updated.MultipleOf) {
+		c.breaking(path, "multipleOf changed to %v", *updated.MultipleOf)
+	}
+	if updated.Minimum != nil && (old.Minimum == nil || *old.Minimum < *updated.Minimum) {
+		c.breaking(path, "minimum raised to %v", *updated.Minimum)
+	}
+	if updated.Maximum != nil && (old.Maximum == nil || *old.Maximum > *updated.Maximum) {
+		c.breaking(path, "maximum lowered to %v", *updated.Maximum)
+	}
+	if updated.MinItems != nil && (old.MinItems == nil || *old.MinItems < *updated.MinItems) {
+		c.breaking(path, "minItems raised to %d", *updated.MinItems)
+	}
+	if updated.MaxItems != nil && (old.MaxItems == nil || *old.MaxItems > *updated.MaxItems) {
+		c.breaking(path, "maxItems lowered to %d", *updated.MaxItems)
+	}
+
+	if len(old.AllOf) != len(updated.AllOf) {
+		c.breaking(path, "allOf composition changed")
+	} else {
+		for i := range old.AllOf {
+			c.compare(fmt.Sprintf("%s/allOf/%d", path, i), old.AllOf[i], updated.AllOf[i])
+		}
+	}
+	if updated.UnevaluatedProperties != nil && !*updated.UnevaluatedProperties &&
+		(old.UnevaluatedProperties == nil || *old.UnevaluatedProperties) {
+		c.breaking(path, "unevaluated properties are no longer accepted")
+	}
+
+	switch updated.Type {
+	case ObjectT:
+		c.compareObject(path, old, updated)
+	case Array:
+		if old.Items != nil && updated.Items != nil {
+			c.compare(path+"/*", old.Items, updated.Items)
+		} else if updated.Items != nil {
+			c.breaking(path, "array items are restricted")
+		}
+	}
+}
+
+func (c *compatChecker) compareObject(path string, old, updated *Property) {
+	oldRequired := make(map[string]bool, len(old.Required))
+	for _, name := range old.Required {
+		oldRequired[name] = true
+	}
+	for _, name := range updated.Required {
+		if !oldRequired[name] {
+			c.breaking(path+"/"+name, "property became required")
+		}
+	}
+
+	for _, name := range sortedPropertyNames(old.Properties) {
+		newProp, ok := updated.Properties[name]
+		if !ok {
+			if additional, ok := updated.AdditionalProperties.(bool); ok && !additional {
+				c.breaking(path+"/"+name, "property removed while additional properties are not allowed")
+			}
+			continue
+		}
+		c.compare(path+"/"+name, old.Properties[name], newProp)
+	}
+}
+
+func (c *compatChecker) compareEnum(path string, old, updated []any) {
+	if len(updated) == 0 {
+		return
+	}
+	if len(old) == 0 {
+		c.breaking(path, "values are restricted to an enum")
+		return
+	}
+	for _, value := range old {
+		if !containsEnumValue(updated, value) {
+			c.breaking(path, "enum value %v removed", value)
+		}
+	}
+}
+
+// compareOneOf requires each alternative of the old schema to be compatible with an alternative of the updated one,
+// a schema without oneOf being its single alternative.
+func (c *compatChecker) compareOneOf(path string, old, updated *Property) {
+	oldVariants, newVariants := old.OneOf, updated.OneOf
+	if len(oldVariants) == 0 {
+		oldVariants = []*Property{old}
+	}
+	if len(newVariants) == 0 {
+		newVariants = []*Property{updated}
+	}
+
+	for i, oldVariant := range oldVariants {
+		compatible := false
+		for _, newVariant := range newVariants {
+			visited := make(map[[2]string]bool, len(c.visited))
+			for key := range c.visited {
+				visited[key] = true
+			}
+			variantChecker := &compatChecker{oldDefs: c.oldDefs, newDefs: c.newDefs, visited: visited}
+			variantChecker.compare(path, oldVariant, newVariant)
+			if len(variantChecker.reasons) == 0 {
+				compatible = true
+				break
+			}
+		}
+		if !compatible {
+			c.breaking(path, "alternative %d is no longer accepted", i)
+		}
+	}
+}
+
+func resolveDef(defs map[string]*Property, p *Property) *Property {
+	for p != nil && p.Ref != "" {
+		p = defs[p.Ref[strings.LastIndex(p.Ref, "/")+1:]]
+	}
+	return p
+}
+
+func containsEnumValue(enum []any, value any) bool {
+	for _, v := range enum {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	// numbers compare by value, e.g. the int 1 of a generated enum and the float64 1 of a decoded one
+	if f, ok := toFloat64(value); ok {
+		for _, v := range enum {
+			if g, ok := toFloat64(v); ok && f == g {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toFloat64 converts the numeric value v, of any integer or float type, to a float64
+func toFloat64(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
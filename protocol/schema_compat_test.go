@@ -0,0 +1,115 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsBackwardCompatible(t *testing.T) {
+	base := func() *InputSchema {
+		return &InputSchema{
+			Type: Object,
+			Properties: map[string]*Property{
+				"name":   {Type: String},
+				"count":  {Type: Integer},
+				"status": {Type: String, Enum: []any{"active", "inactive"}},
+				"tags":   {Type: Array, Items: &Property{Type: String}},
+			},
+			Required: []string{"name"},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		change      func(s *InputSchema)
+		wantReasons []string
+	}{
+		{
+			name:   "unchanged",
+			change: func(s *InputSchema) {},
+		},
+		{
+			name:   "new optional property",
+			change: func(s *InputSchema) { s.Properties["note"] = &Property{Type: String} },
+		},
+		{
+			name: "new required property",
+			change: func(s *InputSchema) {
+				s.Properties["note"] = &Property{Type: String}
+				s.Required = append(s.Required, "note")
+			},
+			wantReasons: []string{"/note: property became required"},
+		},
+		{
+			name:   "widened enum",
+			change: func(s *InputSchema) { s.Properties["status"].Enum = append(s.Properties["status"].Enum, "archived") },
+		},
+		{
+			name:        "narrowed enum",
+			change:      func(s *InputSchema) { s.Properties["status"].Enum = []any{"active"} },
+			wantReasons: []string{"/status: enum value inactive removed"},
+		},
+		{
+			name:   "integer widened to number",
+			change: func(s *InputSchema) { s.Properties["count"].Type = Number },
+		},
+		{
+			name:        "changed item type",
+			change:      func(s *InputSchema) { s.Properties["tags"].Items.Type = Integer },
+			wantReasons: []string{"/tags/*: type changed from string to integer"},
+		},
+		{
+			name:   "removed property",
+			change: func(s *InputSchema) { delete(s.Properties, "count") },
+		},
+		{
+			name: "removed property without additional properties",
+			change: func(s *InputSchema) {
+				delete(s.Properties, "count")
+				s.AdditionalProperties = false
+			},
+			wantReasons: []string{"/count: property removed while additional properties are not allowed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := base()
+			tt.change(updated)
+			ok, reasons := IsBackwardCompatible(base(), updated)
+			if ok != (len(tt.wantReasons) == 0) || !reflect.DeepEqual(reasons, tt.wantReasons) {
+				t.Errorf("IsBackwardCompatible() = %v, %q, want reasons %q", ok, reasons, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestIsBackwardCompatibleWithDefinitions(t *testing.T) {
+	old := &InputSchema{
+		Type:       Object,
+		Properties: map[string]*Property{"node": {Ref: "#/$defs/Node"}},
+		Defs: map[string]*Property{
+			"Node": {Type: ObjectT, Properties: map[string]*Property{
+				"value": {Type: Integer},
+				"next":  {Ref: "#/$defs/Node"},
+			}},
+		},
+	}
+	updated := &InputSchema{
+		Type:       Object,
+		Properties: map[string]*Property{"node": {Ref: "#/$defs/Node"}},
+		Defs: map[string]*Property{
+			"Node": {Type: ObjectT, Properties: map[string]*Property{
+				"value": {Type: String},
+				"next":  {Ref: "#/$defs/Node"},
+			}},
+		},
+	}
+
+	if ok, reasons := IsBackwardCompatible(old, old); !ok {
+		t.Errorf("IsBackwardCompatible() of a recursive schema with itself = %q", reasons)
+	}
+	ok, reasons := IsBackwardCompatible(old, updated)
+	if want := []string{"/node/value: type changed from integer to string"}; ok || !reflect.DeepEqual(reasons, want) {
+		t.Errorf("IsBackwardCompatible() = %v, %q, want %q", ok, reasons, want)
+	}
+}
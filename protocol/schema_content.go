@@ -0,0 +1,46 @@
+package protocol
+
+import (
+	"reflect"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+// ContentSchemaProvider can be implemented by string types carrying JSON encoded content,
+// the generator describes the content with the schema of the returned type, emitted once in the $defs of the schema
+// and referenced through the contentSchema keyword.
+type ContentSchemaProvider interface {
+	ContentSchemaType() reflect.Type
+}
+
+var contentSchemaProviderType = reflect.TypeOf((*ContentSchemaProvider)(nil)).Elem()
+
+// JSONString is a string holding the JSON encoding of a T, e.g. a filter passed through as text.
+// Its schema is a string with the "application/json" content media type and the schema of T as content schema.
+type JSONString[T any] string
+
+func (JSONString[T]) ContentSchemaType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Decode unmarshals the JSON content of s.
+func (s JSONString[T]) Decode() (T, error) {
+	var v T
+	err := pkg.JSONUnmarshal([]byte(s), &v)
+	return v, err
+}
+
+// reflectContentSchema generates the schema of the string type t implementing ContentSchemaProvider
+func (g *schemaGenerator) reflectContentSchema(t reflect.Type) (*Property, error) {
+	contentType := reflect.Zero(t).Interface().(ContentSchemaProvider).ContentSchemaType()
+	for contentType.Kind() == reflect.Ptr {
+		contentType = contentType.Elem()
+	}
+	ref, err := g.defineType(contentType, func() (*Property, error) {
+		return g.reflectSchemaByType(contentType)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Property{Type: String, ContentMediaType: "application/json", ContentSchema: ref}, nil
+}
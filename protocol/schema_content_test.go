@@ -0,0 +1,109 @@
+package protocol
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testSearchFilter struct {
+	Field string `json:"field"`
+	Value string `json:"value,omitempty"`
+}
+
+func TestGenerateSchemaWithContentSchema(t *testing.T) {
+	type contentSchemaReq struct {
+		Filter   JSONString[testSearchFilter]   `json:"filter"`
+		Fallback *JSONString[*testSearchFilter] `json:"fallback,omitempty"`
+	}
+
+	got, err := generateSchemaFromReqStruct(contentSchemaReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	content := func() *Property {
+		return &Property{Type: String, ContentMediaType: "application/json", ContentSchema: &Property{Ref: "#/$defs/testSearchFilter"}}
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"filter":   content(),
+			"fallback": content(),
+		},
+		Required: []string{"filter"},
+		Defs: map[string]*Property{
+			"testSearchFilter": {
+				Type:       ObjectT,
+				Properties: map[string]*Property{"field": {Type: String}, "value": {Type: String}},
+				Required:   []string{"field"},
+			},
+		},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(got.Properties["filter"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if wantJSON := `"contentSchema":{"$ref":"#/$defs/testSearchFilter"}`; !strings.Contains(string(data), wantJSON) {
+		t.Errorf("json.Marshal() = %s, missing %s", data, wantJSON)
+	}
+
+	filter, err := JSONString[testSearchFilter](`{"field":"status","value":"open"}`).Decode()
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if want := (testSearchFilter{Field: "status", Value: "open"}); !reflect.DeepEqual(filter, want) {
+		t.Errorf("Decode() = %v, want %v", filter, want)
+	}
+}
+
+func TestGenerateSchemaWithRawMessageSchemaType(t *testing.T) {
+	RegisterNamedType("SearchFilter", reflect.TypeOf(&testSearchFilter{}))
+	defer unregisterNamedType("SearchFilter")
+
+	type rawMessageReq struct {
+		Filter  json.RawMessage `json:"filter" schemaType:"SearchFilter" description:"decoded later"`
+		Payload json.RawMessage `json:"payload,omitempty"`
+	}
+
+	got, err := generateSchemaFromReqStruct(rawMessageReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"filter":  {Ref: "#/$defs/testSearchFilter", Description: "decoded later"},
+			"payload": {},
+		},
+		Required: []string{"filter"},
+		Defs: map[string]*Property{
+			"testSearchFilter": {
+				Type:       ObjectT,
+				Properties: map[string]*Property{"field": {Type: String}, "value": {Type: String}},
+				Required:   []string{"field"},
+			},
+		},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	if err = got.Validate(map[string]any{"filter": map[string]any{"field": "status"}, "payload": []any{1.0, "x"}}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err = got.Validate(map[string]any{"filter": map[string]any{"value": "open"}}); err == nil {
+		t.Errorf("Validate() of a filter missing its required field error = nil, wantErr")
+	}
+
+	_, err = generateSchemaFromReqStruct(struct {
+		Filter json.RawMessage `json:"filter" schemaType:"Unknown"`
+	}{})
+	if err == nil {
+		t.Errorf("generateSchemaFromReqStruct() with an unregistered schemaType error = nil, wantErr")
+	}
+}
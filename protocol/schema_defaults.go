@@ -0,0 +1,77 @@
+package protocol
+
+import (
+	"encoding/json"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+// ApplyDefaults returns a copy of the decoded arguments data where the missing optional properties are set to their default,
+// e.g. for normalizing the arguments of a tool before dispatching them. Defaults are applied recursively to the objects
+// present in data or set by a default, and are converted to their JSON representation, e.g. float64 for numbers.
+// Required properties are left missing so that validation still reports them; data itself is not modified.
+func (s *InputSchema) ApplyDefaults(data map[string]any) map[string]any {
+	result := cloneValue(data)
+	if result == nil {
+		result = make(map[string]any)
+	}
+	d := defaulter{defs: s.Defs}
+	d.applyObject(*s.object(), result)
+	return result
+}
+
+// defaulter applies the defaults of schemas, resolving $ref against the definitions of the root schema
+type defaulter struct {
+	defs map[string]*Property
+}
+
+func (d defaulter) apply(schema Property, value any) {
+	if schema.Ref != "" {
+		def, ok := schemaValidator{defs: d.defs}.resolve(schema.Ref)
+		if !ok {
+			return
+		}
+		schema = *def
+	}
+	if object, ok := value.(map[string]any); ok {
+		d.applyObject(schema, object)
+	}
+}
+
+func (d defaulter) applyObject(schema Property, object map[string]any) {
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	for name, p := range schema.Properties {
+		if p == nil {
+			continue
+		}
+		value, exists := object[name]
+		if !exists && !required[name] && p.Default != nil {
+			value, exists = defaultValue(p.Default), true
+			object[name] = value
+		}
+		if exists {
+			d.apply(*p, value)
+		}
+	}
+	for _, sub := range schema.AllOf {
+		if sub != nil {
+			d.apply(*sub, object)
+		}
+	}
+}
+
+// defaultValue returns a copy of the default v as decoded from JSON, like the arguments it is merged into
+func defaultValue(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return cloneValue(v)
+	}
+	var value any
+	if err = pkg.JSONUnmarshal(data, &value); err != nil {
+		return cloneValue(v)
+	}
+	return value
+}
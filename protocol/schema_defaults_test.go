@@ -0,0 +1,103 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInputSchema_ApplyDefaults(t *testing.T) {
+	type defaultsOptions struct {
+		Color string `json:"color,omitempty" default:"red"`
+		Size  int    `json:"size,omitempty" default:"3"`
+	}
+	type defaultsReq struct {
+		Name    string          `json:"name" default:"required"`
+		Limit   int             `json:"limit,omitempty" default:"10"`
+		Verbose bool            `json:"verbose,omitempty" default:"true"`
+		Tags    []string        `json:"tags,omitempty" default:"[\"a\",\"b\"]"`
+		Options defaultsOptions `json:"options,omitempty"`
+		Extra   *struct {
+			Mode string `json:"mode,omitempty" default:"fast"`
+		} `json:"extra,omitempty" default:"{\"level\":1}"`
+	}
+
+	schema, err := generateSchemaFromReqStruct(defaultsReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data map[string]any
+		want map[string]any
+	}{
+		{
+			name: "top-level and nested defaults",
+			data: map[string]any{"options": map[string]any{}},
+			want: map[string]any{
+				"limit":   float64(10),
+				"verbose": true,
+				"tags":    []any{"a", "b"},
+				"options": map[string]any{"color": "red", "size": float64(3)},
+				"extra":   map[string]any{"level": float64(1), "mode": "fast"},
+			},
+		},
+		{
+			name: "nil arguments",
+			data: nil,
+			want: map[string]any{
+				"limit":   float64(10),
+				"verbose": true,
+				"tags":    []any{"a", "b"},
+				"extra":   map[string]any{"level": float64(1), "mode": "fast"},
+			},
+		},
+		{
+			name: "existing values preserved",
+			data: map[string]any{
+				"name":    "alice",
+				"limit":   float64(1),
+				"verbose": false,
+				"tags":    []any{},
+				"options": map[string]any{"color": "blue"},
+				"extra":   nil,
+			},
+			want: map[string]any{
+				"name":    "alice",
+				"limit":   float64(1),
+				"verbose": false,
+				"tags":    []any{},
+				"options": map[string]any{"color": "blue", "size": float64(3)},
+				"extra":   nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := cloneValue(tt.data)
+			if got := schema.ApplyDefaults(data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyDefaults() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(data, tt.data) {
+				t.Errorf("ApplyDefaults() modified the arguments to %v, want %v", data, tt.data)
+			}
+		})
+	}
+}
+
+func TestInputSchema_ApplyDefaultsResolvesRefs(t *testing.T) {
+	schema := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"retry": {Ref: "#/$defs/Retry", Default: map[string]any{}},
+		},
+		Defs: map[string]*Property{
+			"Retry": {Type: ObjectT, Properties: map[string]*Property{"attempts": {Type: Integer, Default: 3}}},
+		},
+	}
+
+	want := map[string]any{"retry": map[string]any{"attempts": float64(3)}}
+	if got := schema.ApplyDefaults(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplyDefaults() = %v, want %v", got, want)
+	}
+}
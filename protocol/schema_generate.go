@@ -1,10 +1,19 @@
 package protocol
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"unicode"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
@@ -22,7 +31,11 @@ const (
 )
 
 type Property struct {
-	Type DataType `json:"type"`
+	Type DataType `json:"type,omitempty"`
+	// Ref references a definition of the root schema, e.g. "#/$defs/Status".
+	Ref string `json:"$ref,omitempty"`
+	// Title is a short human-readable name of the property, e.g. for form labels.
+	Title string `json:"title,omitempty"`
 	// Description is the description of the schema.
 	Description string `json:"description,omitempty"`
 	// Items specifies which data type an array contains, if the schema type is Array.
@@ -31,203 +44,1715 @@ type Property struct {
 	Properties map[string]*Property `json:"properties,omitempty"`
 	Required   []string             `json:"required,omitempty"`
 	Enum       []any                `json:"enum,omitempty"`
+	// Const restricts the value to a single one, it must be a member of Enum if both are set.
+	Const any `json:"const,omitempty"`
 	// Default specifies the default value for the property.
 	Default any `json:"default,omitempty"`
+	// Examples lists sample values of the property, helping models produce valid arguments.
+	Examples []any `json:"examples,omitempty"`
+	// Format specifies the semantic format of a string, e.g. "date-time" or "uuid".
+	Format string `json:"format,omitempty"`
+	// MinLength and MaxLength bound the number of characters of a string.
+	MinLength *int `json:"minLength,omitempty"`
+	MaxLength *int `json:"maxLength,omitempty"`
+	// Pattern is a regular expression a string must match, it is checked with the RE2 syntax of package regexp.
+	Pattern string `json:"pattern,omitempty"`
+	// ContentEncoding specifies the encoding of a string carrying binary data, e.g. "base64".
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	// ContentMediaType and ContentSchema describe the content of a string carrying an encoded document,
+	// e.g. "application/json" and a reference to the schema of the JSON value.
+	ContentMediaType string    `json:"contentMediaType,omitempty"`
+	ContentSchema    *Property `json:"contentSchema,omitempty"`
+	// MultipleOf restricts a number to multiples of the value, e.g. 0.01 for two decimal places.
+	MultipleOf *float64 `json:"multipleOf,omitempty"`
+	// Minimum and Maximum bound a number inclusively.
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+	// MinItems and MaxItems bound the length of an array.
+	MinItems *int `json:"minItems,omitempty"`
+	MaxItems *int `json:"maxItems,omitempty"`
+	// OneOf requires the value to be valid against exactly one of the schemas, e.g. the variants of a union.
+	OneOf []*Property `json:"oneOf,omitempty"`
+	// AllOf requires the value to be valid against all the schemas, e.g. the schemas composing an object.
+	AllOf []*Property `json:"allOf,omitempty"`
+	// PropertyNames describes the names of the properties of an object, e.g. the integer keys of a map.
+	PropertyNames *Property `json:"propertyNames,omitempty"`
+	// AdditionalProperties is either a bool or a *Property describing the values of properties
+	// not listed in Properties, if the schema type is Object.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+	// UnevaluatedProperties false rejects the properties declared neither by the object nor by its AllOf schemas,
+	// which additionalProperties can't express for composed objects.
+	UnevaluatedProperties *bool `json:"unevaluatedProperties,omitempty"`
+	// ReadOnly marks a value set by the server, which clients are not expected to send.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Nullable allows null besides the values of Type, it is emitted as a type array, e.g. ["string", "null"].
+	Nullable bool `json:"-"`
+	// Extensions holds vendor keywords (prefixed with "x-") emitted alongside the standard keywords.
+	Extensions map[string]any `json:"-"`
 }
 
-var schemaCache = pkg.SyncMap[*InputSchema]{}
+func (p Property) MarshalJSON() ([]byte, error) {
+	type alias Property
+	var data []byte
+	var err error
+	if p.Nullable && p.Type != "" {
+		data, err = json.Marshal(struct {
+			Type []DataType `json:"type"`
+			alias
+		}{Type: []DataType{p.Type, Null}, alias: alias(p)})
+	} else {
+		data, err = json.Marshal(alias(p))
+	}
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err = pkg.JSONUnmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for keyword, value := range p.Extensions {
+		if fields[keyword], err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+func (p *Property) UnmarshalJSON(data []byte) error {
+	type alias Property
+	temp := struct {
+		Type json.RawMessage `json:"type,omitempty"`
+		*alias
+	}{alias: (*alias)(p)}
+	if err := pkg.JSONUnmarshal(data, &temp); err != nil {
+		return err
+	}
+	if err := p.unmarshalType(temp.Type); err != nil {
+		return err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := pkg.JSONUnmarshal(data, &fields); err != nil {
+		return err
+	}
+	if raw, ok := fields["additionalProperties"]; ok && len(raw) > 0 && raw[0] == '{' {
+		additional := &Property{}
+		if err := pkg.JSONUnmarshal(raw, additional); err != nil {
+			return err
+		}
+		p.AdditionalProperties = additional
+	}
+	for keyword, raw := range fields {
+		if !strings.HasPrefix(keyword, "x-") {
+			continue
+		}
+		var value any
+		if err := pkg.JSONUnmarshal(raw, &value); err != nil {
+			return err
+		}
+		p.setExtension(keyword, value)
+	}
+	return nil
+}
+
+// unmarshalType sets Type and Nullable from either a single type or a type array.
+func (p *Property) unmarshalType(raw json.RawMessage) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if raw[0] != '[' {
+		return pkg.JSONUnmarshal(raw, &p.Type)
+	}
+
+	var types []DataType
+	if err := pkg.JSONUnmarshal(raw, &types); err != nil {
+		return err
+	}
+	for _, t := range types {
+		if t != Null {
+			p.Type = t
+			continue
+		}
+		p.Nullable = true
+	}
+	if p.Type == "" && p.Nullable {
+		p.Type, p.Nullable = Null, false
+	}
+	return nil
+}
+
+// SetDefault sets the default value of the property after checking that v is valid against the schema,
+// e.g. that it is a string for a String property and one of the values of its enum.
+// Properties without a Type, such as references, accept any default.
+func (p *Property) SetDefault(v any) error {
+	if p.Type != "" {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("default value %v is not serializable: %w", v, err)
+		}
+		var value any
+		if err = pkg.JSONUnmarshal(data, &value); err != nil {
+			return err
+		}
+		if !validate(*p, value) {
+			return fmt.Errorf("default value %v is not compatible with type %s", v, p.Type)
+		}
+	}
+	p.Default = v
+	return nil
+}
+
+func (p *Property) setExtension(keyword string, value any) {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	p.Extensions[keyword] = value
+}
+
+// schemaCache holds the schemas generated without options by type, along with the generation of the registry
+// they were generated at, as the registrations of types may change the schemas of the types using them
+var (
+	schemaCache        = pkg.SyncMap[cachedSchema]{}
+	registryGeneration uint64
+)
+
+type cachedSchema struct {
+	schema     *InputSchema
+	generation uint64
+}
+
+// invalidateSchemaCache makes the schemas cached so far stale, they are regenerated on their next use
+func invalidateSchemaCache() {
+	atomic.AddUint64(&registryGeneration, 1)
+}
+
+// loadSchema returns the schema of t generated without options, which is shared and must not be modified
+func loadSchema(ctx context.Context, t reflect.Type) (*InputSchema, error) {
+	typeUID := getTypeUUID(t)
+	generation := atomic.LoadUint64(&registryGeneration)
+	if cached, ok := schemaCache.Load(typeUID); ok && cached.generation == generation {
+		return cached.schema, nil
+	}
+
+	g, err := newSchemaGenerator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := g.generate(t)
+	if err != nil {
+		return nil, err
+	}
+	schemaCache.Store(typeUID, cachedSchema{schema: schema, generation: generation})
+	return schema, nil
+}
+
+func generateSchemaFromReqStruct(v any) (*InputSchema, error) {
+	return GenerateSchemaContext(context.Background(), v)
+}
+
+// GenerateSchema generates the InputSchema of the request struct v, a struct or a pointer to a struct,
+// from the types and tags of its fields. It is the stable entry point for tooling building on the generator,
+// e.g. to validate arguments or document tools. Schemas generated without options are cached per type.
+func GenerateSchema(v any, opts ...SchemaOption) (*InputSchema, error) {
+	return GenerateSchemaContext(context.Background(), v, opts...)
+}
+
+// GenerateSchemaContext generates the InputSchema of the request struct v.
+// Generation checks ctx between type nodes and aborts with ctx.Err() once it is done,
+// which protects servers generating schemas on demand for very large type graphs.
+// Schemas generated without options are cached per type, the returned schema being a copy the caller may modify.
+func GenerateSchemaContext(ctx context.Context, v any, opts ...SchemaOption) (*InputSchema, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	t, err := reqStructType(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts) == 0 {
+		schema, err := loadSchema(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		return schema.Clone(), nil
+	}
+
+	g, err := newSchemaGenerator(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return g.generate(t)
+}
+
+// GenerateSchemaLenient generates a best-effort InputSchema of the request struct v.
+// Fields whose schema can not be generated are omitted and reported as warnings,
+// which keeps a large struct usable when a single field is problematic.
+func GenerateSchemaLenient(v any, opts ...SchemaOption) (*InputSchema, []error) {
+	t, err := reqStructType(v)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	g, err := newSchemaGenerator(context.Background(), opts...)
+	if err != nil {
+		return nil, []error{err}
+	}
+	g.lenient = true
+
+	schema, err := g.generate(t)
+	if err != nil {
+		return nil, append(g.warnings, err)
+	}
+	return schema, g.warnings
+}
+
+// GenerateSchemaWithDescriptions generates the InputSchema of the request struct v and sets the descriptions
+// of desc, keyed by the JSON pointer path of the properties as passed by InputSchema.Walk (e.g. "/user/info/age").
+// The descriptions of desc take precedence over the ones of tags, which allows documenting third-party types.
+func GenerateSchemaWithDescriptions(v any, desc map[string]string, opts ...SchemaOption) (*InputSchema, error) {
+	t, err := reqStructType(v)
+	if err != nil {
+		return nil, err
+	}
+	g, err := newSchemaGenerator(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := g.generate(t)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string]bool, len(desc))
+	_ = schema.Walk(func(path string, p *Property) error {
+		if description, ok := desc[path]; ok {
+			p.Description = description
+			applied[path] = true
+		}
+		return nil
+	})
+	for path := range desc {
+		if !applied[path] {
+			return nil, fmt.Errorf("description for unknown property %s", path)
+		}
+	}
+	return schema, nil
+}
+
+// GenerateListSchema generates the InputSchema of the conventional paginated list result of elem, whose type is expanded:
+// an object holding the elements in a required items array and the optional nextCursor string of the next page.
+func GenerateListSchema(elem any, opts ...SchemaOption) (*InputSchema, error) {
+	elemType := reflect.TypeOf(elem)
+	if elemType == nil {
+		return nil, fmt.Errorf("list element type is required")
+	}
+	t := reflect.StructOf([]reflect.StructField{
+		{Name: "Items", Type: reflect.SliceOf(elemType), Tag: `json:"items"`},
+		{Name: "NextCursor", Type: reflect.TypeOf(Cursor("")), Tag: `json:"nextCursor,omitempty"`},
+	})
+
+	g, err := newSchemaGenerator(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return g.generate(t)
+}
+
+// reqStructType returns the struct type of v, dereferencing pointers
+func reqStructType(v any) (reflect.Type, error) {
+	t := reflect.TypeOf(v)
+	for t.Kind() != reflect.Struct {
+		if t.Kind() != reflect.Ptr {
+			return nil, fmt.Errorf("invalid type %v", t)
+		}
+		t = t.Elem()
+	}
+	return t, nil
+}
+
+func getTypeUUID(t reflect.Type) string {
+	if t.PkgPath() != "" && t.Name() != "" {
+		return t.PkgPath() + "." + t.Name()
+	}
+	// fallback for unnamed types (like anonymous struct)
+	return t.String()
+}
+
+// schemaGenerator holds the state of a single schema generation.
+type schemaGenerator struct {
+	ctx  context.Context
+	opts schemaOptions
+
+	// lenient generations skip the fields failing to generate, recording the errors as warnings
+	lenient  bool
+	warnings []error
+
+	// defs are the definitions of the generated schema, defNames the names given to their types
+	defs     map[string]*Property
+	defNames map[reflect.Type]string
+
+	// anonymous holds the properties generated for each anonymous struct type, in the order of anonymousTypes,
+	// which are merged into shared definitions by WithStructuralDedup
+	anonymous      map[reflect.Type][]*Property
+	anonymousTypes []reflect.Type
+
+	// inProgress holds the struct types being generated, detecting self-referential types
+	inProgress map[reflect.Type]bool
+}
+
+func newSchemaGenerator(ctx context.Context, opts ...SchemaOption) (*schemaGenerator, error) {
+	options := newSchemaOptions(opts...)
+	if options.objectTypeName != "" && !strings.EqualFold(strings.TrimSpace(options.objectTypeName), string(ObjectT)) {
+		return nil, fmt.Errorf("object type name %q is not spec compliant, expected %q", options.objectTypeName, ObjectT)
+	}
+	if options.refPrefix != "" && !strings.HasSuffix(options.refPrefix, "/") {
+		return nil, fmt.Errorf("reference prefix %q does not end with a slash", options.refPrefix)
+	}
+	return &schemaGenerator{
+		ctx:        ctx,
+		opts:       options,
+		defs:       make(map[string]*Property),
+		defNames:   make(map[reflect.Type]string),
+		anonymous:  make(map[reflect.Type][]*Property),
+		inProgress: make(map[reflect.Type]bool),
+	}, nil
+}
+
+// define adds s to the definitions as the schema of t and returns a reference to it
+func (g *schemaGenerator) define(t reflect.Type, s *Property) *Property {
+	ref, _ := g.defineType(t, func() (*Property, error) { return s, nil })
+	return ref
+}
+
+// defineType adds the schema built by build to the definitions as the schema of t, unless t is already
+// defined, and returns a reference to it. The definition is reserved before calling build,
+// so that references to t within its own schema terminate.
+func (g *schemaGenerator) defineType(t reflect.Type, build func() (*Property, error)) (*Property, error) {
+	name := g.definitionName(t)
+	if _, ok := g.defs[name]; !ok {
+		g.defs[name] = &Property{}
+		s, err := build()
+		if err != nil {
+			delete(g.defs, name)
+			delete(g.defNames, t)
+			return nil, err
+		}
+		*g.defs[name] = *s
+	}
+	return &Property{Ref: g.refPrefix() + name}, nil
+}
+
+// dedupAnonymous moves the anonymous struct types occurring several times into definitions,
+// named after a hash of their structure, replacing the occurrences by references.
+// Annotations of the fields, e.g. their descriptions, are kept on the references.
+func (g *schemaGenerator) dedupAnonymous() {
+	for _, t := range g.anonymousTypes {
+		occurrences := make([]*Property, 0, len(g.anonymous[t]))
+		for _, p := range g.anonymous[t] {
+			// references cannot carry a type, nullable objects stay inline
+			if !p.Nullable {
+				occurrences = append(occurrences, p)
+			}
+		}
+		if len(occurrences) < 2 {
+			continue
+		}
+
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(t.String()))
+		g.defNames[t] = fmt.Sprintf("Struct%08x", hash.Sum32())
+		first := occurrences[0]
+		ref := g.define(t, &Property{
+			Type:                  first.Type,
+			Properties:            first.Properties,
+			Required:              first.Required,
+			AdditionalProperties:  first.AdditionalProperties,
+			AllOf:                 first.AllOf,
+			UnevaluatedProperties: first.UnevaluatedProperties,
+		})
+		// the keywords set by the tags of each field, e.g. the description or the title, are kept next to the reference
+		for _, p := range occurrences {
+			p.Ref = ref.Ref
+			p.Type, p.Properties, p.Required = "", nil, nil
+			p.AdditionalProperties, p.AllOf, p.UnevaluatedProperties = nil, nil, nil
+		}
+	}
+}
+
+// definitionName names the definition of t after the type, suffixed with a number on collisions
+func (g *schemaGenerator) definitionName(t reflect.Type) string {
+	if name, ok := g.defNames[t]; ok {
+		return name
+	}
+
+	base := t.Name()
+	if base == "" {
+		base = "Def"
+	}
+	name := base
+	for i := 2; g.defs[name] != nil; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.defNames[t] = name
+	return name
+}
+
+// refPrefix returns the prefix of the references to definitions, "#/$defs/" unless set by WithRefPrefix
+func (g *schemaGenerator) refPrefix() string {
+	if g.opts.refPrefix != "" {
+		return g.opts.refPrefix
+	}
+	return "#/$defs/"
+}
+
+func (g *schemaGenerator) validator() schemaValidator {
+	return schemaValidator{defs: g.defs}
+}
+
+func (g *schemaGenerator) generate(t reflect.Type) (*InputSchema, error) {
+	if provider, ok := reflect.New(t).Interface().(InputSchemaProvider); ok {
+		schema := provider.MCPInputSchema()
+		if schema == nil {
+			return nil, fmt.Errorf("MCPInputSchema of type %v returned no schema", t)
+		}
+		return schema.Clone(), nil
+	}
+
+	property, err := g.reflectSchemaByObject(t)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &InputSchema{
+		Type:                  Object,
+		Properties:            property.Properties,
+		Required:              property.Required,
+		AdditionalProperties:  property.AdditionalProperties,
+		AllOf:                 property.AllOf,
+		UnevaluatedProperties: property.UnevaluatedProperties,
+	}
+	g.dedupAnonymous()
+	if len(g.defs) > 0 {
+		schema.Defs = g.defs
+	}
+	schema.ID = g.opts.schemaID
+	schema.Title = g.opts.title
+	if provider, ok := reflect.New(t).Interface().(SchemaIDProvider); ok && schema.ID == "" {
+		schema.ID = provider.SchemaID()
+	}
+	if provider, ok := reflect.New(t).Interface().(SchemaTitleProvider); ok && schema.Title == "" {
+		schema.Title = provider.SchemaTitle()
+	}
+	if provider, ok := reflect.New(t).Interface().(SchemaDependentSchemasProvider); ok {
+		dependents := provider.SchemaDependentSchemas()
+		for name := range dependents {
+			if _, ok := schema.Properties[name]; !ok {
+				return nil, fmt.Errorf("dependent schema provided for unknown property %s of type %v", name, t)
+			}
+		}
+		if len(dependents) > 0 {
+			schema.DependentSchemas = dependents
+		}
+	}
+	if err = g.finalize(schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// finalize applies the options to the whole generated schema and enforces their constraints
+func (g *schemaGenerator) finalize(schema *InputSchema) error {
+	// The descriptions are supplied first, for the options checking them
+	if describe := g.opts.contextDescriptions; describe != nil {
+		_ = schema.Walk(func(path string, p *Property) error {
+			if p.Description != "" {
+				return nil
+			}
+			if description, ok := describe(path); ok {
+				p.Description = description
+			}
+			return nil
+		})
+	}
+	// The readOnly fields are skipped while reflecting, the properties supplied by SchemaProvider or the registry remain
+	if g.opts.stripReadOnly {
+		schema.Required = stripReadOnly(schema.Properties, schema.Required)
+		_ = schema.Walk(func(_ string, p *Property) error {
+			p.Required = stripReadOnly(p.Properties, p.Required)
+			return nil
+		})
+	}
+	if g.opts.mirrorRequired {
+		mirrorRequired(schema.Properties, schema.Required)
+	}
+	if g.opts.requireDescriptions {
+		if err := requireDescriptions("", schema.Properties); err != nil {
+			return err
+		}
+	}
+	if g.opts.minimal && schema.AdditionalProperties == true {
+		schema.AdditionalProperties = nil
+	}
+	if g.opts.requiredOrder == RequiredOrderSorted {
+		sort.Strings(schema.Required)
+	}
+	if g.opts.strictComposition && len(schema.AllOf) > 0 {
+		schema.UnevaluatedProperties = new(bool)
+	}
+	err := schema.Walk(func(path string, p *Property) error {
+		if g.opts.strictComposition && len(p.AllOf) > 0 {
+			p.UnevaluatedProperties = new(bool)
+		}
+		if g.opts.requiredOrder == RequiredOrderSorted {
+			sort.Strings(p.Required)
+		}
+		if g.opts.sortEnums {
+			sortEnum(p)
+		}
+		if g.opts.minimal {
+			minimize(p)
+		}
+		if g.opts.mirrorRequired {
+			mirrorRequired(p.Properties, p.Required)
+		}
+		if g.opts.requireDescriptions {
+			if err := requireDescriptions(path, p.Properties); err != nil {
+				return err
+			}
+		}
+		if g.opts.lowercaseEnums {
+			if err := lowercaseEnum(path, p); err != nil {
+				return err
+			}
+		}
+		if g.opts.normalizeNumbers {
+			p.Default = normalizeNumber(p.Type, p.Default)
+		}
+		if g.opts.examplesLimit != nil && len(p.Examples) > *g.opts.examplesLimit {
+			p.Examples = append([]any(nil), p.Examples[:*g.opts.examplesLimit]...)
+		}
+		if g.opts.maxEnumSize > 0 && len(p.Enum) > g.opts.maxEnumSize {
+			return fmt.Errorf("enum of property %s has %d values, exceeding the maximum of %d", path, len(p.Enum), g.opts.maxEnumSize)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if g.opts.maxTotalProperties > 0 {
+		if total := countProperties(schema); total > g.opts.maxTotalProperties {
+			return fmt.Errorf("schema has %d properties, exceeding the maximum of %d", total, g.opts.maxTotalProperties)
+		}
+	}
+	return nil
+}
+
+// countProperties returns the number of properties of all the objects of the schema, definitions included
+func countProperties(schema *InputSchema) int {
+	total := len(schema.Properties)
+	_ = schema.Walk(func(_ string, p *Property) error {
+		total += len(p.Properties)
+		return nil
+	})
+	return total
+}
+
+// normalizeNumber converts the numeric value v to the Go type matching the JSON type t:
+// float64 for numbers and int for integers with an integral value, other values are returned as is.
+func normalizeNumber(t DataType, v any) any {
+	f, ok := toFloat64(v)
+	if !ok {
+		return v
+	}
+	switch t {
+	case Number:
+		return f
+	case Integer:
+		if _, isFloat := v.(float64); isFloat && f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int(f)
+		}
+	}
+	return v
+}
+
+// minimize removes the keywords of p that don't constrain the value any further,
+// and collapses a oneOf with a single alternative into the alternative.
+func minimize(p *Property) {
+	if len(p.OneOf) == 1 && p.Type == "" {
+		alternative := *p.OneOf[0]
+		if p.Description != "" {
+			alternative.Description = p.Description
+		}
+		if p.Default != nil {
+			alternative.Default = p.Default
+		}
+		*p = alternative
+	}
+	if p.AdditionalProperties == true {
+		p.AdditionalProperties = nil
+	}
+	if p.MinItems != nil && *p.MinItems == 0 {
+		p.MinItems = nil
+	}
+	if p.Const != nil && len(p.Enum) > 0 {
+		p.Enum = nil
+	}
+}
+
+// stripReadOnly removes the readOnly properties, and returns required without them,
+// so that a client is never required to send a property it cannot send
+func stripReadOnly(properties map[string]*Property, required []string) []string {
+	stripped := make(map[string]bool)
+	for name, p := range properties {
+		if p != nil && p.ReadOnly {
+			delete(properties, name)
+			stripped[name] = true
+		}
+	}
+	if len(stripped) == 0 {
+		return required
+	}
+	kept := make([]string, 0, len(required))
+	for _, name := range required {
+		if !stripped[name] {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// sortEnum sorts the enum of p, the numbers numerically then the strings lexically, followed by the other values
+// such as the null of nullable enums. The x-enumLabels of the values are sorted along.
+func sortEnum(p *Property) {
+	if len(p.Enum) < 2 {
+		return
+	}
+	order := make([]int, len(p.Enum))
+	for i := range order {
+		order[i] = i
+	}
+	rank := func(v any) int {
+		if _, ok := toFloat64(v); ok {
+			return 0
+		}
+		if v != nil && reflect.TypeOf(v).Kind() == reflect.String {
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := p.Enum[order[i]], p.Enum[order[j]]
+		if rankA, rankB := rank(a), rank(b); rankA != rankB {
+			return rankA < rankB
+		}
+		if fa, ok := toFloat64(a); ok {
+			fb, _ := toFloat64(b)
+			return fa < fb
+		}
+		if rank(a) == 1 {
+			return reflect.ValueOf(a).String() < reflect.ValueOf(b).String()
+		}
+		return false
+	})
+
+	enum := make([]any, len(p.Enum))
+	for i, j := range order {
+		enum[i] = p.Enum[j]
+	}
+	p.Enum = enum
+	if labels, ok := p.Extensions["x-enumLabels"].([]string); ok && len(labels) == len(order) {
+		sorted := make([]string, len(labels))
+		for i, j := range order {
+			sorted[i] = labels[j]
+		}
+		p.Extensions["x-enumLabels"] = sorted
+	}
+}
+
+// mirrorRequired sets the x-required vendor keyword on the required properties
+func mirrorRequired(properties map[string]*Property, required []string) {
+	for _, name := range required {
+		if p, ok := properties[name]; ok {
+			p.setExtension("x-required", true)
+		}
+	}
+}
+
+// requireDescriptions fails for the first of the properties of the object at path lacking a description
+func requireDescriptions(path string, properties map[string]*Property) error {
+	for _, name := range sortedPropertyNames(properties) {
+		if properties[name].Description == "" {
+			return fmt.Errorf("property %s/%s has no description", path, name)
+		}
+	}
+	return nil
+}
+
+// lowercaseEnum lowercases the string members of the enum of p and its string default.
+func lowercaseEnum(path string, p *Property) error {
+	if len(p.Enum) == 0 {
+		return nil
+	}
+	// the enum is copied as it may be shared with a registered schema
+	enum := make([]any, len(p.Enum))
+	seen := make(map[string]bool, len(p.Enum))
+	for i, v := range p.Enum {
+		enum[i] = v
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+		lower := strings.ToLower(str)
+		if seen[lower] {
+			return fmt.Errorf("enum of property %s has values differing only in case: %q", path, lower)
+		}
+		seen[lower] = true
+		enum[i] = lower
+	}
+	p.Enum = enum
+	if str, ok := p.Default.(string); ok {
+		p.Default = strings.ToLower(str)
+	}
+	return nil
+}
+
+func (g *schemaGenerator) reflectSchemaByObject(t reflect.Type) (*Property, error) {
+	if err := g.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Inlined schemas of self-referential types would never terminate,
+	// recursive structures are supported through the definitions of RegisterUnion.
+	if g.inProgress[t] {
+		return nil, fmt.Errorf("unsupported type: %v is self-referential", t)
+	}
+	g.inProgress[t] = true
+	defer delete(g.inProgress, t)
+
+	var (
+		properties      = make(map[string]*Property)
+		anonymousFields = make([]reflect.StructField, 0)
+		// requiredByField holds the required properties by field index, so that the promoted properties
+		// of embedded fields take the position of the embedded field, as in the JSON encoding
+		requiredByField = make([][]string, t.NumField())
+	)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// Embedded interfaces are not flattened but named after their type, as encoding/json does.
+		// The options of the json tag of an embedded struct, such as omitempty, do not change its flattening.
+		if field.Anonymous && field.Type.Kind() != reflect.Interface {
+			anonymousFields = append(anonymousFields, field)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		// The non-standard `json:",inline"` option of some libraries flattens a struct or map field as if it was embedded
+		if _, jsonOptions := parseJSONTag(field.Tag.Get("json")); jsonOptions.contains("inline") {
+			if kind := derefKind(field.Type); kind == reflect.Struct || kind == reflect.Map {
+				anonymousFields = append(anonymousFields, field)
+				continue
+			}
+		}
+
+		jsonTag, item, required, err := g.reflectSchemaByField(field)
+		if err != nil {
+			if g.skipField(t, field, err) {
+				continue
+			}
+			return nil, err
+		}
+		if item.ReadOnly && g.opts.stripReadOnly {
+			continue
+		}
+
+		properties[jsonTag] = item
+		if required {
+			requiredByField[i] = []string{jsonTag}
+		}
+	}
+
+	var (
+		additionalProperties any
+		allOf                []*Property
+	)
+	for _, field := range anonymousFields {
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		// The entries of an embedded map are flattened into the object as additional properties
+		if fieldType.Kind() == reflect.Map {
+			if additionalProperties != nil {
+				return nil, fmt.Errorf("multiple embedded maps in struct %v", t)
+			}
+			additional, err := g.reflectMapValueSchema(fieldType)
+			if err != nil {
+				if g.skipField(t, field, err) {
+					continue
+				}
+				return nil, err
+			}
+			additionalProperties = additional
+			continue
+		}
+
+		if g.opts.embedAsAllOf && field.Anonymous {
+			ref, err := g.defineType(fieldType, func() (*Property, error) {
+				return g.reflectEmbeddedSchema(fieldType)
+			})
+			if err != nil {
+				if g.skipField(t, field, err) {
+					continue
+				}
+				return nil, err
+			}
+			allOf = append(allOf, ref)
+			continue
+		}
+
+		object, err := g.reflectSchemaByObject(fieldType)
+		if err != nil {
+			if g.skipField(t, field, err) {
+				continue
+			}
+			return nil, err
+		}
+		for propName, propValue := range object.Properties {
+			if _, ok := properties[propName]; ok {
+				return nil, fmt.Errorf("duplicate property name %s in anonymous struct", propName)
+			}
+			properties[propName] = propValue
+		}
+		requiredByField[field.Index[0]] = object.Required
+	}
+
+	if additionalProperties == nil && g.opts.additionalProperties != nil {
+		additionalProperties = *g.opts.additionalProperties
+	}
+	// additionalProperties ignores the properties of the allOf schemas, composed objects are closed with unevaluatedProperties
+	var unevaluatedProperties *bool
+	if len(allOf) > 0 && additionalProperties == false {
+		additionalProperties, unevaluatedProperties = nil, new(bool)
+	}
+
+	requiredFields := make([]string, 0)
+	for _, required := range requiredByField {
+		requiredFields = append(requiredFields, required...)
+	}
+
+	if err := g.applySchemaDefaults(t, properties); err != nil {
+		return nil, err
+	}
+	if err := applySchemaExamples(t, properties); err != nil {
+		return nil, err
+	}
+
+	property := &Property{
+		Type:                  ObjectT,
+		Properties:            properties,
+		Required:              requiredFields,
+		AdditionalProperties:  additionalProperties,
+		AllOf:                 allOf,
+		UnevaluatedProperties: unevaluatedProperties,
+	}
+	return property, nil
+}
+
+// reflectEmbeddedSchema generates the schema of the embedded struct type t as a part of the allOf of the embedding
+// object, which is left open, as closing a part would reject the properties of the other parts.
+func (g *schemaGenerator) reflectEmbeddedSchema(t reflect.Type) (*Property, error) {
+	s, err := g.reflectSchemaByType(t)
+	if err != nil {
+		return nil, err
+	}
+	if s.AdditionalProperties == false {
+		s.AdditionalProperties = nil
+	}
+	s.UnevaluatedProperties = nil
+	return s, nil
+}
+
+// derefKind returns the kind of t, or of the type t points to
+func derefKind(t reflect.Type) reflect.Kind {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().Kind()
+	}
+	return t.Kind()
+}
+
+// mapKeySchema returns the propertyNames schema of the keys of map type t, nil for string keys.
+// Integer keys, marshaled as decimal strings by encoding/json, are only accepted with WithStringifiedIntKeys.
+func (g *schemaGenerator) mapKeySchema(t reflect.Type) (*Property, error) {
+	switch t.Key().Kind() {
+	case reflect.String:
+		return nil, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if g.opts.stringifiedIntKeys {
+			return &Property{Type: String, Pattern: `^-?\d+$`}, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if g.opts.stringifiedIntKeys {
+			return &Property{Type: String, Pattern: `^\d+$`}, nil
+		}
+	}
+	return nil, fmt.Errorf("map key type %s is not supported", t.Key().Kind())
+}
+
+// reflectMapValueSchema returns the additionalProperties describing the values of map type t,
+// maps of empty interfaces accept any value.
+func (g *schemaGenerator) reflectMapValueSchema(t reflect.Type) (any, error) {
+	if t.Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("map key type %s is not supported", t.Key().Kind())
+	}
+	if t.Elem().Kind() == reflect.Interface && t.Elem().NumMethod() == 0 {
+		return true, nil
+	}
+	return g.reflectSchemaByType(t.Elem())
+}
+
+// numericFormats are the formats of numbers, by type, as defined by OpenAPI
+var numericFormats = map[DataType][]string{
+	Integer: {"int32", "int64"},
+	Number:  {"float", "double"},
+}
+
+// checkFormat reports whether format applies to values of type dataType.
+// Strings accept any format, e.g. "email" or the "int64" of integers encoded as strings,
+// while numbers only accept the numeric formats of their type.
+func checkFormat(dataType DataType, format string) error {
+	switch dataType {
+	case String:
+		return nil
+	case Integer, Number:
+		for _, f := range numericFormats[dataType] {
+			if f == format {
+				return nil
+			}
+		}
+		return fmt.Errorf("format %q does not apply to %s values, expected one of %v", format, dataType, numericFormats[dataType])
+	default:
+		return fmt.Errorf("format %q does not apply to %s values", format, dataType)
+	}
+}
+
+// reflectSchemaByField generates the property of a struct field from its type and tags,
+// returning the property name and whether the property is required.
+func (g *schemaGenerator) reflectSchemaByField(field reflect.StructField) (string, *Property, bool, error) {
+	jsonTag, jsonOptions := parseJSONTag(field.Tag.Get("json"))
+	if jsonTag == "" {
+		jsonTag = field.Name
+	}
+	// nil pointers and NULL wrappers are the natural absence of a value, such fields are optional even without omitempty
+	required := g.opts.strictRequired || !jsonOptions.contains("omitempty") && !isNullableType(field.Type)
+
+	item, err := g.reflectFieldType(field)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	// the tags of NULL wrappers such as sql.NullString describe their value
+	valueType := field.Type
+	if value, ok := nullWrapperValue(valueType); ok {
+		valueType = value.Type
+	}
+
+	if title := field.Tag.Get("title"); title != "" {
+		item.Title = title
+	} else if g.opts.titleFromFieldName {
+		item.Title = humanize(field.Name)
+	}
+
+	if description := field.Tag.Get("description"); description != "" {
+		item.Description = description
+	}
+
+	if s := field.Tag.Get("required"); s != "" {
+		required, err = strconv.ParseBool(s)
+		if err != nil {
+			return "", nil, false, fmt.Errorf("invalid required field %v: %v", jsonTag, err)
+		}
+	}
+
+	if s := field.Tag.Get("readOnly"); s != "" {
+		item.ReadOnly, err = strconv.ParseBool(s)
+		if err != nil {
+			return "", nil, false, fmt.Errorf("invalid readOnly field %v: %v", jsonTag, err)
+		}
+	}
+
+	// the enum of slices and arrays applies to their items
+	enumItem, enumType := item, valueType
+	if item.Type == Array && item.Items != nil && item.Items.Ref == "" {
+		for enumType.Kind() == reflect.Ptr {
+			enumType = enumType.Elem()
+		}
+		enumItem, enumType = item.Items, enumType.Elem()
+	}
 
-func generateSchemaFromReqStruct(v any) (*InputSchema, error) {
-	t := reflect.TypeOf(v)
-	for t.Kind() != reflect.Struct {
-		if t.Kind() != reflect.Ptr {
-			return nil, fmt.Errorf("invalid type %v", t)
+	if v := field.Tag.Get("enum"); v != "" {
+		if enumItem.Type == ObjectT && derefKind(enumType) == reflect.Struct {
+			enumItem.Enum, err = g.parseObjectEnum(enumItem, v)
+		} else {
+			enumItem.Enum, err = g.parseEnumValues(enumType, v)
+		}
+		if err != nil {
+			return "", nil, false, fmt.Errorf("invalid enum of field %v: %w", jsonTag, err)
+		}
+		if enumItem.Nullable {
+			enumItem.Enum = append(enumItem.Enum, nil)
 		}
-		t = t.Elem()
 	}
 
-	typeUID := getTypeUUID(t)
-	if schema, ok := schemaCache.Load(typeUID); ok {
-		return schema, nil
+	if v := field.Tag.Get("enumLabels"); v != "" {
+		labels := strings.Split(v, ",")
+		for j := range labels {
+			labels[j] = strings.TrimSpace(labels[j])
+		}
+		if len(labels) != len(enumItem.Enum) {
+			return "", nil, false, fmt.Errorf("enumLabels of field %v has %d labels for %d enum values", jsonTag, len(labels), len(enumItem.Enum))
+		}
+		enumItem.setExtension("x-enumLabels", labels)
 	}
 
-	schema := &InputSchema{Type: Object}
+	if g.opts.enumDescriptionsFromMethod {
+		if err = g.applyEnumDescriptions(enumItem, enumType); err != nil {
+			return "", nil, false, fmt.Errorf("invalid enum descriptions of field %v: %w", jsonTag, err)
+		}
+	}
 
-	property, err := reflectSchemaByObject(t)
-	if err != nil {
-		return nil, err
+	if v := field.Tag.Get("const"); v != "" {
+		if item.Const, err = g.parseDefaultValue(item, valueType, v); err != nil {
+			return "", nil, false, fmt.Errorf("invalid const of field %v: %w", jsonTag, err)
+		}
+		if len(item.Enum) > 0 && !containsEnumValue(item.Enum, item.Const) {
+			return "", nil, false, fmt.Errorf("const %v of field %v is not a member of its enum", item.Const, jsonTag)
+		}
 	}
 
-	schema.Properties = property.Properties
-	schema.Required = property.Required
+	if v := field.Tag.Get("examples"); v != "" {
+		if item.Examples, err = g.parseEnumValues(valueType, v); err != nil {
+			return "", nil, false, fmt.Errorf("invalid examples of field %v: %w", jsonTag, err)
+		}
+	}
 
-	schemaCache.Store(typeUID, schema)
-	return schema, nil
-}
+	// Handle default value
+	if defaultValue := field.Tag.Get("default"); defaultValue != "" {
+		if item.Default, err = g.parseDefaultValue(item, valueType, defaultValue); err != nil {
+			return "", nil, false, err
+		}
+	}
 
-func getTypeUUID(t reflect.Type) string {
-	if t.PkgPath() != "" && t.Name() != "" {
-		return t.PkgPath() + "." + t.Name()
+	if v := field.Tag.Get("format"); v != "" {
+		if err = checkFormat(item.Type, v); err != nil {
+			return "", nil, false, fmt.Errorf("invalid format of field %v of type %v: %w", jsonTag, field.Type, err)
+		}
+		item.Format = v
 	}
-	// fallback for unnamed types (like anonymous struct)
-	return t.String()
+
+	if v := field.Tag.Get("pattern"); v != "" {
+		if err = applyPattern(item, v); err != nil {
+			return "", nil, false, fmt.Errorf("invalid pattern of field %v: %w", jsonTag, err)
+		}
+	}
+
+	if err = g.applyBounds(item, field); err != nil {
+		return "", nil, false, err
+	}
+
+	if err = applyLengths(item, field); err != nil {
+		return "", nil, false, err
+	}
+
+	if err = g.applyPrecision(item, field); err != nil {
+		return "", nil, false, err
+	}
+
+	// The default is checked once all the constraints of the tags apply, whatever their order
+	if item.Default != nil && item.Ref == "" && !validate(*item, item.Default) {
+		return "", nil, false, fmt.Errorf("default value %v of field %v does not satisfy the constraints of its schema", item.Default, jsonTag)
+	}
+
+	if v := field.Tag.Get("nullable"); v != "" {
+		nullable, err := strconv.ParseBool(v)
+		if err != nil {
+			return "", nil, false, fmt.Errorf("invalid nullable field %v: %v", jsonTag, err)
+		}
+		if nullable {
+			applyNullable(item)
+		}
+	}
+
+	if jsonOptions.contains("string") {
+		applyJSONStringOption(item, field.Type)
+	}
+
+	// slices described as strings, e.g. the base64 of []byte, have no empty array default
+	if !required && item.Default == nil && g.opts.emptySliceAsEmptyArrayDefault && item.Type == Array && field.Type.Kind() == reflect.Slice {
+		item.Default = []any{}
+	}
+
+	return jsonTag, item, required, nil
 }
 
-func reflectSchemaByObject(t reflect.Type) (*Property, error) {
-	var (
-		properties      = make(map[string]*Property)
-		requiredFields  = make([]string, 0)
-		anonymousFields = make([]reflect.StructField, 0)
-	)
+// reflectFieldType generates the schema of the type of the field, or of the type named by its `schemaType` tag.
+func (g *schemaGenerator) reflectFieldType(field reflect.StructField) (*Property, error) {
+	name := field.Tag.Get("schemaType")
+	if name == "" {
+		return g.reflectSchemaByType(field.Type)
+	}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
+	t, ok := lookupNamedType(name)
+	if !ok {
+		return nil, fmt.Errorf("schemaType %q of field %s is not registered", name, field.Name)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return g.defineType(t, func() (*Property, error) {
+		return g.reflectSchemaByType(t)
+	})
+}
 
-		if field.Anonymous {
-			anonymousFields = append(anonymousFields, field)
-			continue
+// humanize splits the camel case name into capitalized words, keeping acronyms together,
+// e.g. "maxRetries" becomes "Max Retries" and "HTTPServerURL" becomes "HTTP Server URL".
+func humanize(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte(' ')
+			}
+		}
+		if i == 0 {
+			r = unicode.ToUpper(r)
 		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
 
-		if !field.IsExported() {
+// applyBounds sets the minimum and maximum of a numeric property from the `minimum` and `maximum` tags.
+func (g *schemaGenerator) applyBounds(item *Property, field reflect.StructField) error {
+	for _, bound := range []struct {
+		tag   string
+		value **float64
+	}{
+		{tag: "minimum", value: &item.Minimum},
+		{tag: "maximum", value: &item.Maximum},
+	} {
+		v := field.Tag.Get(bound.tag)
+		if v == "" {
 			continue
 		}
+		if item.Type != Integer && item.Type != Number {
+			return fmt.Errorf("%s of field %s is only supported for numeric types, got %v", bound.tag, field.Name, field.Type)
+		}
+		f, err := g.parseNumber(v)
+		if err != nil {
+			return fmt.Errorf("%s %q of field %s is not a number", bound.tag, v, field.Name)
+		}
+		*bound.value = &f
+	}
+	if item.Minimum != nil && item.Maximum != nil && *item.Minimum > *item.Maximum {
+		return fmt.Errorf("minimum %v of field %s exceeds its maximum %v", *item.Minimum, field.Name, *item.Maximum)
+	}
+	return nil
+}
 
-		jsonTag := field.Tag.Get("json")
-		if jsonTag == "-" {
+// applyLengths sets the minLength and maxLength of a string property from the `minLength` and `maxLength` tags.
+func applyLengths(item *Property, field reflect.StructField) error {
+	for _, length := range []struct {
+		tag   string
+		value **int
+	}{
+		{tag: "minLength", value: &item.MinLength},
+		{tag: "maxLength", value: &item.MaxLength},
+	} {
+		v := field.Tag.Get(length.tag)
+		if v == "" {
 			continue
 		}
-		required := true
-		if jsonTag == "" {
-			jsonTag = field.Name
+		if item.Type != String {
+			return fmt.Errorf("%s of field %s is only supported for strings, got %v", length.tag, field.Name, field.Type)
 		}
-		if strings.HasSuffix(jsonTag, ",omitempty") {
-			jsonTag = strings.TrimSuffix(jsonTag, ",omitempty")
-			required = false
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s %q of field %s is not a non-negative integer", length.tag, v, field.Name)
 		}
+		*length.value = &n
+	}
+	if item.MinLength != nil && item.MaxLength != nil && *item.MinLength > *item.MaxLength {
+		return fmt.Errorf("minLength %d of field %s exceeds its maxLength %d", *item.MinLength, field.Name, *item.MaxLength)
+	}
+	return nil
+}
 
-		item, err := reflectSchemaByType(field.Type)
-		if err != nil {
-			return nil, err
-		}
+// applyPattern sets the pattern of the string property, checking that its default matches the pattern.
+func applyPattern(item *Property, pattern string) error {
+	if item.Type != String {
+		return fmt.Errorf("pattern is only supported for strings, got %s", item.Type)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	if str, ok := item.Default.(string); ok && !re.MatchString(str) {
+		return fmt.Errorf("default value %q does not match pattern %q", str, pattern)
+	}
+	item.Pattern = pattern
+	return nil
+}
 
-		if description := field.Tag.Get("description"); description != "" {
-			item.Description = description
+// applyPrecision converts the number of decimal places of the `precision` tag,
+// or the default of WithNumberPrecision for float fields, to the multipleOf of the property.
+func (g *schemaGenerator) applyPrecision(item *Property, field reflect.StructField) error {
+	precision := g.opts.numberPrecision
+	if v := field.Tag.Get("precision"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 0 {
+			return fmt.Errorf("precision %q of field %s is not a non-negative integer", v, field.Name)
 		}
-		properties[jsonTag] = item
+		if item.Type != Number {
+			return fmt.Errorf("precision of field %s is only supported for float types, got %v", field.Name, field.Type)
+		}
+		precision = &p
+	}
+
+	if precision != nil && item.Type == Number {
+		multipleOf := math.Pow10(-*precision)
+		item.MultipleOf = &multipleOf
+	}
+	return nil
+}
+
+// applyNullable allows null for the property: the type becomes a type array including "null"
+// and null is added to the enum, so that validators checking the enum alone accept null as well.
+// References cannot carry a type, so they are wrapped in a oneOf with the null type instead.
+func applyNullable(item *Property) {
+	if item.Ref != "" {
+		item.OneOf = []*Property{{Ref: item.Ref}, {Type: Null}}
+		item.Ref = ""
+		return
+	}
+	if item.Nullable {
+		return
+	}
+	item.Nullable = true
+	if len(item.Enum) > 0 {
+		item.Enum = append(item.Enum, nil)
+	}
+}
+
+// nullWrapperValue returns the field holding the value of t if t is a wrapper of a nullable column,
+// i.e. a scanner with a Valid field and the value field like sql.Null[T], which marshals itself to JSON
+// as its value or null. Such wrappers are described as their nullable value, while the database/sql types
+// themselves, which encoding/json encodes as {"String": ..., "Valid": ...} objects, are described as these objects.
+func nullWrapperValue(t reflect.Type) (reflect.StructField, bool) {
+	if t.Kind() != reflect.Struct || t.NumField() != 2 || !reflect.PtrTo(t).Implements(sqlScannerType) ||
+		!implements(t, jsonMarshalerType) || !implements(t, jsonUnmarshalerType) {
+		return reflect.StructField{}, false
+	}
+	valid, ok := t.FieldByName("Valid")
+	if !ok || valid.Type.Kind() != reflect.Bool {
+		return reflect.StructField{}, false
+	}
+	value := t.Field(0)
+	if value.Name == valid.Name {
+		value = t.Field(1)
+	}
+	return value, value.IsExported()
+}
+
+var sqlScannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// isNullableType reports whether the values of t can be absent, which is the case of pointers and NULL wrappers
+func isNullableType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		return true
+	}
+	_, ok := nullWrapperValue(t)
+	return ok
+}
+
+// skipField reports whether the field failing with err can be skipped,
+// which is only the case for lenient generations recording err as a warning.
+func (g *schemaGenerator) skipField(t reflect.Type, field reflect.StructField, err error) bool {
+	if !g.lenient || g.ctx.Err() != nil {
+		return false
+	}
+	g.warnings = append(g.warnings, fmt.Errorf("field %s of %v skipped: %w", field.Name, t, err))
+	return true
+}
+
+// parseEnumValues converts the comma separated values of the `enum` tag to the type of the field.
+func (g *schemaGenerator) parseEnumValues(fieldType reflect.Type, tag string) ([]any, error) {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	enumStrings := strings.Split(tag, ",")
+	enumValues := make([]any, len(enumStrings))
+
+	for j, value := range enumStrings {
+		value = strings.TrimSpace(value)
 
-		if s := field.Tag.Get("required"); s != "" {
-			required, err = strconv.ParseBool(s)
+		// Convert string values to appropriate types based on field type
+		switch fieldType.Kind() {
+		case reflect.String:
+			enumValues[j] = value
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			intVal, err := strconv.Atoi(value)
 			if err != nil {
-				return nil, fmt.Errorf("invalid required field %v: %v", jsonTag, err)
+				return nil, fmt.Errorf("enum value %q is not compatible with integer type %v", value, fieldType)
 			}
-		}
-		if required {
-			requiredFields = append(requiredFields, jsonTag)
-		}
-
-		if v := field.Tag.Get("enum"); v != "" {
-			enumStrings := strings.Split(v, ",")
-			enumValues := make([]any, len(enumStrings))
-
-			for j, value := range enumStrings {
-				value = strings.TrimSpace(value)
-
-				// Convert string values to appropriate types based on field type
-				switch field.Type.Kind() {
-				case reflect.String:
-					enumValues[j] = value
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-					intVal, err := strconv.Atoi(value)
-					if err != nil {
-						return nil, fmt.Errorf("enum value %q is not compatible with integer type %v", value, field.Type)
-					}
-					enumValues[j] = intVal
-				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-					uintVal, err := strconv.ParseUint(value, 10, 64)
-					if err != nil {
-						return nil, fmt.Errorf("enum value %q is not compatible with unsigned integer type %v", value, field.Type)
-					}
-					enumValues[j] = uintVal
-				case reflect.Float32, reflect.Float64:
-					floatVal, err := strconv.ParseFloat(value, 64)
-					if err != nil {
-						return nil, fmt.Errorf("enum value %q is not compatible with float type %v", value, field.Type)
-					}
-					enumValues[j] = floatVal
-				case reflect.Bool:
-					boolVal, err := strconv.ParseBool(value)
-					if err != nil {
-						return nil, fmt.Errorf("enum value %q is not compatible with boolean type %v", value, field.Type)
-					}
-					enumValues[j] = boolVal
-				default:
-					return nil, fmt.Errorf("unsupported type %v for enum validation", field.Type)
-				}
+			enumValues[j] = intVal
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			uintVal, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("enum value %q is not compatible with unsigned integer type %v", value, fieldType)
 			}
-			item.Enum = enumValues
-		}
-
-		// Handle default value
-		if defaultValue := field.Tag.Get("default"); defaultValue != "" {
-			// Convert string value to appropriate type based on field type
-			switch field.Type.Kind() {
-			case reflect.String:
-				item.Default = defaultValue
-			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-				intVal, err := strconv.Atoi(defaultValue)
-				if err != nil {
-					return nil, fmt.Errorf("default value %q is not compatible with integer type %v", defaultValue, field.Type)
-				}
-				item.Default = intVal
-			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-				uintVal, err := strconv.ParseUint(defaultValue, 10, 64)
-				if err != nil {
-					return nil, fmt.Errorf("default value %q is not compatible with unsigned integer type %v", defaultValue, field.Type)
-				}
-				item.Default = uintVal
-			case reflect.Float32, reflect.Float64:
-				floatVal, err := strconv.ParseFloat(defaultValue, 64)
-				if err != nil {
-					return nil, fmt.Errorf("default value %q is not compatible with float type %v", defaultValue, field.Type)
-				}
-				item.Default = floatVal
-			case reflect.Bool:
-				boolVal, err := strconv.ParseBool(defaultValue)
-				if err != nil {
-					return nil, fmt.Errorf("default value %q is not compatible with boolean type %v", defaultValue, field.Type)
-				}
-				item.Default = boolVal
-			default:
-				// For complex types (arrays, objects), keep as string
-				// The consumer can parse it as needed
-				item.Default = defaultValue
+			enumValues[j] = uintVal
+		case reflect.Float32, reflect.Float64:
+			floatVal, err := g.parseNumber(value)
+			if err != nil {
+				return nil, fmt.Errorf("enum value %q is not compatible with float type %v", value, fieldType)
+			}
+			enumValues[j] = floatVal
+		case reflect.Bool:
+			boolVal, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("enum value %q is not compatible with boolean type %v", value, fieldType)
 			}
+			enumValues[j] = boolVal
+		default:
+			return nil, fmt.Errorf("unsupported type %v for enum validation", fieldType)
 		}
 	}
+	return enumValues, nil
+}
 
-	for _, field := range anonymousFields {
-		object, err := reflectSchemaByObject(field.Type)
+// applyEnumDescriptions emits the descriptions of the enum values listed by the EnumDescriptions method
+// of the type t as the x-enumDescriptions keyword, keyed by the values. The descriptions of types with a
+// registered enum are emitted on their definition.
+func (g *schemaGenerator) applyEnumDescriptions(item *Property, t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !t.Implements(enumDescriptionsProviderType) {
+		return nil
+	}
+	target := item
+	if def := resolveDef(g.defs, item); def != nil {
+		target = def
+	}
+
+	descriptions := make(map[string]string)
+	for value, description := range reflect.Zero(t).Interface().(EnumDescriptionsProvider).EnumDescriptions() {
+		v := reflect.ValueOf(value)
+		if !v.IsValid() {
+			return fmt.Errorf("description %q for a nil enum value", description)
+		}
+		enumValue := enumValueOf(v)
+		if len(target.Enum) > 0 && !containsEnumValue(target.Enum, enumValue) {
+			return fmt.Errorf("description for %v, which is not a member of the enum", enumValue)
+		}
+		descriptions[fmt.Sprint(enumValue)] = description
+	}
+	target.setExtension("x-enumDescriptions", descriptions)
+	return nil
+}
+
+// parseObjectEnum parses the `enum` tag of a struct field, a JSON array of the allowed objects,
+// e.g. `enum:"[{\"width\":800,\"height\":600},{\"width\":1024,\"height\":768}]"`. Each object must be valid against item.
+func (g *schemaGenerator) parseObjectEnum(item *Property, tag string) ([]any, error) {
+	var values []any
+	if err := pkg.JSONUnmarshal([]byte(tag), &values); err != nil {
+		return nil, fmt.Errorf("enum of an object is not a JSON array: %w", err)
+	}
+	for _, value := range values {
+		if !g.validator().validate(*item, value) {
+			return nil, fmt.Errorf("enum value %v is not compatible with the schema of the object", value)
+		}
+	}
+	return values, nil
+}
+
+// parseNumber parses the numeric value of a tag with the parser of WithNumberParser, or strconv.ParseFloat by default.
+func (g *schemaGenerator) parseNumber(s string) (float64, error) {
+	if g.opts.numberParser != nil {
+		return g.opts.numberParser(s)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// jsonTagOptions are the comma separated options following the name in a json struct tag
+type jsonTagOptions []string
+
+func (o jsonTagOptions) contains(option string) bool {
+	for _, v := range o {
+		if v == option {
+			return true
+		}
+	}
+	return false
+}
+
+// parseJSONTag splits a json struct tag into the property name and its options,
+// the name is empty for tags only carrying options such as `json:",omitempty"`.
+func parseJSONTag(tag string) (string, jsonTagOptions) {
+	name, options, found := strings.Cut(tag, ",")
+	if !found {
+		return name, nil
+	}
+	return name, strings.Split(options, ",")
+}
+
+// applyJSONStringOption describes scalar fields tagged with the `,string` option as strings,
+// as encoding/json marshals their values quoted.
+func applyJSONStringOption(item *Property, fieldType reflect.Type) {
+	// like encoding/json, the option also applies to an unnamed pointer to a scalar
+	if fieldType.Kind() == reflect.Ptr && fieldType.Name() == "" {
+		fieldType = fieldType.Elem()
+	}
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool:
+	default:
+		return
+	}
+
+	item.Type = String
+	for i, value := range item.Enum {
+		if value != nil {
+			item.Enum[i] = fmt.Sprint(value)
+		}
+	}
+	if item.Default != nil {
+		item.Default = fmt.Sprint(item.Default)
+	}
+}
+
+// parseDefaultValue converts the `default` tag value to the type of the field.
+// The values of string schemas are kept as is, including those of types described as strings such as time.Time,
+// and the values of complex types (arrays, objects) are parsed as JSON and validated against the field schema.
+func (g *schemaGenerator) parseDefaultValue(item *Property, fieldType reflect.Type, defaultValue string) (any, error) {
+	switch item.Type {
+	case String:
+		return defaultValue, nil
+	case ObjectT, Array:
+		return g.parseJSONDefaultValue(item, fieldType, defaultValue)
+	}
+
+	// Convert string value to appropriate type based on field type
+	switch fieldType.Kind() {
+	case reflect.String:
+		return defaultValue, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intVal, err := strconv.Atoi(defaultValue)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("default value %q is not compatible with integer type %v", defaultValue, fieldType)
 		}
-		for propName, propValue := range object.Properties {
-			if _, ok := properties[propName]; ok {
-				return nil, fmt.Errorf("duplicate property name %s in anonymous struct", propName)
-			}
-			properties[propName] = propValue
+		return intVal, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintVal, err := strconv.ParseUint(defaultValue, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("default value %q is not compatible with unsigned integer type %v", defaultValue, fieldType)
+		}
+		return uintVal, nil
+	case reflect.Float32, reflect.Float64:
+		floatVal, err := g.parseNumber(defaultValue)
+		if err != nil {
+			return nil, fmt.Errorf("default value %q is not compatible with float type %v", defaultValue, fieldType)
 		}
-		requiredFields = append(requiredFields, object.Required...)
+		return floatVal, nil
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(defaultValue)
+		if err != nil {
+			return nil, fmt.Errorf("default value %q is not compatible with boolean type %v", defaultValue, fieldType)
+		}
+		return boolVal, nil
+	default:
+		return g.parseJSONDefaultValue(item, fieldType, defaultValue)
 	}
+}
 
-	property := &Property{
-		Type:       ObjectT,
-		Properties: properties,
-		Required:   requiredFields,
+// parseJSONDefaultValue parses the default value of a complex type, expected to be JSON,
+// escaped quotes are already resolved by the struct tag parser.
+func (g *schemaGenerator) parseJSONDefaultValue(item *Property, fieldType reflect.Type, defaultValue string) (any, error) {
+	var value any
+	if err := pkg.JSONUnmarshal([]byte(defaultValue), &value); err != nil {
+		return nil, fmt.Errorf("default value %q is not valid JSON for type %v: %v", defaultValue, fieldType, err)
 	}
-	return property, nil
+	if !g.validator().validate(*item, value) {
+		return nil, fmt.Errorf("default value %q is not compatible with type %v", defaultValue, fieldType)
+	}
+	return value, nil
+}
+
+// SchemaDefaultsProvider can be implemented by request structs to supply default values
+// programmatically, avoiding awkward escaping of complex values in `default` tags.
+// The keys of the returned map are the JSON property names of the struct.
+type SchemaDefaultsProvider interface {
+	SchemaDefaults() map[string]any
+}
+
+// InputSchemaProvider can be implemented by request structs to replace the generation entirely with a hand-crafted schema.
+// The generator returns a copy of the schema as is, ignoring the fields and tags of the struct as well as the options.
+type InputSchemaProvider interface {
+	MCPInputSchema() *InputSchema
+}
+
+// SchemaIDProvider can be implemented by request structs to identify their schema with the returned $id,
+// unless WithSchemaID is used.
+type SchemaIDProvider interface {
+	SchemaID() string
+}
+
+// SchemaTitleProvider can be implemented by request structs to set the title of their schema,
+// the counterpart of the `title` tag of fields, unless WithTitle is used.
+type SchemaTitleProvider interface {
+	SchemaTitle() string
 }
 
-func reflectSchemaByType(t reflect.Type) (*Property, error) {
+// SchemaDependentSchemasProvider can be implemented by request structs to apply an additional subschema
+// when a property is present, e.g. requiring "cvv" whenever "card_number" is given.
+// The keys of the returned map are the JSON property names of the struct, the schemas are emitted as dependentSchemas.
+type SchemaDependentSchemasProvider interface {
+	SchemaDependentSchemas() map[string]*InputSchema
+}
+
+func (g *schemaGenerator) applySchemaDefaults(t reflect.Type, properties map[string]*Property) error {
+	provider, ok := reflect.New(t).Interface().(SchemaDefaultsProvider)
+	if !ok {
+		return nil
+	}
+
+	for name, value := range provider.SchemaDefaults() {
+		item, ok := properties[name]
+		if !ok {
+			return fmt.Errorf("default value provided for unknown property %s of type %v", name, t)
+		}
+
+		// Normalize the value to its JSON representation before validating it against the schema
+		content, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("default value of property %s can not be marshaled: %v", name, err)
+		}
+		var data any
+		if err = pkg.JSONUnmarshal(content, &data); err != nil {
+			return err
+		}
+		if !g.validator().validate(*item, data) {
+			return fmt.Errorf("default value %s is not compatible with property %s", content, name)
+		}
+		item.Default = value
+	}
+	return nil
+}
+
+// SchemaExamplesProvider can be implemented by request structs to supply example values programmatically,
+// appended to the examples of the `examples` tags. The keys of the returned map are the JSON property names of the struct.
+type SchemaExamplesProvider interface {
+	SchemaExamples() map[string][]any
+}
+
+func applySchemaExamples(t reflect.Type, properties map[string]*Property) error {
+	provider, ok := reflect.New(t).Interface().(SchemaExamplesProvider)
+	if !ok {
+		return nil
+	}
+
+	for name, examples := range provider.SchemaExamples() {
+		item, ok := properties[name]
+		if !ok {
+			return fmt.Errorf("examples provided for unknown property %s of type %v", name, t)
+		}
+		item.Examples = append(item.Examples, examples...)
+	}
+	return nil
+}
+
+// reflectRegisteredSchema generates the schema of type t from its registration
+func (g *schemaGenerator) reflectRegisteredSchema(t reflect.Type, ts typeSchema) (*Property, error) {
+	if len(ts.variants) == 0 {
+		s := ts.schema()
+		if ts.definition && !g.opts.inlineEnums {
+			return g.define(t, s), nil
+		}
+		return s, nil
+	}
+
+	// The variants of a union are defined once and referenced by the oneOf of the union
+	s := &Property{OneOf: make([]*Property, 0, len(ts.variants))}
+	for _, variant := range ts.variants {
+		for variant.Kind() == reflect.Ptr {
+			variant = variant.Elem()
+		}
+		ref, err := g.defineType(variant, func() (*Property, error) {
+			return g.reflectSchemaByType(variant)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("union variant %v of %v: %w", variant, t, err)
+		}
+		s.OneOf = append(s.OneOf, ref)
+	}
+	return s, nil
+}
+
+// schemaTypes are the types describing a JSON Schema themselves.
+var schemaTypes = map[reflect.Type]bool{
+	reflect.TypeOf(InputSchema{}):  true,
+	reflect.TypeOf(OutputSchema{}): true,
+	reflect.TypeOf(Property{}):     true,
+}
+
+func (g *schemaGenerator) reflectSchemaByType(t reflect.Type) (*Property, error) {
+	if err := g.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if ts, ok := lookupTypeSchema(t); ok {
+		return g.reflectRegisteredSchema(t, ts)
+	}
+
+	// A parameter which is a JSON Schema itself (e.g. for meta tools) is described as an
+	// unconstrained object, reflecting on the recursive schema types would never terminate.
+	if schemaTypes[t] {
+		return &Property{Type: ObjectT}, nil
+	}
+
+	// See SchemaProvider for the precedence of the rules resolving the schema of a type
+	if t.Kind() != reflect.Ptr && implements(t, schemaProviderType) {
+		s := reflect.New(t).Interface().(SchemaProvider).JSONSchema()
+		if s == nil {
+			return nil, fmt.Errorf("the SchemaProvider %v returned a nil schema", t)
+		}
+		return s, nil
+	}
+	if t.Kind() != reflect.Ptr && implements(t, textMarshalerType) && !implements(t, jsonMarshalerType) {
+		return &Property{Type: String}, nil
+	}
+
+	if t.Kind() == reflect.String && t.Implements(contentSchemaProviderType) {
+		return g.reflectContentSchema(t)
+	}
+
 	s := &Property{}
 
 	switch t.Kind() {
@@ -236,40 +1761,97 @@ func reflectSchemaByType(t reflect.Type) (*Property, error) {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		s.Type = Integer
+		if g.opts.enumFromStringer && t.Implements(stringerType) {
+			if values, names := stringerEnum(t); len(values) > 0 {
+				s.Enum = values
+				s.setExtension("x-enumLabels", names)
+			}
+		}
 	case reflect.Float32, reflect.Float64:
 		s.Type = Number
 	case reflect.Bool:
 		s.Type = Boolean
 	case reflect.Slice, reflect.Array:
+		// encoding/json marshals []byte as a base64 string, but fixed size byte arrays as arrays of integers
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			s.Type = String
+			s.ContentEncoding = "base64"
+			break
+		}
 		s.Type = Array
-		items, err := reflectSchemaByType(t.Elem())
+		items, err := g.reflectSchemaByType(t.Elem())
 		if err != nil {
 			return nil, err
 		}
 		s.Items = items
+		if g.opts.itemEnumFromMethod && len(items.Enum) == 0 && items.Ref == "" && t.Elem().Implements(enumProviderType) {
+			values := reflect.Zero(t.Elem()).Interface().(EnumProvider).EnumValues()
+			if _, items.Enum, err = enumValuesOf(t.Elem(), values); err != nil {
+				return nil, err
+			}
+		}
+		if t.Kind() == reflect.Array {
+			length := t.Len()
+			s.MinItems, s.MaxItems = &length, &length
+		}
 	case reflect.Struct:
-		object, err := reflectSchemaByObject(t)
+		if value, ok := nullWrapperValue(t); ok {
+			p, err := g.reflectSchemaByType(value.Type)
+			if err != nil {
+				return nil, err
+			}
+			applyNullable(p)
+			return p, nil
+		}
+		object, err := g.reflectSchemaByObject(t)
 		if err != nil {
 			return nil, err
 		}
 		object.Type = ObjectT
+		if g.opts.goTypeKeyword != "" && t.Name() != "" {
+			object.setExtension(g.opts.goTypeKeyword, t.String())
+		}
+		if g.opts.structuralDedup && t.Name() == "" {
+			if _, ok := g.anonymous[t]; !ok {
+				g.anonymousTypes = append(g.anonymousTypes, t)
+			}
+			g.anonymous[t] = append(g.anonymous[t], object)
+		}
 		s = object
 	case reflect.Map:
-		if t.Key().Kind() != reflect.String {
-			return nil, fmt.Errorf("map key type %s is not supported", t.Key().Kind())
+		propertyNames, err := g.mapKeySchema(t)
+		if err != nil {
+			return nil, err
 		}
 		object := &Property{
-			Type: ObjectT,
+			Type:          ObjectT,
+			PropertyNames: propertyNames,
+		}
+		// values are only described for registered types such as unions, other maps stay free-form objects
+		elem := t.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if _, ok := lookupTypeSchema(elem); ok {
+			additional, err := g.reflectSchemaByType(elem)
+			if err != nil {
+				return nil, err
+			}
+			object.AdditionalProperties = additional
 		}
 		s = object
 	case reflect.Ptr:
-		p, err := reflectSchemaByType(t.Elem())
+		p, err := g.reflectSchemaByType(t.Elem())
 		if err != nil {
 			return nil, err
 		}
 		s = p
+	case reflect.Interface:
+		// Also the case of generic fields whose type parameter is instantiated with its constraint interface
+		return nil, fmt.Errorf("unsupported type: %v is an interface, not a concrete type: "+
+			"instantiate generic types with concrete type arguments or register a schema for the type", t)
 	case reflect.Invalid, reflect.Uintptr, reflect.Complex64, reflect.Complex128,
-		reflect.Chan, reflect.Func, reflect.Interface,
+		reflect.Chan, reflect.Func,
 		reflect.UnsafePointer:
 		return nil, fmt.Errorf("unsupported type: %s", t.Kind().String())
 	default:
@@ -1,9 +1,20 @@
 package protocol
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 func TestGenerateSchemaFromReqStruct(t *testing.T) {
@@ -365,7 +376,11 @@ func compareInputSchema(a, b *InputSchema) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	if a.Type != b.Type {
+	if a.Type != b.Type || a.ID != b.ID || a.Title != b.Title {
+		return false
+	}
+	if !compareProperty(&Property{AllOf: a.AllOf, UnevaluatedProperties: a.UnevaluatedProperties},
+		&Property{AllOf: b.AllOf, UnevaluatedProperties: b.UnevaluatedProperties}) {
 		return false
 	}
 
@@ -399,6 +414,15 @@ func compareInputSchema(a, b *InputSchema) bool {
 		}
 	}
 
+	if len(a.Defs) != len(b.Defs) {
+		return false
+	}
+	for k, aDef := range a.Defs {
+		if !compareProperty(aDef, b.Defs[k]) {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -410,10 +434,13 @@ func compareProperty(a, b *Property) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	if a.Type != b.Type {
+	if a.Type != b.Type || a.Ref != b.Ref || a.Nullable != b.Nullable || a.ReadOnly != b.ReadOnly {
+		return false
+	}
+	if !reflect.DeepEqual(a.Const, b.Const) || !reflect.DeepEqual(a.Examples, b.Examples) {
 		return false
 	}
-	if a.Description != b.Description {
+	if a.Description != b.Description || a.Title != b.Title {
 		return false
 	}
 
@@ -461,6 +488,55 @@ func compareProperty(a, b *Property) bool {
 		return false
 	}
 
+	if a.Format != b.Format || a.Pattern != b.Pattern || a.ContentEncoding != b.ContentEncoding || a.ContentMediaType != b.ContentMediaType {
+		return false
+	}
+	if !compareProperty(a.ContentSchema, b.ContentSchema) || !compareProperty(a.PropertyNames, b.PropertyNames) {
+		return false
+	}
+	if !reflect.DeepEqual(a.MinLength, b.MinLength) || !reflect.DeepEqual(a.MaxLength, b.MaxLength) {
+		return false
+	}
+	if !reflect.DeepEqual(a.Minimum, b.Minimum) || !reflect.DeepEqual(a.Maximum, b.Maximum) {
+		return false
+	}
+	if !reflect.DeepEqual(a.MinItems, b.MinItems) || !reflect.DeepEqual(a.MaxItems, b.MaxItems) {
+		return false
+	}
+	if !reflect.DeepEqual(a.MultipleOf, b.MultipleOf) {
+		return false
+	}
+	if !reflect.DeepEqual(a.Extensions, b.Extensions) {
+		return false
+	}
+	if !reflect.DeepEqual(a.UnevaluatedProperties, b.UnevaluatedProperties) || len(a.AllOf) != len(b.AllOf) {
+		return false
+	}
+	for i := range a.AllOf {
+		if !compareProperty(a.AllOf[i], b.AllOf[i]) {
+			return false
+		}
+	}
+	if len(a.OneOf) != len(b.OneOf) {
+		return false
+	}
+	for i := range a.OneOf {
+		if !compareProperty(a.OneOf[i], b.OneOf[i]) {
+			return false
+		}
+	}
+
+	// compare AdditionalProperties field, which holds either a bool or a *Property
+	aAdditional, aOk := a.AdditionalProperties.(*Property)
+	bAdditional, bOk := b.AdditionalProperties.(*Property)
+	if aOk || bOk {
+		if !compareProperty(aAdditional, bAdditional) {
+			return false
+		}
+	} else if !reflect.DeepEqual(a.AdditionalProperties, b.AdditionalProperties) {
+		return false
+	}
+
 	return true
 }
 
@@ -552,7 +628,7 @@ func TestGenerateSchemaWithDefaultValues(t *testing.T) {
 						Items: &Property{
 							Type: String,
 						},
-						Default: "[\"item1\",\"item2\"]",
+						Default: []any{"item1", "item2"},
 					},
 				},
 				Required: []string{"required_string"},
@@ -580,3 +656,3496 @@ func TestGenerateSchemaWithDefaultValues(t *testing.T) {
 		})
 	}
 }
+
+// countdownContext reports context.Canceled after Err has been consulted n times,
+// which allows cancelling deterministically in the middle of a generation.
+type countdownContext struct {
+	context.Context
+	n int
+}
+
+func (c *countdownContext) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestGenerateSchemaContext(t *testing.T) {
+	type level3 struct {
+		Value string `json:"value"`
+	}
+	type level2 struct {
+		Next level3 `json:"next"`
+	}
+	type level1 struct {
+		Next level2 `json:"next"`
+	}
+	type deeplyNested struct {
+		Next level1 `json:"next"`
+	}
+
+	t.Run("pre-cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		got, err := GenerateSchemaContext(ctx, deeplyNested{})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("GenerateSchemaContext() error = %v, want %v", err, context.Canceled)
+		}
+		if got != nil {
+			t.Errorf("GenerateSchemaContext() got = %v, want nil", got)
+		}
+	})
+
+	t.Run("cancelled mid-generation", func(t *testing.T) {
+		ctx := &countdownContext{Context: context.Background(), n: 3}
+
+		got, err := GenerateSchemaContext(ctx, deeplyNested{})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("GenerateSchemaContext() error = %v, want %v", err, context.Canceled)
+		}
+		if got != nil {
+			t.Errorf("GenerateSchemaContext() got = %v, want nil", got)
+		}
+	})
+
+	t.Run("live context", func(t *testing.T) {
+		got, err := GenerateSchemaContext(context.Background(), deeplyNested{})
+		if err != nil {
+			t.Fatalf("GenerateSchemaContext() error = %v", err)
+		}
+		if got.Properties["next"].Properties["next"].Properties["next"].Properties["value"].Type != String {
+			t.Errorf("GenerateSchemaContext() got = %v, want nested string value", got)
+		}
+	})
+}
+
+type testDataWithProvidedDefaults struct {
+	Filter struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags,omitempty"`
+	} `json:"filter,omitempty"`
+	Limit int `json:"limit,omitempty"`
+}
+
+func (testDataWithProvidedDefaults) SchemaDefaults() map[string]any {
+	return map[string]any{
+		"filter": map[string]any{"name": `say "hi"`, "tags": []string{"a", "b"}},
+		"limit":  10,
+	}
+}
+
+type testDataWithInvalidProvidedDefaults struct {
+	Limit int `json:"limit,omitempty"`
+}
+
+func (*testDataWithInvalidProvidedDefaults) SchemaDefaults() map[string]any {
+	return map[string]any{"limit": "ten"}
+}
+
+type testDataWithUnknownProvidedDefaults struct {
+	Limit int `json:"limit,omitempty"`
+}
+
+func (testDataWithUnknownProvidedDefaults) SchemaDefaults() map[string]any {
+	return map[string]any{"offset": 1}
+}
+
+func TestGenerateSchemaWithComplexDefaultValues(t *testing.T) {
+	t.Run("nested JSON tag with escaped quotes", func(t *testing.T) {
+		got, err := generateSchemaFromReqStruct(struct {
+			Options map[string]any `json:"options,omitempty" default:"{\"name\":\"say \\\"hi\\\"\",\"nested\":{\"list\":[1,2]}}"`
+		}{})
+		if err != nil {
+			t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+		}
+		want := map[string]any{"name": `say "hi"`, "nested": map[string]any{"list": []any{float64(1), float64(2)}}}
+		if !reflect.DeepEqual(got.Properties["options"].Default, want) {
+			t.Errorf("generateSchemaFromReqStruct() default = %#v, want %#v", got.Properties["options"].Default, want)
+		}
+	})
+
+	t.Run("invalid JSON tag", func(t *testing.T) {
+		_, err := generateSchemaFromReqStruct(struct {
+			Options map[string]any `json:"options,omitempty" default:"{name:1}"`
+		}{})
+		if err == nil {
+			t.Errorf("generateSchemaFromReqStruct() error = nil, wantErr")
+		}
+	})
+
+	t.Run("JSON tag incompatible with schema", func(t *testing.T) {
+		_, err := generateSchemaFromReqStruct(struct {
+			List []int `json:"list,omitempty" default:"[\"a\"]"`
+		}{})
+		if err == nil {
+			t.Errorf("generateSchemaFromReqStruct() error = nil, wantErr")
+		}
+	})
+
+	t.Run("defaults provided by method", func(t *testing.T) {
+		got, err := generateSchemaFromReqStruct(testDataWithProvidedDefaults{})
+		if err != nil {
+			t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+		}
+		want := map[string]any{"name": `say "hi"`, "tags": []string{"a", "b"}}
+		if !reflect.DeepEqual(got.Properties["filter"].Default, want) {
+			t.Errorf("generateSchemaFromReqStruct() filter default = %#v, want %#v", got.Properties["filter"].Default, want)
+		}
+		if got.Properties["limit"].Default != 10 {
+			t.Errorf("generateSchemaFromReqStruct() limit default = %#v, want 10", got.Properties["limit"].Default)
+		}
+	})
+
+	t.Run("method default incompatible with schema", func(t *testing.T) {
+		if _, err := generateSchemaFromReqStruct(testDataWithInvalidProvidedDefaults{}); err == nil {
+			t.Errorf("generateSchemaFromReqStruct() error = nil, wantErr")
+		}
+	})
+
+	t.Run("method default for unknown property", func(t *testing.T) {
+		if _, err := generateSchemaFromReqStruct(testDataWithUnknownProvidedDefaults{}); err == nil {
+			t.Errorf("generateSchemaFromReqStruct() error = nil, wantErr")
+		}
+	})
+}
+
+func TestGenerateSchemaWithSchemaTypedField(t *testing.T) {
+	type metaToolReq struct {
+		Name   string      `json:"name"`
+		Schema InputSchema `json:"schema" description:"schema of the tool"`
+		Output *Property   `json:"output,omitempty"`
+	}
+
+	got, err := generateSchemaFromReqStruct(metaToolReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name": {
+				Type: String,
+			},
+			"schema": {
+				Type:        ObjectT,
+				Description: "schema of the tool",
+			},
+			"output": {
+				Type: ObjectT,
+			},
+		},
+		Required: []string{"name", "schema"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateSchemaWithEmptySliceAsEmptyArrayDefault(t *testing.T) {
+	type sliceDefaultsReq struct {
+		Required []string `json:"required"`
+		Optional []string `json:"optional,omitempty"`
+		Defaults []int    `json:"defaults,omitempty" default:"[1]"`
+		Name     string   `json:"name,omitempty"`
+		Data     []byte   `json:"data,omitempty"`
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), sliceDefaultsReq{}, WithEmptySliceAsEmptyArrayDefault())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Properties["optional"].Default, []any{}) {
+		t.Errorf("optional default = %#v, want empty array", got.Properties["optional"].Default)
+	}
+	if got.Properties["required"].Default != nil {
+		t.Errorf("required default = %#v, want nil", got.Properties["required"].Default)
+	}
+	if !reflect.DeepEqual(got.Properties["defaults"].Default, []any{float64(1)}) {
+		t.Errorf("defaults default = %#v, want [1]", got.Properties["defaults"].Default)
+	}
+	if got.Properties["name"].Default != nil {
+		t.Errorf("name default = %#v, want nil", got.Properties["name"].Default)
+	}
+	if got.Properties["data"].Default != nil {
+		t.Errorf("data default = %#v, want nil for the base64 string of []byte", got.Properties["data"].Default)
+	}
+
+	got, err = GenerateSchemaContext(context.Background(), sliceDefaultsReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if got.Properties["optional"].Default != nil {
+		t.Errorf("optional default without option = %#v, want nil", got.Properties["optional"].Default)
+	}
+}
+
+type testUUID [16]byte
+
+func TestGenerateSchemaForByteArrays(t *testing.T) {
+	length := 16
+	type byteArraysReq struct {
+		Data     []byte   `json:"data"`
+		Checksum [16]byte `json:"checksum"`
+	}
+
+	got, err := generateSchemaFromReqStruct(byteArraysReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"data": {
+				Type:            String,
+				ContentEncoding: "base64",
+			},
+			"checksum": {
+				Type:     Array,
+				Items:    &Property{Type: Integer},
+				MinItems: &length,
+				MaxItems: &length,
+			},
+		},
+		Required: []string{"data", "checksum"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	RegisterTypeFormat(reflect.TypeOf(testUUID{}), "uuid")
+	defer unregisterTypeSchema(reflect.TypeOf(testUUID{}))
+
+	got, err = generateSchemaFromReqStruct(struct {
+		ID testUUID `json:"id"`
+	}{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	if !compareProperty(got.Properties["id"], &Property{Type: String, Format: "uuid"}) {
+		t.Errorf("generateSchemaFromReqStruct() id = %v, want uuid string", got.Properties["id"])
+	}
+}
+
+func TestGenerateSchemaWithEnumLabels(t *testing.T) {
+	got, err := generateSchemaFromReqStruct(struct {
+		Priority int `json:"priority" enum:"1,2,3" enumLabels:"Low, Medium, High"`
+	}{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &Property{
+		Type:       Integer,
+		Enum:       []any{1, 2, 3},
+		Extensions: map[string]any{"x-enumLabels": []string{"Low", "Medium", "High"}},
+	}
+	if !compareProperty(got.Properties["priority"], want) {
+		t.Errorf("generateSchemaFromReqStruct() priority = %v, want %v", got.Properties["priority"], want)
+	}
+
+	data, err := json.Marshal(got.Properties["priority"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if wantJSON := `{"enum":[1,2,3],"type":"integer","x-enumLabels":["Low","Medium","High"]}`; string(data) != wantJSON {
+		t.Errorf("json.Marshal() = %s, want %s", data, wantJSON)
+	}
+
+	var decoded Property
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Extensions, map[string]any{"x-enumLabels": []any{"Low", "Medium", "High"}}) {
+		t.Errorf("json.Unmarshal() extensions = %v", decoded.Extensions)
+	}
+
+	_, err = generateSchemaFromReqStruct(struct {
+		Priority int `json:"priority" enum:"1,2,3" enumLabels:"Low,High"`
+	}{})
+	if err == nil {
+		t.Errorf("generateSchemaFromReqStruct() with mismatched labels error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithJSONTagOptionsOnly(t *testing.T) {
+	type jsonOptionsReq struct {
+		Count    int     `json:",string"`
+		Ratio    float64 `json:"ratio,omitempty,string" default:"0.5"`
+		Level    int     `json:"level,string" enum:"1,2"`
+		Optional string  `json:",omitempty"`
+		Limit    *int    `json:"limit,string,omitempty" default:"5"`
+		Enabled  *bool   `json:"enabled,string,omitempty"`
+	}
+
+	got, err := generateSchemaFromReqStruct(jsonOptionsReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"Count": {
+				Type: String,
+			},
+			"ratio": {
+				Type:    String,
+				Default: "0.5",
+			},
+			"level": {
+				Type: String,
+				Enum: []any{"1", "2"},
+			},
+			"Optional": {
+				Type: String,
+			},
+			"limit": {
+				Type:    String,
+				Default: "5",
+			},
+			"enabled": {
+				Type: String,
+			},
+		},
+		Required: []string{"Count", "level"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	var req jsonOptionsReq
+	content := json.RawMessage(`{"Count":"1","level":"2","limit":"7","enabled":"true"}`)
+	if err = VerifyAndUnmarshal(content, &req); err != nil {
+		t.Fatalf("VerifyAndUnmarshal() error = %v", err)
+	}
+	if req.Limit == nil || *req.Limit != 7 || req.Enabled == nil || !*req.Enabled {
+		t.Errorf("VerifyAndUnmarshal() = %+v, want limit 7 and enabled true", req)
+	}
+}
+
+func TestGenerateSchemaWithObjectTypeName(t *testing.T) {
+	if string(Object) != string(ObjectT) {
+		t.Fatalf("Object = %q and ObjectT = %q differ", Object, ObjectT)
+	}
+
+	type objectTypeNameReq struct {
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+
+	for _, name := range []string{"object", "Object"} {
+		got, err := GenerateSchemaContext(context.Background(), objectTypeNameReq{}, WithObjectTypeName(name))
+		if err != nil {
+			t.Fatalf("GenerateSchemaContext(%q) error = %v", name, err)
+		}
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		want := `{"type":"object","properties":{"user":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}},"required":["user"]}`
+		if string(data) != want {
+			t.Errorf("json.Marshal() = %s, want %s", data, want)
+		}
+	}
+
+	if _, err := GenerateSchemaContext(context.Background(), objectTypeNameReq{}, WithObjectTypeName("map")); err == nil {
+		t.Errorf("GenerateSchemaContext() with non compliant type name error = nil, wantErr")
+	}
+
+	data, err := json.Marshal(InputSchema{})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"type":"object"}`; string(data) != want {
+		t.Errorf("json.Marshal() of empty schema = %s, want %s", data, want)
+	}
+}
+
+type testProps map[string]string
+
+type testAnyProps map[string]any
+
+func TestGenerateSchemaWithEmbeddedNamedMap(t *testing.T) {
+	type embeddedMapReq struct {
+		testProps
+		Name   string `json:"name"`
+		Nested struct {
+			testAnyProps
+		} `json:"nested"`
+	}
+
+	got, err := generateSchemaFromReqStruct(embeddedMapReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name": {Type: String},
+			"nested": {
+				Type:                 ObjectT,
+				Properties:           map[string]*Property{},
+				AdditionalProperties: true,
+			},
+		},
+		Required:             []string{"name", "nested"},
+		AdditionalProperties: &Property{Type: String},
+	}
+	if !compareInputSchema(got, want) || !compareProperty(got.AdditionalProperties.(*Property), want.AdditionalProperties.(*Property)) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"additionalProperties":{"type":"string"}`) {
+		t.Errorf("json.Marshal() = %s, want additionalProperties of strings", data)
+	}
+}
+
+func TestGenerateSchemaLenient(t *testing.T) {
+	type lenientReq struct {
+		Name     string `json:"name" description:"name"`
+		Callback func() `json:"callback"`
+		Level    int    `json:"level,omitempty" enum:"low,high"`
+		Nested   struct {
+			Channel chan int `json:"channel"`
+			Valid   bool     `json:"valid"`
+		} `json:"nested"`
+	}
+
+	got, warnings := GenerateSchemaLenient(lenientReq{})
+	if len(warnings) != 3 {
+		t.Fatalf("GenerateSchemaLenient() warnings = %v, want 3 warnings", warnings)
+	}
+	for i, name := range []string{"Callback", "Level", "Channel"} {
+		if !strings.Contains(warnings[i].Error(), name) {
+			t.Errorf("GenerateSchemaLenient() warning %d = %v, want it to report field %s", i, warnings[i], name)
+		}
+	}
+
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name": {Type: String, Description: "name"},
+			"nested": {
+				Type:       ObjectT,
+				Properties: map[string]*Property{"valid": {Type: Boolean}},
+				Required:   []string{"valid"},
+			},
+		},
+		Required: []string{"name", "nested"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaLenient() got = %v, want %v", got, want)
+	}
+
+	if _, err := generateSchemaFromReqStruct(lenientReq{}); err == nil {
+		t.Errorf("generateSchemaFromReqStruct() error = nil, wantErr")
+	}
+
+	if got, warnings = GenerateSchemaLenient(1); got != nil || len(warnings) != 1 {
+		t.Errorf("GenerateSchemaLenient() of invalid type got = %v, warnings = %v", got, warnings)
+	}
+}
+
+type testFlag bool
+
+func TestGenerateSchemaWithNamedBool(t *testing.T) {
+	type namedBoolReq struct {
+		Confirm testFlag `json:"confirm" enum:"true"`
+		Verbose testFlag `json:"verbose,omitempty" default:"false"`
+	}
+
+	got, err := generateSchemaFromReqStruct(namedBoolReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"confirm": {Type: Boolean, Enum: []any{true}},
+			"verbose": {Type: Boolean, Default: false},
+		},
+		Required: []string{"confirm"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	var req namedBoolReq
+	if err = VerifyAndUnmarshal(json.RawMessage(`{"confirm":true}`), &req); err != nil || !bool(req.Confirm) {
+		t.Errorf("VerifyAndUnmarshal() error = %v, confirm = %v", err, req.Confirm)
+	}
+	if err = VerifyAndUnmarshal(json.RawMessage(`{"confirm":false}`), &req); err == nil {
+		t.Errorf("VerifyAndUnmarshal() of value outside the enum error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithRegisteredTypes(t *testing.T) {
+	type registeredTypesReq struct {
+		CreatedAt time.Time  `json:"created_at" description:"creation time"`
+		UpdatedAt *time.Time `json:"updated_at,omitempty"`
+		Addr      net.IP     `json:"addr"`
+		ID        uuid.UUID  `json:"id"`
+		Amount    *big.Int   `json:"amount"`
+	}
+
+	got, warnings := GenerateSchemaLenient(registeredTypesReq{})
+	if len(warnings) != 0 {
+		t.Fatalf("GenerateSchemaLenient() warnings = %v", warnings)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"created_at": {Type: String, Format: "date-time", Description: "creation time"},
+			"updated_at": {Type: String, Format: "date-time"},
+			"addr":       {Type: String, Pattern: ipPattern},
+			"id":         {Type: String, Format: "uuid"},
+			"amount":     {Type: Integer},
+		},
+		Required: []string{"created_at", "addr", "id"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaLenient() got = %v, want %v", got, want)
+	}
+
+	timeType := reflect.TypeOf(time.Time{})
+	defaultSchema, _ := typeSchemas.Load(timeType)
+	defer storeTypeSchema(timeType, defaultSchema.(typeSchema))
+
+	RegisterTypeSchema(timeType, func() *Property {
+		return &Property{Type: Integer, Description: "unix timestamp"}
+	})
+	got, warnings = GenerateSchemaLenient(struct {
+		At time.Time `json:"at"`
+	}{})
+	if len(warnings) != 0 {
+		t.Fatalf("GenerateSchemaLenient() warnings = %v", warnings)
+	}
+	if !compareProperty(got.Properties["at"], &Property{Type: Integer, Description: "unix timestamp"}) {
+		t.Errorf("GenerateSchemaLenient() at = %v, want the overridden registration", got.Properties["at"])
+	}
+}
+
+func TestGenerateSchemaWithBigRat(t *testing.T) {
+	type bigRatReq struct {
+		Ratio    big.Rat  `json:"ratio"`
+		Discount *big.Rat `json:"discount"`
+	}
+
+	got, err := GenerateSchema(bigRatReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"ratio":    {Type: String, Pattern: `^-?\d+(/\d+)?$`},
+			"discount": {Type: String, Pattern: `^-?\d+(/\d+)?$`},
+		},
+		Required: []string{"ratio"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchema() got = %v, want %v", got, want)
+	}
+
+	// MarshalText of big.Rat has a pointer receiver, the value field is only marshaled as text when addressable
+	data, err := json.Marshal(&bigRatReq{Ratio: *big.NewRat(-3, 2), Discount: big.NewRat(4, 2)})
+	if err != nil {
+		t.Fatalf("json Marshal: %v", err)
+	}
+	var req bigRatReq
+	if err = VerifyAndUnmarshal(data, &req); err != nil {
+		t.Errorf("VerifyAndUnmarshal(%s) error = %v", data, err)
+	}
+	if err = VerifyAndUnmarshal(json.RawMessage(`{"ratio":"1.5"}`), &req); err == nil {
+		t.Errorf("VerifyAndUnmarshal() of a decimal ratio error = nil, wantErr")
+	}
+}
+
+type timestampAlias time.Time
+
+func TestGenerateSchemaWithTimeAlias(t *testing.T) {
+	type timeAliasReq struct {
+		At    timestampAlias   `json:"at" description:"event time"`
+		Until *timestampAlias  `json:"until,omitempty"`
+		Times []timestampAlias `json:"times"`
+	}
+
+	got, err := generateSchemaFromReqStruct(timeAliasReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"at":    {Type: String, Format: "date-time", Description: "event time"},
+			"until": {Type: String, Format: "date-time"},
+			"times": {Type: Array, Items: &Property{Type: String, Format: "date-time"}},
+		},
+		Required: []string{"at", "times"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+}
+
+type testStatus string
+
+func TestGenerateSchemaWithRegisteredEnum(t *testing.T) {
+	statusType := reflect.TypeOf(testStatus(""))
+	if err := RegisterEnum(statusType, "active", testStatus("inactive")); err != nil {
+		t.Fatalf("RegisterEnum() error = %v", err)
+	}
+	defer unregisterTypeSchema(statusType)
+
+	type registeredEnumReq struct {
+		Status   testStatus   `json:"status" description:"current status"`
+		Previous testStatus   `json:"previous,omitempty"`
+		History  []testStatus `json:"history,omitempty"`
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), registeredEnumReq{}, WithObjectTypeName("object"))
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"status":   {Ref: "#/$defs/testStatus", Description: "current status"},
+			"previous": {Ref: "#/$defs/testStatus"},
+			"history":  {Type: Array, Items: &Property{Ref: "#/$defs/testStatus"}},
+		},
+		Required: []string{"status"},
+		Defs: map[string]*Property{
+			"testStatus": {Type: String, Enum: []any{"active", "inactive"}},
+		},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaContext() got = %v, want %v", got, want)
+	}
+
+	validator := schemaValidator{defs: got.Defs}
+	schema := Property{Type: ObjectT, Properties: got.Properties, Required: got.Required}
+	if !validator.validate(schema, map[string]any{"status": "active", "history": []any{"inactive"}}) {
+		t.Errorf("validate() of registered enum members = false, want true")
+	}
+	if validator.validate(schema, map[string]any{"status": "deleted"}) {
+		t.Errorf("validate() of unknown enum member = true, want false")
+	}
+
+	got, err = GenerateSchemaContext(context.Background(), registeredEnumReq{}, WithInlineEnums())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	want = &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"status":   {Type: String, Enum: []any{"active", "inactive"}, Description: "current status"},
+			"previous": {Type: String, Enum: []any{"active", "inactive"}},
+			"history":  {Type: Array, Items: &Property{Type: String, Enum: []any{"active", "inactive"}}},
+		},
+		Required: []string{"status"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaContext() with inline enums got = %v, want %v", got, want)
+	}
+
+	if err = RegisterEnum(reflect.TypeOf(struct{}{}), "a"); err == nil {
+		t.Errorf("RegisterEnum() of struct type error = nil, wantErr")
+	}
+	if err = RegisterEnum(statusType, 1); err == nil {
+		t.Errorf("RegisterEnum() of incompatible value error = nil, wantErr")
+	}
+}
+
+type testGenericReq[T any] struct {
+	Value T `json:"value"`
+}
+
+type testScalar interface {
+	String() string
+}
+
+func TestGenerateSchemaWithTypeParameterField(t *testing.T) {
+	got, err := generateSchemaFromReqStruct(testGenericReq[int]{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	if !compareProperty(got.Properties["value"], &Property{Type: Integer}) {
+		t.Errorf("generateSchemaFromReqStruct() value = %v, want integer", got.Properties["value"])
+	}
+
+	// The constraint interface stands in for an uninstantiated type parameter
+	_, err = generateSchemaFromReqStruct(testGenericReq[testScalar]{})
+	if err == nil || !strings.Contains(err.Error(), "not a concrete type") || !strings.Contains(err.Error(), "testScalar") {
+		t.Errorf("generateSchemaFromReqStruct() error = %v, want an error naming the non concrete type", err)
+	}
+}
+
+func TestGenerateSchemaWithDescriptions(t *testing.T) {
+	type descriptionsReq struct {
+		User struct {
+			Name string `json:"name" description:"tag description"`
+			Info struct {
+				Age int `json:"age"`
+			} `json:"info"`
+		} `json:"user"`
+		Tags []string `json:"tags,omitempty"`
+	}
+
+	got, err := GenerateSchemaWithDescriptions(descriptionsReq{}, map[string]string{
+		"/user/info/age": "age in years",
+		"/user/name":     "full name",
+		"/tags/*":        "a tag",
+	})
+	if err != nil {
+		t.Fatalf("GenerateSchemaWithDescriptions() error = %v", err)
+	}
+	user := got.Properties["user"]
+	if d := user.Properties["info"].Properties["age"].Description; d != "age in years" {
+		t.Errorf("GenerateSchemaWithDescriptions() /user/info/age description = %q", d)
+	}
+	if d := user.Properties["name"].Description; d != "full name" {
+		t.Errorf("GenerateSchemaWithDescriptions() /user/name description = %q", d)
+	}
+	if d := got.Properties["tags"].Items.Description; d != "a tag" {
+		t.Errorf("GenerateSchemaWithDescriptions() /tags/* description = %q", d)
+	}
+	if d := user.Properties["info"].Description; d != "" {
+		t.Errorf("GenerateSchemaWithDescriptions() /user/info description = %q, want empty", d)
+	}
+
+	if _, err = GenerateSchemaWithDescriptions(descriptionsReq{}, map[string]string{"/user/email": "email"}); err == nil {
+		t.Errorf("GenerateSchemaWithDescriptions() with unknown path error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithContextDescriptions(t *testing.T) {
+	type contextDescriptionsReq struct {
+		User struct {
+			Name string `json:"name" description:"tag description"`
+			Age  int    `json:"age"`
+		} `json:"user"`
+		Tags []string `json:"tags,omitempty"`
+	}
+
+	cms := map[string]string{
+		"/user/name": "cms name",
+		"/user/age":  "cms age",
+		"/tags/*":    "cms tag",
+	}
+	describe := WithContextDescriptions(func(path string) (string, bool) {
+		description, ok := cms[path]
+		return description, ok
+	})
+	if _, err := GenerateSchemaContext(context.Background(), contextDescriptionsReq{}, describe, WithRequireDescriptions()); err == nil {
+		t.Errorf("GenerateSchemaContext() with undescribed /user and /tags error = nil, wantErr")
+	}
+	cms["/user"], cms["/tags"] = "the user", "the tags"
+	got, err := GenerateSchemaContext(context.Background(), contextDescriptionsReq{}, describe, WithRequireDescriptions())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+
+	user := got.Properties["user"]
+	if d := user.Properties["name"].Description; d != "tag description" {
+		t.Errorf("/user/name description = %q, want the one of the tag", d)
+	}
+	if d := user.Properties["age"].Description; d != "cms age" {
+		t.Errorf("/user/age description = %q, want %q", d, "cms age")
+	}
+	if d := got.Properties["tags"].Items.Description; d != "cms tag" {
+		t.Errorf("/tags/* description = %q, want %q", d, "cms tag")
+	}
+}
+
+func TestGenerateListSchema(t *testing.T) {
+	type listedItem struct {
+		ID    string `json:"id"`
+		Count int    `json:"count,omitempty"`
+	}
+
+	tests := []struct {
+		name  string
+		elem  any
+		items *Property
+	}{
+		{
+			name: "struct elements",
+			elem: listedItem{},
+			items: &Property{
+				Type: ObjectT,
+				Properties: map[string]*Property{
+					"id":    {Type: String},
+					"count": {Type: Integer},
+				},
+				Required: []string{"id"},
+			},
+		},
+		{
+			name: "pointer elements",
+			elem: &listedItem{},
+			items: &Property{
+				Type: ObjectT,
+				Properties: map[string]*Property{
+					"id":    {Type: String},
+					"count": {Type: Integer},
+				},
+				Required: []string{"id"},
+			},
+		},
+		{
+			name:  "string elements",
+			elem:  "",
+			items: &Property{Type: String},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateListSchema(tt.elem)
+			if err != nil {
+				t.Fatalf("GenerateListSchema() error = %v", err)
+			}
+			want := &InputSchema{
+				Type: Object,
+				Properties: map[string]*Property{
+					"items":      {Type: Array, Items: tt.items},
+					"nextCursor": {Type: String},
+				},
+				Required: []string{"items"},
+			}
+			if !compareInputSchema(got, want) {
+				t.Errorf("GenerateListSchema() got = %v, want %v", got, want)
+			}
+		})
+	}
+
+	schema, err := GenerateListSchema(listedItem{})
+	if err != nil {
+		t.Fatalf("GenerateListSchema() error = %v", err)
+	}
+	validate := func(data string) error {
+		var v any
+		if err := json.Unmarshal([]byte(data), &v); err != nil {
+			t.Fatalf("json Unmarshal: %v", err)
+		}
+		return schema.Validate(v)
+	}
+	for _, data := range []string{`{"items":[{"id":"a"}],"nextCursor":"c2"}`, `{"items":[]}`} {
+		if err = validate(data); err != nil {
+			t.Errorf("Validate(%s) error = %v", data, err)
+		}
+	}
+	if err = validate(`{"nextCursor":"c2"}`); err == nil {
+		t.Errorf("Validate() without items error = nil, wantErr")
+	}
+
+	if _, err = GenerateListSchema(nil); err == nil {
+		t.Errorf("GenerateListSchema(nil) error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithMaxEnumSize(t *testing.T) {
+	type maxEnumSizeReq struct {
+		Color string `json:"color" enum:"red,green,blue"`
+		Items []struct {
+			Size string `json:"size" enum:"s,m,l,xl"`
+		} `json:"items"`
+	}
+
+	if _, err := GenerateSchemaContext(context.Background(), maxEnumSizeReq{}, WithMaxEnumSize(4)); err != nil {
+		t.Errorf("GenerateSchemaContext() at the limit error = %v", err)
+	}
+
+	_, err := GenerateSchemaContext(context.Background(), maxEnumSizeReq{}, WithMaxEnumSize(3))
+	if err == nil || !strings.Contains(err.Error(), "/items/*/size") {
+		t.Errorf("GenerateSchemaContext() over the limit error = %v, want an error for /items/*/size", err)
+	}
+}
+
+func TestGenerateSchemaWithCombinedStringConstraints(t *testing.T) {
+	type combinedConstraintsReq struct {
+		Code string `json:"code" description:"country code" enum:"FR,DE,IT" default:"FR" minLength:"2" maxLength:"2" pattern:"^[A-Z]+$"`
+	}
+
+	got, err := GenerateSchema(combinedConstraintsReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	two := 2
+	want := &Property{
+		Type:        String,
+		Description: "country code",
+		Enum:        []any{"FR", "DE", "IT"},
+		Default:     "FR",
+		MinLength:   &two,
+		MaxLength:   &two,
+		Pattern:     "^[A-Z]+$",
+	}
+	if !compareProperty(got.Properties["code"], want) {
+		t.Errorf("GenerateSchema() code = %+v, want %+v", got.Properties["code"], want)
+	}
+
+	invalid := map[string]any{
+		"default outside the enum": struct {
+			Code string `json:"code" enum:"FR,DE" default:"IT"`
+		}{},
+		"default shorter than minLength": struct {
+			Code string `json:"code" default:"F" minLength:"2"`
+		}{},
+		"default longer than maxLength": struct {
+			Code string `json:"code" maxLength:"2" default:"FRA"`
+		}{},
+		"default not matching the pattern": struct {
+			Code string `json:"code" default:"fr" pattern:"^[A-Z]+$"`
+		}{},
+	}
+	for name, v := range invalid {
+		if _, err = GenerateSchema(v); err == nil {
+			t.Errorf("GenerateSchema() with %s error = nil, wantErr", name)
+		}
+	}
+}
+
+func TestGenerateSchemaWithStringTypeDefaults(t *testing.T) {
+	type stringTypeDefaultsReq struct {
+		Since time.Time  `json:"since" default:"2024-01-01T00:00:00Z"`
+		ID    uuid.UUID  `json:"id" default:"123e4567-e89b-12d3-a456-426614174000"`
+		Host  net.IP     `json:"host" default:"127.0.0.1"`
+		Until *time.Time `json:"until,omitempty" default:"2025-01-01T00:00:00Z"`
+	}
+
+	got, err := GenerateSchema(stringTypeDefaultsReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	for name, want := range map[string]any{
+		"since": "2024-01-01T00:00:00Z",
+		"id":    "123e4567-e89b-12d3-a456-426614174000",
+		"host":  "127.0.0.1",
+		"until": "2025-01-01T00:00:00Z",
+	} {
+		if p := got.Properties[name]; p == nil || p.Default != want {
+			t.Errorf("GenerateSchema() %s = %+v, want the default %v", name, p, want)
+		}
+	}
+}
+
+func TestGenerateSchemaWithUnsignedDefaults(t *testing.T) {
+	type unsignedDefaultsReq struct {
+		Count   uint    `json:"count" default:"5"`
+		Port    uint16  `json:"port" default:"8080" minimum:"1" maximum:"65535"`
+		Retries int8    `json:"retries" default:"3" enum:"1,3,5"`
+		Size    uint64  `json:"size" default:"1024"`
+		Ratio   float32 `json:"ratio" default:"0.5"`
+	}
+
+	got, err := GenerateSchema(unsignedDefaultsReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	for name, want := range map[string]string{"count": "5", "port": "8080", "retries": "3", "size": "1024", "ratio": "0.5"} {
+		if p := got.Properties[name]; p == nil || fmt.Sprint(p.Default) != want {
+			t.Errorf("GenerateSchema() %s = %+v, want the default %v", name, p, want)
+		}
+	}
+
+	if _, err = GenerateSchema(struct {
+		Port uint16 `json:"port" default:"80" minimum:"1024"`
+	}{}); err == nil {
+		t.Errorf("GenerateSchema() with an unsigned default below the minimum error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithMaxTotalProperties(t *testing.T) {
+	type totalPropertiesAddress struct {
+		Street string `json:"street"`
+		City   string `json:"city"`
+	}
+	type totalPropertiesReq struct {
+		Name     string                   `json:"name"`
+		Home     totalPropertiesAddress   `json:"home"`
+		Previous []totalPropertiesAddress `json:"previous"`
+	}
+
+	// 3 properties at the root, and 2 for each of the addresses
+	if _, err := GenerateSchemaContext(context.Background(), totalPropertiesReq{}, WithMaxTotalProperties(7)); err != nil {
+		t.Errorf("GenerateSchemaContext() at the limit error = %v", err)
+	}
+	_, err := GenerateSchemaContext(context.Background(), totalPropertiesReq{}, WithMaxTotalProperties(6))
+	if err == nil || !strings.Contains(err.Error(), "7 properties") {
+		t.Errorf("GenerateSchemaContext() over the limit error = %v, want an error for 7 properties", err)
+	}
+}
+
+// UnionShape is exported as encoding/json only names embedded fields of exported types
+type UnionShape interface {
+	area() float64
+}
+
+type testCircle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c testCircle) area() float64 { return c.Radius * c.Radius * 3.14 }
+
+type testSquare struct {
+	Side float64 `json:"side"`
+}
+
+func (s *testSquare) area() float64 { return s.Side * s.Side }
+
+func TestGenerateSchemaWithEmbeddedUnion(t *testing.T) {
+	shapeType := reflect.TypeOf((*UnionShape)(nil)).Elem()
+	if err := RegisterUnion(shapeType, testCircle{}, &testSquare{}); err != nil {
+		t.Fatalf("RegisterUnion() error = %v", err)
+	}
+	defer unregisterTypeSchema(shapeType)
+
+	type embeddedUnionReq struct {
+		UnionShape
+		Name string `json:"name"`
+	}
+
+	got, err := generateSchemaFromReqStruct(embeddedUnionReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name": {Type: String},
+			"UnionShape": {OneOf: []*Property{
+				{Ref: "#/$defs/testCircle"},
+				{Ref: "#/$defs/testSquare"},
+			}},
+		},
+		Required: []string{"UnionShape", "name"},
+		Defs: map[string]*Property{
+			"testCircle": {Type: ObjectT, Properties: map[string]*Property{"radius": {Type: Number}}, Required: []string{"radius"}},
+			"testSquare": {Type: ObjectT, Properties: map[string]*Property{"side": {Type: Number}}, Required: []string{"side"}},
+		},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	validator := schemaValidator{defs: got.Defs}
+	schema := Property{Type: ObjectT, Properties: got.Properties, Required: got.Required}
+	if !validator.validate(schema, map[string]any{"name": "a", "UnionShape": map[string]any{"side": 1.0}}) {
+		t.Errorf("validate() of a union variant = false, want true")
+	}
+	if validator.validate(schema, map[string]any{"name": "a", "UnionShape": map[string]any{"edges": 3.0}}) {
+		t.Errorf("validate() of an unknown variant = true, want false")
+	}
+
+	if err = RegisterUnion(reflect.TypeOf(testCircle{}), testCircle{}); err == nil {
+		t.Errorf("RegisterUnion() of a struct error = nil, wantErr")
+	}
+	if err = RegisterUnion(shapeType, testSquare{}); err == nil {
+		t.Errorf("RegisterUnion() of a variant not implementing the interface error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithNumberPrecision(t *testing.T) {
+	cent, tenth := 0.01, 0.1
+	type precisionReq struct {
+		Price float64 `json:"price" precision:"2"`
+		Ratio float32 `json:"ratio"`
+		Count int     `json:"count"`
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), precisionReq{}, WithNumberPrecision(1))
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"price": {Type: Number, MultipleOf: &cent},
+			"ratio": {Type: Number, MultipleOf: &tenth},
+			"count": {Type: Integer},
+		},
+		Required: []string{"price", "ratio", "count"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaContext() got = %v, want %v", got, want)
+	}
+
+	if !validate(*got.Properties["price"], 12.34) || validate(*got.Properties["price"], 12.345) {
+		t.Errorf("validate() does not enforce multipleOf 0.01")
+	}
+
+	for _, v := range []any{
+		struct {
+			Price float64 `json:"price" precision:"-1"`
+		}{},
+		struct {
+			Price float64 `json:"price" precision:"two"`
+		}{},
+		struct {
+			Count int `json:"count" precision:"2"`
+		}{},
+	} {
+		if _, err = generateSchemaFromReqStruct(v); err == nil {
+			t.Errorf("generateSchemaFromReqStruct(%T) error = nil, wantErr", v)
+		}
+	}
+}
+
+func TestGenerateSchemaWithNullableEnum(t *testing.T) {
+	type nullableEnumReq struct {
+		Status *string `json:"status,omitempty" enum:"active,inactive" nullable:"true"`
+		Note   *string `json:"note,omitempty" nullable:"true"`
+	}
+
+	got, err := generateSchemaFromReqStruct(nullableEnumReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"status": {Type: String, Nullable: true, Enum: []any{"active", "inactive", nil}},
+			"note":   {Type: String, Nullable: true},
+		},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(got.Properties["status"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if wantJSON := `{"type":["string","null"],"enum":["active","inactive",null]}`; string(data) != wantJSON {
+		t.Errorf("json.Marshal() got = %s, want %s", data, wantJSON)
+	}
+
+	var decoded Property
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !compareProperty(&decoded, got.Properties["status"]) {
+		t.Errorf("json.Unmarshal() got = %v, want %v", decoded, got.Properties["status"])
+	}
+
+	status := *got.Properties["status"]
+	if !validate(status, nil) || !validate(status, "active") || validate(status, "deleted") {
+		t.Errorf("validate() does not accept exactly null and the enum values")
+	}
+
+	if _, err = generateSchemaFromReqStruct(struct {
+		Status *string `json:"status" nullable:"maybe"`
+	}{}); err == nil {
+		t.Errorf("generateSchemaFromReqStruct() error = nil, wantErr")
+	}
+}
+
+func TestPropertySetDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		property Property
+		value    any
+		wantErr  bool
+	}{
+		{name: "string", property: Property{Type: String}, value: "hello"},
+		{name: "integer", property: Property{Type: Integer}, value: 42},
+		{name: "number accepts integer", property: Property{Type: Number}, value: 3},
+		{name: "boolean", property: Property{Type: Boolean}, value: true},
+		{name: "array", property: Property{Type: Array, Items: &Property{Type: String}}, value: []string{"a", "b"}},
+		{name: "object", property: Property{Type: ObjectT, Properties: map[string]*Property{"n": {Type: Integer}}}, value: map[string]int{"n": 1}},
+		{name: "enum member", property: Property{Type: String, Enum: []any{"a", "b"}}, value: "b"},
+		{name: "untyped", property: Property{Ref: "#/$defs/Status"}, value: "active"},
+		{name: "string mismatch", property: Property{Type: String}, value: 1, wantErr: true},
+		{name: "integer mismatch", property: Property{Type: Integer}, value: 1.5, wantErr: true},
+		{name: "array item mismatch", property: Property{Type: Array, Items: &Property{Type: String}}, value: []int{1}, wantErr: true},
+		{name: "enum non-member", property: Property{Type: String, Enum: []any{"a", "b"}}, value: "c", wantErr: true},
+		{name: "unserializable", property: Property{Type: String}, value: make(chan int), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.property
+			err := p.SetDefault(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetDefault() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && p.Default != nil {
+				t.Errorf("SetDefault() set Default = %v on error", p.Default)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(p.Default, tt.value) {
+				t.Errorf("SetDefault() Default = %v, want %v", p.Default, tt.value)
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaWithEmitGoType(t *testing.T) {
+	type goTypeGeo struct {
+		Lat float64 `json:"lat"`
+	}
+	type goTypeAddress struct {
+		City string    `json:"city"`
+		Geo  goTypeGeo `json:"geo"`
+	}
+	type goTypeReq struct {
+		Address  goTypeAddress   `json:"address"`
+		Previous []goTypeAddress `json:"previous"`
+		Inline   struct {
+			Note string `json:"note"`
+		} `json:"inline"`
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), goTypeReq{}, WithEmitGoType("x-go-type"))
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		p    *Property
+		want any
+	}{
+		{name: "nested", p: got.Properties["address"], want: "protocol.goTypeAddress"},
+		{name: "deeply nested", p: got.Properties["address"].Properties["geo"], want: "protocol.goTypeGeo"},
+		{name: "array items", p: got.Properties["previous"].Items, want: "protocol.goTypeAddress"},
+		{name: "anonymous struct", p: got.Properties["inline"], want: nil},
+		{name: "non-object", p: got.Properties["address"].Properties["city"], want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if value := tt.p.Extensions["x-go-type"]; value != tt.want {
+				t.Errorf("x-go-type = %v, want %v", value, tt.want)
+			}
+		})
+	}
+
+	data, err := json.Marshal(got.Properties["address"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"x-go-type":"protocol.goTypeAddress"`) {
+		t.Errorf("json.Marshal() = %s, missing x-go-type", data)
+	}
+
+	plain, err := GenerateSchemaContext(context.Background(), goTypeReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if len(plain.Properties["address"].Extensions) != 0 {
+		t.Errorf("extensions without option = %v", plain.Properties["address"].Extensions)
+	}
+}
+
+func TestGenerateSchemaWithMapOfUnion(t *testing.T) {
+	shapeType := reflect.TypeOf((*UnionShape)(nil)).Elem()
+	if err := RegisterUnion(shapeType, testCircle{}, &testSquare{}); err != nil {
+		t.Fatalf("RegisterUnion() error = %v", err)
+	}
+	defer unregisterTypeSchema(shapeType)
+
+	type mapOfUnionReq struct {
+		Shapes map[string]UnionShape `json:"shapes"`
+		Labels map[string]string     `json:"labels"`
+	}
+
+	got, err := generateSchemaFromReqStruct(mapOfUnionReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"shapes": {Type: ObjectT, AdditionalProperties: &Property{OneOf: []*Property{
+				{Ref: "#/$defs/testCircle"},
+				{Ref: "#/$defs/testSquare"},
+			}}},
+			"labels": {Type: ObjectT},
+		},
+		Required: []string{"shapes", "labels"},
+		Defs: map[string]*Property{
+			"testCircle": {Type: ObjectT, Properties: map[string]*Property{"radius": {Type: Number}}, Required: []string{"radius"}},
+			"testSquare": {Type: ObjectT, Properties: map[string]*Property{"side": {Type: Number}}, Required: []string{"side"}},
+		},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	validator := schemaValidator{defs: got.Defs}
+	shapes := *got.Properties["shapes"]
+	if !validator.validate(shapes, map[string]any{"a": map[string]any{"radius": 1.0}, "b": map[string]any{"side": 2.0}}) {
+		t.Errorf("validate() of union values = false, want true")
+	}
+	if validator.validate(shapes, map[string]any{"a": map[string]any{"edges": 3.0}}) {
+		t.Errorf("validate() of an unknown variant value = true, want false")
+	}
+}
+
+type testPaymentReq struct {
+	CardNumber string `json:"card_number,omitempty"`
+	CVV        string `json:"cvv,omitempty"`
+	Amount     int    `json:"amount"`
+}
+
+func (testPaymentReq) SchemaDependentSchemas() map[string]*InputSchema {
+	return map[string]*InputSchema{
+		"card_number": {Type: Object, Required: []string{"cvv"}},
+	}
+}
+
+type testUnknownDependentReq struct {
+	Amount int `json:"amount"`
+}
+
+func (testUnknownDependentReq) SchemaDependentSchemas() map[string]*InputSchema {
+	return map[string]*InputSchema{"card_number": {Type: Object, Required: []string{"cvv"}}}
+}
+
+func TestGenerateSchemaWithDependentSchemas(t *testing.T) {
+	got, err := generateSchemaFromReqStruct(testPaymentReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := map[string]*InputSchema{"card_number": {Type: Object, Required: []string{"cvv"}}}
+	if !reflect.DeepEqual(got.DependentSchemas, want) {
+		t.Errorf("DependentSchemas = %v, want %v", got.DependentSchemas, want)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if wantJSON := `"dependentSchemas":{"card_number":{"type":"object","required":["cvv"]}}`; !strings.Contains(string(data), wantJSON) {
+		t.Errorf("json.Marshal() = %s, missing %s", data, wantJSON)
+	}
+
+	if err = got.Validate(map[string]any{"amount": 1.0}); err != nil {
+		t.Errorf("Validate() without the dependent property error = %v", err)
+	}
+	if err = got.Validate(map[string]any{"amount": 1.0, "card_number": "4242", "cvv": "123"}); err != nil {
+		t.Errorf("Validate() satisfying the dependent schema error = %v", err)
+	}
+	if err = got.Validate(map[string]any{"amount": 1.0, "card_number": "4242"}); err == nil {
+		t.Errorf("Validate() violating the dependent schema error = nil, wantErr")
+	}
+
+	if _, err = generateSchemaFromReqStruct(testUnknownDependentReq{}); err == nil {
+		t.Errorf("generateSchemaFromReqStruct() with an unknown dependent property error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithLowercaseEnumNormalization(t *testing.T) {
+	type lowercaseEnumReq struct {
+		Status string `json:"status" enum:"Active,INACTIVE" default:"Active"`
+		Level  int    `json:"level" enum:"1,2"`
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), lowercaseEnumReq{}, WithLowercaseEnumNormalization())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"status": {Type: String, Enum: []any{"active", "inactive"}, Default: "active"},
+			"level":  {Type: Integer, Enum: []any{1, 2}},
+		},
+		Required: []string{"status", "level"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaContext() got = %v, want %v", got, want)
+	}
+
+	plain, err := GenerateSchemaContext(context.Background(), lowercaseEnumReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if !reflect.DeepEqual(plain.Properties["status"].Enum, []any{"Active", "INACTIVE"}) {
+		t.Errorf("enum without option = %v", plain.Properties["status"].Enum)
+	}
+
+	_, err = GenerateSchemaContext(context.Background(), struct {
+		Status string `json:"status" enum:"on,ON"`
+	}{}, WithLowercaseEnumNormalization())
+	if err == nil {
+		t.Errorf("GenerateSchemaContext() with members differing only in case error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithSortEnums(t *testing.T) {
+	type sortEnumsReq struct {
+		Status   string   `json:"status" enum:"pending,active,closed"`
+		Priority int      `json:"priority" enum:"10,2,33" enumLabels:"high,low,urgent"`
+		Ratio    float64  `json:"ratio" enum:"2.5,-1,0.5"`
+		Size     *string  `json:"size" enum:"m,s,l" nullable:"true"`
+		Tags     []string `json:"tags" enum:"b,c,a"`
+	}
+
+	sorted, err := GenerateSchemaContext(context.Background(), sortEnumsReq{}, WithSortEnums())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	plain, err := GenerateSchemaContext(context.Background(), sortEnumsReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		enum       func(*InputSchema) []any
+		wantSorted []any
+		wantPlain  []any
+	}{
+		{
+			name:       "strings",
+			enum:       func(s *InputSchema) []any { return s.Properties["status"].Enum },
+			wantSorted: []any{"active", "closed", "pending"},
+			wantPlain:  []any{"pending", "active", "closed"},
+		},
+		{
+			name:       "integers",
+			enum:       func(s *InputSchema) []any { return s.Properties["priority"].Enum },
+			wantSorted: []any{2, 10, 33},
+			wantPlain:  []any{10, 2, 33},
+		},
+		{
+			name:       "numbers",
+			enum:       func(s *InputSchema) []any { return s.Properties["ratio"].Enum },
+			wantSorted: []any{-1.0, 0.5, 2.5},
+			wantPlain:  []any{2.5, -1.0, 0.5},
+		},
+		{
+			name:       "nullable",
+			enum:       func(s *InputSchema) []any { return s.Properties["size"].Enum },
+			wantSorted: []any{"l", "m", "s", nil},
+			wantPlain:  []any{"m", "s", "l", nil},
+		},
+		{
+			name:       "array items",
+			enum:       func(s *InputSchema) []any { return s.Properties["tags"].Items.Enum },
+			wantSorted: []any{"a", "b", "c"},
+			wantPlain:  []any{"b", "c", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.enum(sorted); !reflect.DeepEqual(got, tt.wantSorted) {
+				t.Errorf("enum with WithSortEnums = %#v, want %#v", got, tt.wantSorted)
+			}
+			if got := tt.enum(plain); !reflect.DeepEqual(got, tt.wantPlain) {
+				t.Errorf("enum without WithSortEnums = %#v, want %#v", got, tt.wantPlain)
+			}
+		})
+	}
+
+	if labels := sorted.Properties["priority"].Extensions["x-enumLabels"]; !reflect.DeepEqual(labels, []string{"low", "high", "urgent"}) {
+		t.Errorf("x-enumLabels with WithSortEnums = %v, want the labels sorted along the values", labels)
+	}
+}
+
+// testCivilDate stands in for third-party date types such as civil.Date, marshaling as "2006-01-02"
+type testCivilDate struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+func (d testCivilDate) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)), nil
+}
+
+func TestGenerateSchemaWithRegisteredTimeType(t *testing.T) {
+	dateType := reflect.TypeOf(testCivilDate{})
+	RegisterTypeFormat(dateType, "date")
+	defer unregisterTypeSchema(dateType)
+
+	type civilDateReq struct {
+		Birthday testCivilDate   `json:"birthday"`
+		Deadline *testCivilDate  `json:"deadline,omitempty"`
+		Holidays []testCivilDate `json:"holidays"`
+	}
+
+	got, err := generateSchemaFromReqStruct(civilDateReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"birthday": {Type: String, Format: "date"},
+			"deadline": {Type: String, Format: "date"},
+			"holidays": {Type: Array, Items: &Property{Type: String, Format: "date"}},
+		},
+		Required: []string{"birthday", "holidays"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(civilDateReq{
+		Birthday: testCivilDate{Year: 2024, Month: time.February, Day: 29},
+		Holidays: []testCivilDate{{Year: 2024, Month: time.December, Day: 25}},
+	})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var arguments any
+	if err = json.Unmarshal(data, &arguments); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if err = got.Validate(arguments); err != nil {
+		t.Errorf("Validate() of marshaled dates error = %v", err)
+	}
+}
+
+func TestGenerateSchemaWithMirrorRequiredOnProperty(t *testing.T) {
+	type mirrorRequiredItem struct {
+		SKU   string `json:"sku"`
+		Notes string `json:"notes,omitempty"`
+	}
+	type mirrorRequiredReq struct {
+		Name  string               `json:"name"`
+		Email string               `json:"email,omitempty"`
+		Items []mirrorRequiredItem `json:"items"`
+		Owner *struct {
+			ID string `json:"id"`
+		} `json:"owner,omitempty"`
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), mirrorRequiredReq{}, WithMirrorRequiredOnProperty())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+
+	// agree checks that exactly the properties listed in required carry x-required
+	agree := func(path string, properties map[string]*Property, required []string) {
+		requiredSet := make(map[string]bool, len(required))
+		for _, name := range required {
+			requiredSet[name] = true
+		}
+		for name, p := range properties {
+			if mirrored := p.Extensions["x-required"] == true; mirrored != requiredSet[name] {
+				t.Errorf("%s/%s x-required = %v, required = %v", path, name, mirrored, requiredSet[name])
+			}
+		}
+	}
+	agree("", got.Properties, got.Required)
+	objects := 0
+	if err = got.Walk(func(path string, p *Property) error {
+		if len(p.Properties) > 0 {
+			objects++
+			agree(path, p.Properties, p.Required)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if objects != 2 {
+		t.Errorf("Walk() visited %d nested objects, want 2", objects)
+	}
+
+	plain, err := GenerateSchemaContext(context.Background(), mirrorRequiredReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if len(plain.Properties["name"].Extensions) != 0 {
+		t.Errorf("extensions without option = %v", plain.Properties["name"].Extensions)
+	}
+}
+
+func TestGenerateSchemaWithConst(t *testing.T) {
+	type constReq struct {
+		Kind    string `json:"kind" enum:"circle,square" const:"circle"`
+		Version int    `json:"version" const:"2"`
+	}
+
+	got, err := generateSchemaFromReqStruct(constReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"kind":    {Type: String, Enum: []any{"circle", "square"}, Const: "circle"},
+			"version": {Type: Integer, Const: 2},
+		},
+		Required: []string{"kind", "version"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	if err = got.Validate(map[string]any{"kind": "circle", "version": 2.0}); err != nil {
+		t.Errorf("Validate() of the const values error = %v", err)
+	}
+	if err = got.Validate(map[string]any{"kind": "square", "version": 2.0}); err == nil {
+		t.Errorf("Validate() of an enum member other than the const error = nil, wantErr")
+	}
+
+	for _, v := range []any{
+		struct {
+			Kind string `json:"kind" enum:"circle,square" const:"triangle"`
+		}{},
+		struct {
+			Version int `json:"version" const:"two"`
+		}{},
+	} {
+		if _, err = generateSchemaFromReqStruct(v); err == nil {
+			t.Errorf("generateSchemaFromReqStruct(%T) error = nil, wantErr", v)
+		}
+	}
+}
+
+func TestGenerateSchemaWithInlineOption(t *testing.T) {
+	type inlineMeta struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels,omitempty"`
+	}
+	type inlineReq struct {
+		Meta    inlineMeta     `json:",inline"`
+		Extra   map[string]any `json:",inline"`
+		Count   int            `json:"count"`
+		Comment string         `json:"comment,inline"`
+	}
+
+	got, err := generateSchemaFromReqStruct(inlineReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name":    {Type: String},
+			"labels":  {Type: ObjectT},
+			"count":   {Type: Integer},
+			"comment": {Type: String},
+		},
+		Required:             []string{"count", "comment", "name"},
+		AdditionalProperties: true,
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	_, err = generateSchemaFromReqStruct(struct {
+		Meta  inlineMeta `json:",inline"`
+		Other inlineMeta `json:",inline"`
+	}{})
+	if err == nil {
+		t.Errorf("generateSchemaFromReqStruct() with conflicting inline fields error = nil, wantErr")
+	}
+}
+
+type testIdentifiedReq struct {
+	Query string `json:"query"`
+}
+
+func (testIdentifiedReq) SchemaID() string {
+	return "https://example.com/schemas/search.json"
+}
+
+func TestGenerateSchemaWithID(t *testing.T) {
+	got, err := generateSchemaFromReqStruct(testIdentifiedReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if want := `{"$id":"https://example.com/schemas/search.json","type":"object"`; !strings.HasPrefix(string(data), want) {
+		t.Errorf("json.Marshal() = %s, want prefix %s", data, want)
+	}
+
+	got, err = GenerateSchemaContext(context.Background(), testIdentifiedReq{}, WithSchemaID("urn:example:search"))
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if got.ID != "urn:example:search" {
+		t.Errorf("ID = %q, want the option to take precedence", got.ID)
+	}
+
+	got, err = generateSchemaFromReqStruct(struct {
+		Query string `json:"query"`
+	}{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	if data, _ = json.Marshal(got); strings.Contains(string(data), "$id") {
+		t.Errorf("json.Marshal() = %s, want no $id", data)
+	}
+}
+
+func TestGenerateSchemaWithRequireDescriptions(t *testing.T) {
+	type describedAddress struct {
+		City string `json:"city" description:"city name"`
+	}
+	type describedReq struct {
+		Name    string             `json:"name" description:"user name"`
+		Tags    []string           `json:"tags" description:"labels"`
+		Address describedAddress   `json:"address" description:"postal address"`
+		History []describedAddress `json:"history" description:"previous addresses"`
+	}
+	if _, err := GenerateSchemaContext(context.Background(), describedReq{}, WithRequireDescriptions()); err != nil {
+		t.Errorf("GenerateSchemaContext() of a fully described struct error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		v       any
+		wantErr string
+	}{
+		{
+			name: "top-level",
+			v: struct {
+				Name  string `json:"name" description:"user name"`
+				Email string `json:"email"`
+			}{},
+			wantErr: "property /email has no description",
+		},
+		{
+			name: "nested",
+			v: struct {
+				Address struct {
+					City string `json:"city"`
+				} `json:"address" description:"postal address"`
+			}{},
+			wantErr: "property /address/city has no description",
+		},
+		{
+			name: "array items",
+			v: struct {
+				History []struct {
+					City string `json:"city"`
+				} `json:"history" description:"previous addresses"`
+			}{},
+			wantErr: "property /history/*/city has no description",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GenerateSchemaContext(context.Background(), tt.v, WithRequireDescriptions())
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("GenerateSchemaContext() error = %v, want %s", err, tt.wantErr)
+			}
+			if _, err = generateSchemaFromReqStruct(tt.v); err != nil {
+				t.Errorf("generateSchemaFromReqStruct() without option error = %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaWithStructuralDedup(t *testing.T) {
+	type dedupReq struct {
+		Billing struct {
+			Street string `json:"street"`
+			City   string `json:"city"`
+		} `json:"billing" description:"billing address"`
+		Shipping struct {
+			Street string `json:"street"`
+			City   string `json:"city"`
+		} `json:"shipping" title:"Shipping address" readOnly:"true" default:"{\"street\":\"Main St\",\"city\":\"Springfield\"}"`
+		Contact struct {
+			Email string `json:"email"`
+		} `json:"contact"`
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), dedupReq{}, WithStructuralDedup())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if len(got.Defs) != 1 {
+		t.Fatalf("Defs = %v, want a single definition", got.Defs)
+	}
+	var name string
+	for name = range got.Defs {
+		break
+	}
+	if !strings.HasPrefix(name, "Struct") {
+		t.Errorf("definition name = %s, want a Struct prefixed hash", name)
+	}
+
+	ref := "#/$defs/" + name
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"billing": {Ref: ref, Description: "billing address"},
+			"shipping": {
+				Ref:      ref,
+				Title:    "Shipping address",
+				ReadOnly: true,
+				Default:  map[string]any{"street": "Main St", "city": "Springfield"},
+			},
+			"contact": {Type: ObjectT, Properties: map[string]*Property{"email": {Type: String}}, Required: []string{"email"}},
+		},
+		Required: []string{"billing", "shipping", "contact"},
+		Defs: map[string]*Property{
+			name: {
+				Type:       ObjectT,
+				Properties: map[string]*Property{"street": {Type: String}, "city": {Type: String}},
+				Required:   []string{"street", "city"},
+			},
+		},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaContext() got = %v, want %v", got, want)
+	}
+
+	plain, err := GenerateSchemaContext(context.Background(), dedupReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if len(plain.Defs) != 0 || plain.Properties["shipping"].Ref != "" {
+		t.Errorf("GenerateSchemaContext() without option deduplicated = %v", plain.Defs)
+	}
+}
+
+type testExamplesReq struct {
+	City  string `json:"city" examples:"Paris,Tokyo,Lima"`
+	Count int    `json:"count" examples:"1,10"`
+	Query string `json:"query"`
+}
+
+func (testExamplesReq) SchemaExamples() map[string][]any {
+	return map[string][]any{"city": {"Oslo"}, "query": {"status:open", "label:bug"}}
+}
+
+func TestGenerateSchemaWithExamplesLimit(t *testing.T) {
+	got, err := generateSchemaFromReqStruct(testExamplesReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"city":  {Type: String, Examples: []any{"Paris", "Tokyo", "Lima", "Oslo"}},
+			"count": {Type: Integer, Examples: []any{1, 10}},
+			"query": {Type: String, Examples: []any{"status:open", "label:bug"}},
+		},
+		Required: []string{"city", "count", "query"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	got, err = GenerateSchemaContext(context.Background(), testExamplesReq{}, WithExamplesLimit(1))
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	want.Properties = map[string]*Property{
+		"city":  {Type: String, Examples: []any{"Paris"}},
+		"count": {Type: Integer, Examples: []any{1}},
+		"query": {Type: String, Examples: []any{"status:open"}},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaContext() got = %v, want %v", got, want)
+	}
+
+	if _, err = generateSchemaFromReqStruct(struct {
+		Count int `json:"count" examples:"one"`
+	}{}); err == nil {
+		t.Errorf("generateSchemaFromReqStruct() with invalid examples error = nil, wantErr")
+	}
+}
+
+// ASTNode models a recursive syntax tree, its variants refer back to the interface
+type ASTNode interface {
+	node()
+}
+
+type testLiteralNode struct {
+	Value float64 `json:"value"`
+}
+
+func (testLiteralNode) node() {}
+
+type testBinaryNode struct {
+	Op    string  `json:"op" enum:"+,-"`
+	Left  ASTNode `json:"left"`
+	Right ASTNode `json:"right"`
+}
+
+func (*testBinaryNode) node() {}
+
+type testListNode struct {
+	Items []ASTNode `json:"items"`
+}
+
+func (testListNode) node() {}
+
+func TestGenerateSchemaWithRecursiveInterface(t *testing.T) {
+	nodeType := reflect.TypeOf((*ASTNode)(nil)).Elem()
+	if err := RegisterUnion(nodeType, testLiteralNode{}, &testBinaryNode{}, testListNode{}); err != nil {
+		t.Fatalf("RegisterUnion() error = %v", err)
+	}
+	defer unregisterTypeSchema(nodeType)
+
+	type recursiveASTReq struct {
+		Root ASTNode `json:"root"`
+	}
+
+	got, err := generateSchemaFromReqStruct(recursiveASTReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	node := func() *Property {
+		return &Property{OneOf: []*Property{
+			{Ref: "#/$defs/testLiteralNode"},
+			{Ref: "#/$defs/testBinaryNode"},
+			{Ref: "#/$defs/testListNode"},
+		}}
+	}
+	want := &InputSchema{
+		Type:       Object,
+		Properties: map[string]*Property{"root": node()},
+		Required:   []string{"root"},
+		Defs: map[string]*Property{
+			"testLiteralNode": {Type: ObjectT, Properties: map[string]*Property{"value": {Type: Number}}, Required: []string{"value"}},
+			"testBinaryNode": {
+				Type: ObjectT,
+				Properties: map[string]*Property{
+					"op":    {Type: String, Enum: []any{"+", "-"}},
+					"left":  node(),
+					"right": node(),
+				},
+				Required: []string{"op", "left", "right"},
+			},
+			"testListNode": {
+				Type:       ObjectT,
+				Properties: map[string]*Property{"items": {Type: Array, Items: node()}},
+				Required:   []string{"items"},
+			},
+		},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	if err = got.Validate(map[string]any{"root": map[string]any{
+		"op":    "+",
+		"left":  map[string]any{"value": 1.0},
+		"right": map[string]any{"items": []any{map[string]any{"value": 2.0}}},
+	}}); err != nil {
+		t.Errorf("Validate() of a nested tree error = %v", err)
+	}
+	if err = got.Validate(map[string]any{"root": map[string]any{
+		"op":    "*",
+		"left":  map[string]any{"value": 1.0},
+		"right": map[string]any{"value": 2.0},
+	}}); err == nil {
+		t.Errorf("Validate() of an invalid inner node error = nil, wantErr")
+	}
+}
+
+type testNumberDefaultsReq struct {
+	Ratio  float64 `json:"ratio,omitempty" default:"3"`
+	Weight float32 `json:"weight,omitempty"`
+	Count  int     `json:"count,omitempty" default:"5"`
+	Limit  int     `json:"limit,omitempty"`
+	Name   string  `json:"name,omitempty" default:"3"`
+}
+
+func (testNumberDefaultsReq) SchemaDefaults() map[string]any {
+	return map[string]any{"weight": 2, "limit": 10.0}
+}
+
+func TestGenerateSchemaWithNormalizeNumbers(t *testing.T) {
+	got, err := GenerateSchemaContext(context.Background(), testNumberDefaultsReq{}, WithNormalizeNumbers())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+
+	tests := []struct {
+		property string
+		want     any
+	}{
+		{property: "ratio", want: 3.0},
+		{property: "weight", want: 2.0},
+		{property: "count", want: 5},
+		{property: "limit", want: 10},
+		{property: "name", want: "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			if got := got.Properties[tt.property].Default; got != tt.want {
+				t.Errorf("Default = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+
+	plain, err := GenerateSchemaContext(context.Background(), testNumberDefaultsReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if weight := plain.Properties["weight"].Default; weight != 2 {
+		t.Errorf("Default without option = %v (%T), want the int 2", weight, weight)
+	}
+}
+
+type testItemStatus string
+
+func (testItemStatus) EnumValues() []any {
+	return []any{"open", "closed"}
+}
+
+type testItemPriority int
+
+func (testItemPriority) EnumValues() []any {
+	return []any{"high"}
+}
+
+func TestGenerateSchemaWithArrayItemEnumFromElementMethod(t *testing.T) {
+	type itemEnumReq struct {
+		Statuses []testItemStatus  `json:"statuses"`
+		Pair     [2]testItemStatus `json:"pair"`
+		Status   testItemStatus    `json:"status"`
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), itemEnumReq{}, WithArrayItemEnumFromElementMethod())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	two := 2
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"statuses": {Type: Array, Items: &Property{Type: String, Enum: []any{"open", "closed"}}},
+			"pair":     {Type: Array, Items: &Property{Type: String, Enum: []any{"open", "closed"}}, MinItems: &two, MaxItems: &two},
+			"status":   {Type: String},
+		},
+		Required: []string{"statuses", "pair", "status"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaContext() got = %v, want %v", got, want)
+	}
+
+	plain, err := GenerateSchemaContext(context.Background(), itemEnumReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if enum := plain.Properties["statuses"].Items.Enum; len(enum) != 0 {
+		t.Errorf("items enum without option = %v", enum)
+	}
+
+	_, err = GenerateSchemaContext(context.Background(), struct {
+		Priorities []testItemPriority `json:"priorities"`
+	}{}, WithArrayItemEnumFromElementMethod())
+	if err == nil {
+		t.Errorf("GenerateSchemaContext() with enum values of the wrong type error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaRequiredOrderWithEmbeddedFields(t *testing.T) {
+	type orderAudit struct {
+		CreatedBy string `json:"created_by"`
+		UpdatedBy string `json:"updated_by"`
+	}
+	type orderPaging struct {
+		Page int `json:"page"`
+	}
+	type embeddedOrderReq struct {
+		ID string `json:"id"`
+		orderAudit
+		Name   string      `json:"name"`
+		Paging orderPaging `json:",inline"`
+		Note   string      `json:"note"`
+	}
+
+	got, err := generateSchemaFromReqStruct(embeddedOrderReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := []string{"id", "created_by", "updated_by", "name", "page", "note"}
+	if !reflect.DeepEqual(got.Required, want) {
+		t.Errorf("Required = %v, want %v", got.Required, want)
+	}
+}
+
+func TestGenerateSchemaWithOmitemptyEmbeddedStruct(t *testing.T) {
+	type omitemptyAudit struct {
+		CreatedBy string `json:"created_by"`
+		Revision  int    `json:"revision,omitempty"`
+	}
+	type untaggedEmbeddedReq struct {
+		ID string `json:"id"`
+		omitemptyAudit
+	}
+	type omitemptyEmbeddedReq struct {
+		ID             string `json:"id"`
+		omitemptyAudit `json:",omitempty"`
+	}
+
+	want, err := generateSchemaFromReqStruct(untaggedEmbeddedReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	got, err := generateSchemaFromReqStruct(omitemptyEmbeddedReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() with omitempty on the embedded struct = %+v, want %+v", got, want)
+	}
+	if _, ok := got.Properties["created_by"]; !ok {
+		t.Errorf("Properties = %v, want the flattened created_by", got.Properties)
+	}
+	if wantRequired := []string{"id", "created_by"}; !reflect.DeepEqual(got.Required, wantRequired) {
+		t.Errorf("Required = %v, want %v", got.Required, wantRequired)
+	}
+}
+
+var testHandCraftedSchema = &InputSchema{
+	Type: Object,
+	Properties: map[string]*Property{
+		"query": {Type: String, Description: "search query", Default: "*"},
+		"tags":  {Type: Array, Items: &Property{Type: String, Enum: []any{"a", "b"}}},
+	},
+	Required:             []string{"query"},
+	AdditionalProperties: false,
+}
+
+type testHandCraftedReq struct {
+	Query string `json:"q" description:"ignored"`
+	Limit int    `json:"limit"`
+}
+
+func (testHandCraftedReq) MCPInputSchema() *InputSchema {
+	return testHandCraftedSchema
+}
+
+type testNilSchemaReq struct{}
+
+func (*testNilSchemaReq) MCPInputSchema() *InputSchema {
+	return nil
+}
+
+func TestGenerateSchemaWithInputSchemaProvider(t *testing.T) {
+	got, err := GenerateSchemaContext(context.Background(), testHandCraftedReq{}, WithRequireDescriptions())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, testHandCraftedSchema) {
+		t.Errorf("GenerateSchemaContext() got = %v, want %v", got, testHandCraftedSchema)
+	}
+	if got == testHandCraftedSchema || got.Properties["tags"] == testHandCraftedSchema.Properties["tags"] {
+		t.Fatalf("GenerateSchemaContext() returned the provided schema instead of a clone")
+	}
+
+	got.Properties["query"].Description = "changed"
+	got.Properties["tags"].Items.Enum[0] = "changed"
+	got.Required[0] = "changed"
+	if testHandCraftedSchema.Properties["query"].Description != "search query" ||
+		testHandCraftedSchema.Properties["tags"].Items.Enum[0] != "a" ||
+		testHandCraftedSchema.Required[0] != "query" {
+		t.Errorf("modifying the generated schema changed the provided one: %v", testHandCraftedSchema)
+	}
+
+	if _, err = generateSchemaFromReqStruct(testNilSchemaReq{}); err == nil {
+		t.Errorf("generateSchemaFromReqStruct() of a nil provided schema error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithPattern(t *testing.T) {
+	type patternReq struct {
+		Code string `json:"code" pattern:"^[A-Z]{3}$" default:"ABC"`
+	}
+
+	got, err := generateSchemaFromReqStruct(patternReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &Property{Type: String, Pattern: "^[A-Z]{3}$", Default: "ABC"}
+	if !compareProperty(got.Properties["code"], want) {
+		t.Errorf("generateSchemaFromReqStruct() code = %v, want %v", got.Properties["code"], want)
+	}
+	if !validate(*want, "XYZ") || validate(*want, "xyz") {
+		t.Errorf("validate() does not enforce the pattern")
+	}
+
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{name: "default violating the pattern", v: struct {
+			Code string `json:"code" pattern:"^[A-Z]{3}$" default:"abcd"`
+		}{}},
+		{name: "invalid pattern", v: struct {
+			Code string `json:"code" pattern:"[A-Z"`
+		}{}},
+		{name: "pattern on a number", v: struct {
+			Count int `json:"count" pattern:"^[0-9]+$"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := generateSchemaFromReqStruct(tt.v); err == nil {
+				t.Errorf("generateSchemaFromReqStruct() error = nil, wantErr")
+			}
+		})
+	}
+}
+
+// MinimalShape is a union with a single registered variant
+type MinimalShape interface {
+	minimal()
+}
+
+type testMinimalCircle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (testMinimalCircle) minimal() {}
+
+func TestGenerateSchemaWithMinimal(t *testing.T) {
+	shapeType := reflect.TypeOf((*MinimalShape)(nil)).Elem()
+	if err := RegisterUnion(shapeType, testMinimalCircle{}); err != nil {
+		t.Fatalf("RegisterUnion() error = %v", err)
+	}
+	defer unregisterTypeSchema(shapeType)
+
+	type minimalReq struct {
+		Kind   string         `json:"kind" enum:"circle,square" const:"circle"`
+		Shape  MinimalShape   `json:"shape" description:"the shape"`
+		Extras map[string]any `json:",inline"`
+		None   [0]string      `json:"none,omitempty"`
+	}
+
+	full, err := GenerateSchemaContext(context.Background(), minimalReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	got, err := GenerateSchemaContext(context.Background(), minimalReq{}, WithMinimal())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+
+	zero := 0
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"kind":  {Type: String, Const: "circle"},
+			"shape": {Ref: "#/$defs/testMinimalCircle", Description: "the shape"},
+			"none":  {Type: Array, Items: &Property{Type: String}, MaxItems: &zero},
+		},
+		Required: []string{"kind", "shape"},
+		Defs: map[string]*Property{
+			"testMinimalCircle": {Type: ObjectT, Properties: map[string]*Property{"radius": {Type: Number}}, Required: []string{"radius"}},
+		},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaContext() got = %v, want %v", got, want)
+	}
+
+	fullData, _ := json.Marshal(full)
+	minimalData, _ := json.Marshal(got)
+	if len(minimalData) >= len(fullData) {
+		t.Errorf("minimal schema %s is not smaller than %s", minimalData, fullData)
+	}
+
+	for _, data := range []map[string]any{
+		{"kind": "circle", "shape": map[string]any{"radius": 1.0}, "extra": true},
+		{"kind": "square", "shape": map[string]any{"radius": 1.0}},
+		{"kind": "circle", "shape": map[string]any{"side": 1.0}},
+		{"kind": "circle"},
+	} {
+		if fullErr, minimalErr := full.Validate(data), got.Validate(data); (fullErr == nil) != (minimalErr == nil) {
+			t.Errorf("Validate(%v) = %v for the minimal schema, %v for the full one", data, minimalErr, fullErr)
+		}
+	}
+}
+
+func TestGenerateSchemaWithForceRequiredOrder(t *testing.T) {
+	type requiredOrderReq struct {
+		Zone   string `json:"zone"`
+		Amount int    `json:"amount"`
+		Nested struct {
+			Beta  string `json:"beta"`
+			Alpha string `json:"alpha"`
+		} `json:"nested"`
+	}
+
+	tests := []struct {
+		name       string
+		opts       []SchemaOption
+		want       []string
+		wantNested []string
+	}{
+		{
+			name:       "declaration by default",
+			want:       []string{"zone", "amount", "nested"},
+			wantNested: []string{"beta", "alpha"},
+		},
+		{
+			name:       "declaration",
+			opts:       []SchemaOption{WithForceRequiredOrder(RequiredOrderDeclaration)},
+			want:       []string{"zone", "amount", "nested"},
+			wantNested: []string{"beta", "alpha"},
+		},
+		{
+			name:       "sorted",
+			opts:       []SchemaOption{WithForceRequiredOrder(RequiredOrderSorted)},
+			want:       []string{"amount", "nested", "zone"},
+			wantNested: []string{"alpha", "beta"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateSchemaContext(context.Background(), requiredOrderReq{}, tt.opts...)
+			if err != nil {
+				t.Fatalf("GenerateSchemaContext() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Required, tt.want) {
+				t.Errorf("Required = %v, want %v", got.Required, tt.want)
+			}
+			if nested := got.Properties["nested"].Required; !reflect.DeepEqual(nested, tt.wantNested) {
+				t.Errorf("nested Required = %v, want %v", nested, tt.wantNested)
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaWithStringifiedIntKeys(t *testing.T) {
+	type intKeysReq struct {
+		Scores map[int]string  `json:"scores"`
+		Counts map[uint8]int   `json:"counts"`
+		Labels map[string]bool `json:"labels"`
+	}
+
+	if _, err := generateSchemaFromReqStruct(intKeysReq{}); err == nil {
+		t.Errorf("generateSchemaFromReqStruct() of integer keys without option error = nil, wantErr")
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), intKeysReq{}, WithStringifiedIntKeys())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"scores": {Type: ObjectT, PropertyNames: &Property{Type: String, Pattern: `^-?\d+$`}},
+			"counts": {Type: ObjectT, PropertyNames: &Property{Type: String, Pattern: `^\d+$`}},
+			"labels": {Type: ObjectT},
+		},
+		Required: []string{"scores", "counts", "labels"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaContext() got = %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(map[int]string{-1: "a", 2: "b"})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var scores any
+	if err = json.Unmarshal(data, &scores); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !validate(*got.Properties["scores"], scores) {
+		t.Errorf("validate() of marshaled integer keys %s = false, want true", data)
+	}
+	if validate(*got.Properties["scores"], map[string]any{"one": "a"}) {
+		t.Errorf("validate() of a non-integer key = true, want false")
+	}
+}
+
+func TestGenerateSchemaArrayItemsRequired(t *testing.T) {
+	type arrayItemsLine struct {
+		SKU      string `json:"sku"`
+		Quantity int    `json:"quantity"`
+		Note     string `json:"note,omitempty"`
+	}
+	type arrayItemsReq struct {
+		Lines    []arrayItemsLine  `json:"lines,omitempty"`
+		Pointers []*arrayItemsLine `json:"pointers" required:"false"`
+	}
+
+	got, err := generateSchemaFromReqStruct(arrayItemsReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	line := func() *Property {
+		return &Property{
+			Type: ObjectT,
+			Properties: map[string]*Property{
+				"sku":      {Type: String},
+				"quantity": {Type: Integer},
+				"note":     {Type: String},
+			},
+			Required: []string{"sku", "quantity"},
+		}
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"lines":    {Type: Array, Items: line()},
+			"pointers": {Type: Array, Items: line()},
+		},
+		Required: []string{},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	if err = got.Validate(map[string]any{}); err != nil {
+		t.Errorf("Validate() without the optional arrays error = %v", err)
+	}
+	if err = got.Validate(map[string]any{"lines": []any{map[string]any{"sku": "A"}}}); err == nil {
+		t.Errorf("Validate() of an item missing a required field error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithTitleFromFieldName(t *testing.T) {
+	type titleReq struct {
+		MaxRetries    int    `json:"max_retries"`
+		HTTPServerURL string `json:"http_server_url"`
+		UserID        string `json:"user_id"`
+		Name          string `json:"name" title:"Full name"`
+		Page2Token    string `json:"page2_token"`
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), titleReq{}, WithTitleFromFieldName())
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	want := map[string]string{
+		"max_retries":     "Max Retries",
+		"http_server_url": "HTTP Server URL",
+		"user_id":         "User ID",
+		"name":            "Full name",
+		"page2_token":     "Page2 Token",
+	}
+	for name, title := range want {
+		if got.Properties[name].Title != title {
+			t.Errorf("title of %s = %q, want %q", name, got.Properties[name].Title, title)
+		}
+	}
+
+	plain, err := GenerateSchemaContext(context.Background(), titleReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	if plain.Properties["max_retries"].Title != "" || plain.Properties["name"].Title != "Full name" {
+		t.Errorf("titles without option = %q, %q", plain.Properties["max_retries"].Title, plain.Properties["name"].Title)
+	}
+}
+
+func TestGenerateSchemaWithMinimumMaximum(t *testing.T) {
+	zero, maxAge, minTemperature := 0.0, 120.0, -273.15
+	type boundsReq struct {
+		Age         int     `json:"age" minimum:"0" maximum:"120"`
+		Temperature float64 `json:"temperature" minimum:"-273.15"`
+		Name        string  `json:"name"`
+	}
+
+	got, err := generateSchemaFromReqStruct(boundsReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"age":         {Type: Integer, Minimum: &zero, Maximum: &maxAge},
+			"temperature": {Type: Number, Minimum: &minTemperature},
+			"name":        {Type: String},
+		},
+		Required: []string{"age", "temperature", "name"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(got.Properties["age"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if wantJSON := `{"type":"integer","minimum":0,"maximum":120}`; string(data) != wantJSON {
+		t.Errorf("json.Marshal() = %s, want %s", data, wantJSON)
+	}
+	if data, _ = json.Marshal(got.Properties["name"]); strings.Contains(string(data), "imum") {
+		t.Errorf("json.Marshal() = %s, want no bounds", data)
+	}
+
+	age := *got.Properties["age"]
+	if !validate(age, 0.0) || !validate(age, 120.0) || validate(age, -1.0) || validate(age, 121.0) {
+		t.Errorf("validate() does not enforce the bounds of age")
+	}
+
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{name: "invalid number", v: struct {
+			Age int `json:"age" minimum:"zero"`
+		}{}},
+		{name: "non-numeric type", v: struct {
+			Name string `json:"name" maximum:"10"`
+		}{}},
+		{name: "minimum exceeding maximum", v: struct {
+			Age int `json:"age" minimum:"10" maximum:"1"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := generateSchemaFromReqStruct(tt.v); err == nil {
+				t.Errorf("generateSchemaFromReqStruct() error = nil, wantErr")
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaWithStringConstraints(t *testing.T) {
+	minLength, maxLength := 3, 32
+	type stringConstraintsReq struct {
+		Username string `json:"username" minLength:"3" maxLength:"32" pattern:"^[a-z0-9_]+$"`
+		Bio      string `json:"bio,omitempty"`
+	}
+
+	got, err := generateSchemaFromReqStruct(stringConstraintsReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"username": {Type: String, MinLength: &minLength, MaxLength: &maxLength, Pattern: "^[a-z0-9_]+$"},
+			"bio":      {Type: String},
+		},
+		Required: []string{"username"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(got.Properties["bio"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(data) != `{"type":"string"}` {
+		t.Errorf("json.Marshal() of an unconstrained string = %s", data)
+	}
+
+	username := *got.Properties["username"]
+	for value, valid := range map[string]bool{
+		"bob":                   true,
+		"ab":                    false,
+		"Bob":                   false,
+		strings.Repeat("a", 33): false,
+	} {
+		if validate(username, value) != valid {
+			t.Errorf("validate(%q) = %v, want %v", value, !valid, valid)
+		}
+	}
+
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{name: "negative length", v: struct {
+			Name string `json:"name" minLength:"-1"`
+		}{}},
+		{name: "non-string type", v: struct {
+			Count int `json:"count" maxLength:"3"`
+		}{}},
+		{name: "minLength exceeding maxLength", v: struct {
+			Name string `json:"name" minLength:"5" maxLength:"2"`
+		}{}},
+		{name: "broken pattern", v: struct {
+			Name string `json:"name" pattern:"(a"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := generateSchemaFromReqStruct(tt.v); err == nil {
+				t.Errorf("generateSchemaFromReqStruct() error = nil, wantErr")
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaWithNumberParser(t *testing.T) {
+	type numberParserReq struct {
+		Ratio float64 `json:"ratio" default:"2,5" minimum:"0,5" maximum:"10"`
+		Count int     `json:"count" default:"3"`
+	}
+	commaDecimal := func(s string) (float64, error) {
+		return strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	}
+
+	got, err := GenerateSchemaContext(context.Background(), numberParserReq{}, WithNumberParser(commaDecimal))
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	minimum, maximum := 0.5, 10.0
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"ratio": {Type: Number, Default: 2.5, Minimum: &minimum, Maximum: &maximum},
+			"count": {Type: Integer, Default: 3},
+		},
+		Required: []string{"ratio", "count"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchemaContext() got = %v, want %v", got, want)
+	}
+
+	if _, err := GenerateSchemaContext(context.Background(), numberParserReq{}); err == nil {
+		t.Errorf("GenerateSchemaContext() without a number parser error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithObjectEnum(t *testing.T) {
+	type objectEnumResolution struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	}
+	type objectEnumReq struct {
+		Resolution objectEnumResolution `json:"resolution" enum:"[{\"width\":800,\"height\":600},{\"width\":1024,\"height\":768}]"`
+	}
+
+	schema, err := generateSchemaFromReqStruct(objectEnumReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := []any{
+		map[string]any{"width": float64(800), "height": float64(600)},
+		map[string]any{"width": float64(1024), "height": float64(768)},
+	}
+	if got := schema.Properties["resolution"].Enum; !reflect.DeepEqual(got, want) {
+		t.Errorf("enum = %v, want %v", got, want)
+	}
+
+	for data, valid := range map[string]bool{
+		`{"resolution":{"width":800,"height":600}}`:  true,
+		`{"resolution":{"width":1024,"height":768}}`: true,
+		`{"resolution":{"width":800,"height":768}}`:  false,
+		`{"resolution":{"width":800}}`:               false,
+	} {
+		var v map[string]any
+		if err := json.Unmarshal([]byte(data), &v); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if err := schema.Validate(v); (err == nil) != valid {
+			t.Errorf("Validate(%s) error = %v, want valid %v", data, err, valid)
+		}
+	}
+
+	type invalidObjectEnumReq struct {
+		Resolution objectEnumResolution `json:"resolution" enum:"[{\"width\":\"wide\",\"height\":600}]"`
+	}
+	if _, err := generateSchemaFromReqStruct(invalidObjectEnumReq{}); err == nil {
+		t.Errorf("generateSchemaFromReqStruct() with an enum member not matching the struct error = nil, wantErr")
+	}
+}
+
+type nestedSliceOption struct {
+	Name  string `json:"name"`
+	Price int    `json:"price,omitempty"`
+}
+
+type nestedSliceLineItem struct {
+	SKU     string              `json:"sku"`
+	Options []nestedSliceOption `json:"options,omitempty"`
+}
+
+type nestedSliceNode struct {
+	Name     string            `json:"name"`
+	Children []nestedSliceNode `json:"children"`
+}
+
+func TestGenerateSchemaWithNestedSlicesOfStructs(t *testing.T) {
+	type nestedSliceReq struct {
+		Items []nestedSliceLineItem `json:"items"`
+	}
+
+	got, err := generateSchemaFromReqStruct(nestedSliceReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"items": {
+				Type: Array,
+				Items: &Property{
+					Type: ObjectT,
+					Properties: map[string]*Property{
+						"sku": {Type: String},
+						"options": {
+							Type: Array,
+							Items: &Property{
+								Type: ObjectT,
+								Properties: map[string]*Property{
+									"name":  {Type: String},
+									"price": {Type: Integer},
+								},
+								Required: []string{"name"},
+							},
+						},
+					},
+					Required: []string{"sku"},
+				},
+			},
+		},
+		Required: []string{"items"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	type selfReferentialReq struct {
+		Root nestedSliceNode `json:"root"`
+	}
+	if _, err := generateSchemaFromReqStruct(selfReferentialReq{}); err == nil || !strings.Contains(err.Error(), "self-referential") {
+		t.Errorf("generateSchemaFromReqStruct() of a self-referential type error = %v, want self-referential error", err)
+	}
+}
+
+func TestGenerateSchemaWithFormatTag(t *testing.T) {
+	type formatReq struct {
+		Email    string     `json:"email" format:"email"`
+		Birthday string     `json:"birthday" format:"date"`
+		At       time.Time  `json:"at"`
+		Until    *time.Time `json:"until,omitempty"`
+		Count    int64      `json:"count" format:"int64"`
+		Ratio    float32    `json:"ratio" format:"float"`
+		Serial   string     `json:"serial" format:"int64"`
+	}
+
+	got, err := generateSchemaFromReqStruct(formatReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"email":    {Type: String, Format: "email"},
+			"birthday": {Type: String, Format: "date"},
+			"at":       {Type: String, Format: "date-time"},
+			"until":    {Type: String, Format: "date-time"},
+			"count":    {Type: Integer, Format: "int64"},
+			"ratio":    {Type: Number, Format: "float"},
+			"serial":   {Type: String, Format: "int64"},
+		},
+		Required: []string{"email", "birthday", "at", "count", "ratio", "serial"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	invalid := []any{
+		struct {
+			Count int `json:"count" format:"email"`
+		}{},
+		struct {
+			Count int `json:"count" format:"double"`
+		}{},
+		struct {
+			Ratio float64 `json:"ratio" format:"int32"`
+		}{},
+		struct {
+			Enabled bool `json:"enabled" format:"int32"`
+		}{},
+		struct {
+			Tags []string `json:"tags" format:"email"`
+		}{},
+	}
+	for _, v := range invalid {
+		if _, err := generateSchemaFromReqStruct(v); err == nil {
+			t.Errorf("generateSchemaFromReqStruct(%T) with a format mismatching the type error = nil, wantErr", v)
+		}
+	}
+}
+
+func TestGenerateSchemaPointerFieldsAreOptional(t *testing.T) {
+	type pointerFieldsReq struct {
+		Name     string  `json:"name"`
+		Nickname *string `json:"nickname"`
+		Age      *int    `json:"age" required:"true"`
+		Email    string  `json:"email,omitempty"`
+	}
+
+	got, err := generateSchemaFromReqStruct(pointerFieldsReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	if want := []string{"name", "age"}; !reflect.DeepEqual(got.Required, want) {
+		t.Errorf("generateSchemaFromReqStruct() required = %v, want %v", got.Required, want)
+	}
+}
+
+func TestGenerateSchemaWithOptions(t *testing.T) {
+	type generateSchemaAddress struct {
+		City string `json:"city,omitempty"`
+	}
+	type generateSchemaReq struct {
+		Name     string                `json:"name"`
+		Nickname *string               `json:"nickname"`
+		Email    string                `json:"email,omitempty"`
+		Debug    bool                  `json:"debug,omitempty" required:"false"`
+		Address  generateSchemaAddress `json:"address"`
+	}
+
+	got, err := GenerateSchema(generateSchemaReq{}, WithStrictRequired(), WithTitle("Create user"), WithAdditionalProperties(false))
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	want := &InputSchema{
+		Title: "Create user",
+		Type:  Object,
+		Properties: map[string]*Property{
+			"name":     {Type: String},
+			"nickname": {Type: String},
+			"email":    {Type: String},
+			"debug":    {Type: Boolean},
+			"address": {
+				Type:                 ObjectT,
+				Properties:           map[string]*Property{"city": {Type: String}},
+				Required:             []string{"city"},
+				AdditionalProperties: false,
+			},
+		},
+		Required:             []string{"name", "nickname", "email", "address"},
+		AdditionalProperties: false,
+	}
+	if !compareInputSchema(got, want) || got.AdditionalProperties != false {
+		t.Errorf("GenerateSchema() got = %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"title":"Create user"`) || !strings.Contains(string(data), `"additionalProperties":false`) {
+		t.Errorf("json.Marshal() = %s, want title and additionalProperties", data)
+	}
+
+	plain, err := GenerateSchema(generateSchemaReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	if plain.Title != "" || plain.AdditionalProperties != nil {
+		t.Errorf("GenerateSchema() without options got title %q and additionalProperties %v", plain.Title, plain.AdditionalProperties)
+	}
+}
+
+func TestGenerateSchemaWithZeroDefaultsOnOptionalFields(t *testing.T) {
+	type zeroDefaultsReq struct {
+		Retries int     `json:"retries,omitempty" default:"0"`
+		Ratio   float64 `json:"ratio,omitempty" default:"0"`
+		Verbose bool    `json:"verbose,omitempty" default:"false"`
+		Prefix  string  `json:"prefix" default:""`
+	}
+
+	got, err := generateSchemaFromReqStruct(zeroDefaultsReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"retries": {Type: Integer, Default: 0},
+			"ratio":   {Type: Number, Default: 0.0},
+			"verbose": {Type: Boolean, Default: false},
+			"prefix":  {Type: String},
+		},
+		Required: []string{"prefix"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	data, err := json.Marshal(got.Properties["retries"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(data) != `{"type":"integer","default":0}` {
+		t.Errorf("json.Marshal() = %s, want the zero default to be emitted", data)
+	}
+}
+
+type enumDescriptionsStatus string
+
+func (enumDescriptionsStatus) EnumDescriptions() map[any]string {
+	return map[any]string{
+		enumDescriptionsStatus("open"):   "Awaiting triage",
+		enumDescriptionsStatus("closed"): "Resolved or rejected",
+	}
+}
+
+func TestGenerateSchemaWithEnumDescriptionsFromMethod(t *testing.T) {
+	type enumDescriptionsReq struct {
+		Status enumDescriptionsStatus `json:"status" enum:"open,closed"`
+	}
+
+	got, err := GenerateSchema(enumDescriptionsReq{}, WithEnumDescriptionsFromMethod())
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	data, err := json.Marshal(got.Properties["status"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"enum":["open","closed"],"type":"string","x-enumDescriptions":{"closed":"Resolved or rejected","open":"Awaiting triage"}}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	plain, err := GenerateSchema(enumDescriptionsReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	if len(plain.Properties["status"].Extensions) != 0 {
+		t.Errorf("GenerateSchema() without the option got extensions %v", plain.Properties["status"].Extensions)
+	}
+
+	type narrowEnumDescriptionsReq struct {
+		Status enumDescriptionsStatus `json:"status" enum:"open"`
+	}
+	if _, err = GenerateSchema(narrowEnumDescriptionsReq{}, WithEnumDescriptionsFromMethod()); err == nil {
+		t.Errorf("GenerateSchema() describing a value outside the enum error = nil, wantErr")
+	}
+}
+
+type titledReq struct {
+	Query string `json:"query" title:"Query" description:"The full text search terms, e.g. \"golang mcp\""`
+	Limit int    `json:"limit,omitempty"`
+}
+
+func (titledReq) SchemaTitle() string { return "Search" }
+
+func TestGenerateSchemaWithTitles(t *testing.T) {
+	got, err := generateSchemaFromReqStruct(titledReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"title":"Search","type":"object","properties":{"limit":{"type":"integer"},` +
+		`"query":{"type":"string","title":"Query","description":"The full text search terms, e.g. \"golang mcp\""}},"required":["query"]}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	overridden, err := GenerateSchema(titledReq{}, WithTitle("Search documents"))
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	if overridden.Title != "Search documents" {
+		t.Errorf("GenerateSchema() title = %q, want the title of WithTitle", overridden.Title)
+	}
+}
+
+type refPrefixLevel string
+
+func TestGenerateSchemaWithRefPrefix(t *testing.T) {
+	levelType := reflect.TypeOf(refPrefixLevel(""))
+	if err := RegisterEnum(levelType, "debug", "info"); err != nil {
+		t.Fatalf("RegisterEnum() error = %v", err)
+	}
+	defer unregisterTypeSchema(levelType)
+
+	type refPrefixReq struct {
+		Level  refPrefixLevel   `json:"level"`
+		Levels []refPrefixLevel `json:"levels,omitempty"`
+	}
+
+	for prefix, wantRef := range map[string]string{
+		"":               "#/$defs/refPrefixLevel",
+		"#/definitions/": "#/definitions/refPrefixLevel",
+		"https://example.com/bundle.json#/$defs/": "https://example.com/bundle.json#/$defs/refPrefixLevel",
+	} {
+		var opts []SchemaOption
+		if prefix != "" {
+			opts = append(opts, WithRefPrefix(prefix))
+		}
+		got, err := GenerateSchema(refPrefixReq{}, opts...)
+		if err != nil {
+			t.Fatalf("GenerateSchema() with prefix %q error = %v", prefix, err)
+		}
+		if ref := got.Properties["level"].Ref; ref != wantRef {
+			t.Errorf("GenerateSchema() with prefix %q ref = %s, want %s", prefix, ref, wantRef)
+		}
+		if ref := got.Properties["levels"].Items.Ref; ref != wantRef {
+			t.Errorf("GenerateSchema() with prefix %q items ref = %s, want %s", prefix, ref, wantRef)
+		}
+		if err = got.Validate(map[string]any{"level": "info"}); err != nil {
+			t.Errorf("Validate() with prefix %q error = %v", prefix, err)
+		}
+	}
+
+	if _, err := GenerateSchema(refPrefixReq{}, WithRefPrefix("#/definitions")); err == nil {
+		t.Errorf("GenerateSchema() with a prefix not ending with a slash error = nil, wantErr")
+	}
+}
+
+func TestGenerateSchemaWithSliceEnums(t *testing.T) {
+	type sliceEnumsReq struct {
+		Tags       []string   `json:"tags" enum:"red,green,blue" enumLabels:"Red,Green,Blue"`
+		Priorities []int      `json:"priorities,omitempty" enum:"1,2,3"`
+		Weights    *[]float64 `json:"weights,omitempty" enum:"0.5,1.5"`
+		Corners    [2]string  `json:"corners,omitempty" enum:"top,bottom"`
+	}
+
+	got, err := generateSchemaFromReqStruct(sliceEnumsReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	two := 2
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"tags": {Type: Array, Items: &Property{
+				Type:       String,
+				Enum:       []any{"red", "green", "blue"},
+				Extensions: map[string]any{"x-enumLabels": []string{"Red", "Green", "Blue"}},
+			}},
+			"priorities": {Type: Array, Items: &Property{Type: Integer, Enum: []any{1, 2, 3}}},
+			"weights":    {Type: Array, Items: &Property{Type: Number, Enum: []any{0.5, 1.5}}},
+			"corners":    {Type: Array, Items: &Property{Type: String, Enum: []any{"top", "bottom"}}, MinItems: &two, MaxItems: &two},
+		},
+		Required: []string{"tags"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+	if len(got.Properties["tags"].Enum) != 0 {
+		t.Errorf("enum of the array = %v, want it on the items only", got.Properties["tags"].Enum)
+	}
+
+	if err = got.Validate(map[string]any{"tags": []any{"red", "blue"}}); err != nil {
+		t.Errorf("Validate() of enum members error = %v", err)
+	}
+	if err = got.Validate(map[string]any{"tags": []any{"red", "purple"}}); err == nil {
+		t.Errorf("Validate() of a value outside the enum error = nil, wantErr")
+	}
+
+	type invalidSliceEnumReq struct {
+		Counts []int `json:"counts" enum:"1,two"`
+	}
+	if _, err = generateSchemaFromReqStruct(invalidSliceEnumReq{}); err == nil {
+		t.Errorf("generateSchemaFromReqStruct() with an enum value not matching the element type error = nil, wantErr")
+	}
+}
+
+type strictCompositionAudited struct {
+	Name string `json:"name"`
+}
+
+func TestGenerateSchemaWithStrictComposition(t *testing.T) {
+	auditedType := reflect.TypeOf(strictCompositionAudited{})
+	RegisterTypeSchema(auditedType, func() *Property {
+		return &Property{
+			Type: ObjectT,
+			AllOf: []*Property{
+				{Type: ObjectT, Properties: map[string]*Property{"created_by": {Type: String}}, Required: []string{"created_by"}},
+			},
+			Properties: map[string]*Property{"name": {Type: String}},
+			Required:   []string{"name"},
+		}
+	})
+	defer unregisterTypeSchema(auditedType)
+
+	type strictCompositionReq struct {
+		Record strictCompositionAudited `json:"record"`
+	}
+
+	got, err := GenerateSchema(strictCompositionReq{}, WithStrictComposition())
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	data, err := json.Marshal(got.Properties["record"])
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"type":"object","properties":{"name":{"type":"string"}},"required":["name"],` +
+		`"allOf":[{"type":"object","properties":{"created_by":{"type":"string"}},"required":["created_by"]}],"unevaluatedProperties":false}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	for record, valid := range map[string]bool{
+		`{"name":"report","created_by":"ada"}`:              true,
+		`{"name":"report"}`:                                 false,
+		`{"name":"report","created_by":"ada","extra":true}`: false,
+	} {
+		var v map[string]any
+		if err = json.Unmarshal([]byte(`{"record":`+record+`}`), &v); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if err = got.Validate(v); (err == nil) != valid {
+			t.Errorf("Validate(%s) error = %v, want valid %v", record, err, valid)
+		}
+	}
+
+	plain, err := GenerateSchema(strictCompositionReq{}, WithTitle("plain"))
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	if plain.Properties["record"].UnevaluatedProperties != nil {
+		t.Errorf("GenerateSchema() without WithStrictComposition emitted unevaluatedProperties")
+	}
+}
+
+type embedAsAllOfBase struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+func TestGenerateSchemaWithEmbedAsAllOf(t *testing.T) {
+	type embedAsAllOfReq struct {
+		embedAsAllOfBase
+		Name string `json:"name"`
+	}
+
+	got, err := GenerateSchema(embedAsAllOfReq{}, WithEmbedAsAllOf())
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	want := &InputSchema{
+		Type:       Object,
+		Properties: map[string]*Property{"name": {Type: String}},
+		Required:   []string{"name"},
+		AllOf:      []*Property{{Ref: "#/$defs/embedAsAllOfBase"}},
+		Defs: map[string]*Property{
+			"embedAsAllOfBase": {
+				Type: ObjectT,
+				Properties: map[string]*Property{
+					"id":         {Type: String},
+					"created_at": {Type: String},
+				},
+				Required: []string{"id"},
+			},
+		},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchema() got = %v, want %v", got, want)
+	}
+	if err = got.Validate(map[string]any{"name": "report"}); err == nil {
+		t.Errorf("Validate() without the required property of the embedded struct error = nil, wantErr")
+	}
+
+	closed, err := GenerateSchema(embedAsAllOfReq{}, WithEmbedAsAllOf(), WithAdditionalProperties(false))
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	if closed.AdditionalProperties != nil || closed.UnevaluatedProperties == nil || *closed.UnevaluatedProperties {
+		t.Errorf("GenerateSchema() closed composition got additionalProperties %v and unevaluatedProperties %v",
+			closed.AdditionalProperties, closed.UnevaluatedProperties)
+	}
+	if err = closed.Validate(map[string]any{"id": "1", "name": "report"}); err != nil {
+		t.Errorf("Validate() of the properties of all parts error = %v", err)
+	}
+	if err = closed.Validate(map[string]any{"id": "1", "name": "report", "extra": true}); err == nil {
+		t.Errorf("Validate() of an undeclared property error = nil, wantErr")
+	}
+
+	function, err := closed.ToOpenAIFunction("create", "")
+	if err != nil {
+		t.Fatalf("ToOpenAIFunction() error = %v", err)
+	}
+	assertJSONEqual(t, function["parameters"], `{
+		"type": "object",
+		"properties": {"name": {"type": "string"}, "id": {"type": "string"}, "created_at": {"type": "string"}},
+		"required": ["name", "id"],
+		"additionalProperties": false
+	}`)
+
+	flattened, err := GenerateSchema(embedAsAllOfReq{}, WithTitle("flattened"))
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	if len(flattened.AllOf) != 0 || len(flattened.Properties) != 3 {
+		t.Errorf("GenerateSchema() without WithEmbedAsAllOf got allOf %v and properties %v", flattened.AllOf, flattened.Properties)
+	}
+}
+
+type readOnlyBase struct {
+	ID        string    `json:"id" readOnly:"true" description:"set by the server"`
+	UpdatedAt time.Time `json:"updated_at" readOnly:"true"`
+}
+
+func TestGenerateSchemaWithStripReadOnlyFromInput(t *testing.T) {
+	type readOnlyReq struct {
+		readOnlyBase
+		Name  string `json:"name"`
+		Draft bool   `json:"draft,omitempty" readOnly:"false"`
+	}
+
+	annotated, err := GenerateSchema(readOnlyReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"id":         {Type: String, Description: "set by the server", ReadOnly: true},
+			"updated_at": {Type: String, Format: "date-time", ReadOnly: true},
+			"name":       {Type: String},
+			"draft":      {Type: Boolean},
+		},
+		Required: []string{"id", "updated_at", "name"},
+	}
+	if !compareInputSchema(annotated, want) {
+		t.Errorf("GenerateSchema() got = %v, want %v", annotated, want)
+	}
+
+	got, err := GenerateSchema(readOnlyReq{}, WithStripReadOnlyFromInput())
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	want = &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name":  {Type: String},
+			"draft": {Type: Boolean},
+		},
+		Required: []string{"name"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchema() with WithStripReadOnlyFromInput got = %v, want %v", got, want)
+	}
+
+	type invalidReadOnlyReq struct {
+		ID string `json:"id" readOnly:"yes"`
+	}
+	if _, err = GenerateSchema(invalidReadOnlyReq{}); err == nil {
+		t.Errorf("GenerateSchema() with an invalid readOnly tag error = nil, wantErr")
+	}
+}
+
+// readOnlyAccount describes itself with a readOnly required property
+type readOnlyAccount struct{}
+
+func (readOnlyAccount) JSONSchema() *Property {
+	return &Property{
+		Type: ObjectT,
+		Properties: map[string]*Property{
+			"id":    {Type: String, ReadOnly: true},
+			"email": {Type: String},
+		},
+		Required: []string{"id", "email"},
+	}
+}
+
+func TestGenerateSchemaWithStripReadOnlyPrunesRequired(t *testing.T) {
+	type readOnlyRequiredReq struct {
+		ID      string          `json:"id" readOnly:"true"`
+		Name    string          `json:"name"`
+		Account readOnlyAccount `json:"account"`
+	}
+
+	got, err := GenerateSchema(readOnlyRequiredReq{}, WithStripReadOnlyFromInput())
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name": {Type: String},
+			"account": {
+				Type:       ObjectT,
+				Properties: map[string]*Property{"email": {Type: String}},
+				Required:   []string{"email"},
+			},
+		},
+		Required: []string{"name", "account"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchema() with WithStripReadOnlyFromInput got = %v, want %v", got, want)
+	}
+	if err = got.Walk(func(path string, p *Property) error {
+		for _, name := range p.Required {
+			if _, ok := p.Properties[name]; !ok {
+				return fmt.Errorf("%s requires the removed property %s", path, name)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+
+	// the output schema of a tool keeps the readOnly properties
+	tool, err := NewToolWithOutputSchema("account", "", struct{}{}, readOnlyRequiredReq{})
+	if err != nil {
+		t.Fatalf("NewToolWithOutputSchema() error = %v", err)
+	}
+	if required := tool.OutputSchema.Properties["account"].Required; !reflect.DeepEqual(required, []string{"id", "email"}) {
+		t.Errorf("output schema account required = %v, want [id email]", required)
+	}
+}
+
+// precedenceProvided implements all the interfaces of the precedence chain, SchemaProvider wins
+type precedenceProvided int
+
+func (precedenceProvided) JSONSchema() *Property {
+	return &Property{Type: String, Format: "duration"}
+}
+func (precedenceProvided) MarshalText() ([]byte, error) { return nil, nil }
+func (precedenceProvided) String() string               { return "" }
+
+// precedenceText is marshaled as text by encoding/json
+type precedenceText int
+
+func (*precedenceText) MarshalText() ([]byte, error) { return nil, nil }
+func (precedenceText) String() string                { return "" }
+
+// precedenceJSON is marshaled by its MarshalJSON, which takes precedence over MarshalText in encoding/json
+type precedenceJSON int
+
+func (precedenceJSON) MarshalText() ([]byte, error) { return nil, nil }
+func (precedenceJSON) MarshalJSON() ([]byte, error) { return []byte("0"), nil }
+
+// precedenceLevel is an enum whose names are generated by stringer
+type precedenceLevel int
+
+const (
+	precedenceLevelDebug precedenceLevel = iota + 1
+	precedenceLevelInfo
+	precedenceLevelError
+)
+
+func (l precedenceLevel) String() string {
+	switch l {
+	case precedenceLevelDebug:
+		return "Debug"
+	case precedenceLevelInfo:
+		return "Info"
+	case precedenceLevelError:
+		return "Error"
+	default:
+		return "precedenceLevel(" + strconv.Itoa(int(l)) + ")"
+	}
+}
+
+// precedenceRegistered implements SchemaProvider, but is registered
+type precedenceRegistered string
+
+func (precedenceRegistered) JSONSchema() *Property {
+	return &Property{Type: String}
+}
+
+func TestGenerateSchemaTypePrecedence(t *testing.T) {
+	registeredType := reflect.TypeOf(precedenceRegistered(""))
+	RegisterTypeSchema(registeredType, func() *Property {
+		return &Property{Type: Integer, Description: "registered"}
+	})
+	defer unregisterTypeSchema(registeredType)
+
+	type precedenceReq struct {
+		Provided   precedenceProvided   `json:"provided"`
+		Text       precedenceText       `json:"text"`
+		JSON       precedenceJSON       `json:"json"`
+		Level      precedenceLevel      `json:"level"`
+		Levels     []precedenceLevel    `json:"levels"`
+		Registered precedenceRegistered `json:"registered"`
+	}
+
+	got, err := GenerateSchema(precedenceReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"provided":   {Type: String, Format: "duration"},
+			"text":       {Type: String},
+			"json":       {Type: Integer},
+			"level":      {Type: Integer},
+			"levels":     {Type: Array, Items: &Property{Type: Integer}},
+			"registered": {Type: Integer, Description: "registered"},
+		},
+		Required: []string{"provided", "text", "json", "level", "levels", "registered"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchema() got = %v, want %v", got, want)
+	}
+
+	got, err = GenerateSchema(precedenceReq{}, WithEnumFromStringer())
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	level := &Property{
+		Type:       Integer,
+		Enum:       []any{1, 2, 3},
+		Extensions: map[string]any{"x-enumLabels": []string{"Debug", "Info", "Error"}},
+	}
+	want.Properties["level"] = level
+	want.Properties["levels"] = &Property{Type: Array, Items: level}
+	if !compareInputSchema(got, want) {
+		t.Errorf("GenerateSchema() with WithEnumFromStringer got = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateSchemaCache(t *testing.T) {
+	type cacheCode string
+	type cacheReq struct {
+		Name string    `json:"name" description:"name"`
+		Code cacheCode `json:"code"`
+	}
+
+	schema, err := GenerateSchema(cacheReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	schema.Properties["name"].Description = "changed"
+	delete(schema.Properties, "code")
+
+	tool, err := NewTool("cache", "cache", cacheReq{})
+	if err != nil {
+		t.Fatalf("NewTool() error = %v", err)
+	}
+	if p := tool.InputSchema.Properties["name"]; p == nil || p.Description != "name" || tool.InputSchema.Properties["code"] == nil {
+		t.Errorf("NewTool() schema = %+v, want the schema unaffected by the changes of a previous GenerateSchema", tool.InputSchema)
+	}
+
+	defer unregisterTypeSchema(reflect.TypeOf(cacheCode("")))
+	RegisterTypeFormat(reflect.TypeOf(cacheCode("")), "uuid")
+	if schema, err = GenerateSchema(cacheReq{}); err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	if p := schema.Properties["code"]; p == nil || p.Format != "uuid" {
+		t.Errorf("GenerateSchema() code = %+v, want the format registered after the schema was cached", p)
+	}
+}
+
+func TestGenerateSchemaForIP(t *testing.T) {
+	type ipReq struct {
+		Addr net.IP `json:"addr"`
+	}
+
+	schema, err := GenerateSchema(ipReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	if p := schema.Properties["addr"]; p == nil || p.Type != String || p.Format != "" {
+		t.Errorf("GenerateSchema() addr = %+v, want a string without the non-standard ip format", p)
+	}
+
+	for _, addr := range []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1"), net.ParseIP("2001:db8::8a2e:370:7334"), net.ParseIP("fe80::1")} {
+		data, err := json.Marshal(ipReq{Addr: addr})
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		var args map[string]any
+		if err = json.Unmarshal(data, &args); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if err = schema.Validate(args); err != nil {
+			t.Errorf("Validate(%s) error = %v", data, err)
+		}
+	}
+	for _, addr := range []string{"localhost", "1.2.3", "not an ip"} {
+		if err = schema.Validate(map[string]any{"addr": addr}); err == nil {
+			t.Errorf("Validate(%q) error = nil, wantErr", addr)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+var openAIFunctionName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// openAIKeywords are the keywords of the subset of JSON Schema accepted for the parameters of OpenAI functions
+var openAIKeywords = map[string]bool{
+	"type":                 true,
+	"description":          true,
+	"enum":                 true,
+	"properties":           true,
+	"required":             true,
+	"items":                true,
+	"additionalProperties": true,
+	"anyOf":                true,
+}
+
+// ToOpenAIFunction returns the definition of an OpenAI function taking the arguments described by the schema,
+// i.e. its name, description and parameters, to be used as the function of a tool of type "function".
+// References are inlined, the properties of allOf schemas are merged into their object,
+// oneOf becomes anyOf, const becomes a single valued enum
+// and the keywords outside the supported subset, e.g. pattern or minimum, are dropped.
+// Recursive definitions cannot be inlined and fail the conversion.
+func (s *InputSchema) ToOpenAIFunction(name, description string) (map[string]any, error) {
+	if !openAIFunctionName.MatchString(name) {
+		return nil, fmt.Errorf("invalid OpenAI function name %q: expected 1 to 64 letters, digits, underscores or dashes", name)
+	}
+
+	inlined, err := (&refInliner{defs: s.Defs, visiting: make(map[string]bool)}).inline(s.object())
+	if err != nil {
+		return nil, err
+	}
+	parameters, err := schemaToMap(inlined)
+	if err != nil {
+		return nil, err
+	}
+	rewriteSchemaMap(parameters, func(schema map[string]any) {
+		mergeAllOf(schema)
+		if oneOf, ok := schema["oneOf"]; ok {
+			schema["anyOf"] = oneOf
+		}
+		if value, ok := schema["const"]; ok {
+			if _, hasEnum := schema["enum"]; !hasEnum {
+				schema["enum"] = []any{value}
+			}
+		}
+		for keyword := range schema {
+			if !openAIKeywords[keyword] {
+				delete(schema, keyword)
+			}
+		}
+	})
+
+	function := map[string]any{"name": name, "parameters": parameters}
+	if description != "" {
+		function["description"] = description
+	}
+	return function, nil
+}
+
+// ToAnthropicToolSchema returns the input_schema of an Anthropic tool taking the arguments described by the schema.
+// The API accepts JSON Schema including $ref and $defs, so the schema is kept as is except for
+// the $id and the vendor "x-" keywords, which are dropped, and the properties, which are always present.
+// References to missing definitions fail the conversion.
+func (s *InputSchema) ToAnthropicToolSchema() (map[string]any, error) {
+	if err := s.Walk(func(path string, p *Property) error {
+		if p.Ref != "" && resolveDef(s.Defs, p) == nil {
+			return fmt.Errorf("unresolved reference %s at %s", p.Ref, path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	schema, err := schemaToMap(s)
+	if err != nil {
+		return nil, err
+	}
+	delete(schema, "$id")
+	if _, ok := schema["properties"]; !ok {
+		schema["properties"] = map[string]any{}
+	}
+	rewriteSchemaMap(schema, func(schema map[string]any) {
+		for keyword := range schema {
+			if strings.HasPrefix(keyword, "x-") {
+				delete(schema, keyword)
+			}
+		}
+	})
+	return schema, nil
+}
+
+// mergeAllOf merges the properties and required properties of the allOf schemas of the generic JSON representation
+// of an object schema into the object, closed with additionalProperties if unevaluatedProperties was false.
+func mergeAllOf(schema map[string]any) {
+	subs, ok := schema["allOf"].([]any)
+	if !ok {
+		return
+	}
+	delete(schema, "allOf")
+
+	properties, _ := schema["properties"].(map[string]any)
+	if properties == nil {
+		properties = make(map[string]any)
+	}
+	required, _ := schema["required"].([]any)
+	for _, sub := range subs {
+		sub, ok := sub.(map[string]any)
+		if !ok {
+			continue
+		}
+		mergeAllOf(sub)
+		subProperties, _ := sub["properties"].(map[string]any)
+		for name, p := range subProperties {
+			properties[name] = p
+		}
+		subRequired, _ := sub["required"].([]any)
+		required = append(required, subRequired...)
+	}
+	schema["properties"] = properties
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if schema["unevaluatedProperties"] == false {
+		schema["additionalProperties"] = false
+	}
+}
+
+// refInliner replaces the references to definitions by copies of the definitions
+type refInliner struct {
+	defs map[string]*Property
+	// visiting holds the definitions being inlined, detecting recursive definitions
+	visiting map[string]bool
+}
+
+// inline returns a copy of p without references, sharing the values of p other than its subschemas
+func (r *refInliner) inline(p *Property) (*Property, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	if p.Ref != "" {
+		name := p.Ref[strings.LastIndex(p.Ref, "/")+1:]
+		def, ok := r.defs[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", p.Ref)
+		}
+		if r.visiting[name] {
+			return nil, fmt.Errorf("recursive reference %s cannot be inlined", p.Ref)
+		}
+		r.visiting[name] = true
+		resolved, err := r.inline(def)
+		delete(r.visiting, name)
+		if err != nil {
+			return nil, err
+		}
+		// annotations of the reference, e.g. the description of the field, take precedence over the definition
+		if p.Title != "" {
+			resolved.Title = p.Title
+		}
+		if p.Description != "" {
+			resolved.Description = p.Description
+		}
+		if p.Default != nil {
+			resolved.Default = p.Default
+		}
+		if p.Const != nil {
+			resolved.Const = p.Const
+		}
+		return resolved, nil
+	}
+
+	c := *p
+	var err error
+	if c.Items, err = r.inline(p.Items); err != nil {
+		return nil, err
+	}
+	if c.ContentSchema, err = r.inline(p.ContentSchema); err != nil {
+		return nil, err
+	}
+	if c.PropertyNames, err = r.inline(p.PropertyNames); err != nil {
+		return nil, err
+	}
+	if p.Properties != nil {
+		c.Properties = make(map[string]*Property, len(p.Properties))
+		for name, prop := range p.Properties {
+			if c.Properties[name], err = r.inline(prop); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if c.OneOf, err = r.inlineAll(p.OneOf); err != nil {
+		return nil, err
+	}
+	if c.AllOf, err = r.inlineAll(p.AllOf); err != nil {
+		return nil, err
+	}
+	if additional, ok := p.AdditionalProperties.(*Property); ok {
+		if c.AdditionalProperties, err = r.inline(additional); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+func (r *refInliner) inlineAll(ps []*Property) ([]*Property, error) {
+	if ps == nil {
+		return nil, nil
+	}
+	inlined := make([]*Property, len(ps))
+	for i, p := range ps {
+		var err error
+		if inlined[i], err = r.inline(p); err != nil {
+			return nil, err
+		}
+	}
+	return inlined, nil
+}
+
+// schemaToMap converts v to its generic JSON representation
+func schemaToMap(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err = pkg.JSONUnmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// rewriteSchemaMap calls fn for the generic JSON representation of a schema and then for each of its subschemas,
+// which are looked up after fn returned.
+func rewriteSchemaMap(schema map[string]any, fn func(map[string]any)) {
+	fn(schema)
+	for _, keyword := range []string{"items", "additionalProperties", "contentSchema", "propertyNames"} {
+		if sub, ok := schema[keyword].(map[string]any); ok {
+			rewriteSchemaMap(sub, fn)
+		}
+	}
+	for _, keyword := range []string{"properties", "$defs", "dependentSchemas"} {
+		if subs, ok := schema[keyword].(map[string]any); ok {
+			for _, sub := range subs {
+				if sub, ok := sub.(map[string]any); ok {
+					rewriteSchemaMap(sub, fn)
+				}
+			}
+		}
+	}
+	for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+		if subs, ok := schema[keyword].([]any); ok {
+			for _, sub := range subs {
+				if sub, ok := sub.(map[string]any); ok {
+					rewriteSchemaMap(sub, fn)
+				}
+			}
+		}
+	}
+}
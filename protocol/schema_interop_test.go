@@ -0,0 +1,155 @@
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// assertJSONEqual compares the JSON representations of got and want, ignoring the order of object keys
+func assertJSONEqual(t *testing.T, got any, want string) {
+	t.Helper()
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var gotValue, wantValue any
+	if err = json.Unmarshal(data, &gotValue); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if err = json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("json.Unmarshal() of want error = %v", err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestInputSchema_ToOpenAIFunction(t *testing.T) {
+	minimum := 1.0
+	schema := &InputSchema{
+		ID:   "https://example.com/search",
+		Type: Object,
+		Properties: map[string]*Property{
+			"query": {Type: String, Description: "search terms", Pattern: "^\\S+", Examples: []any{"go"}},
+			"limit": {Type: Integer, Minimum: &minimum, Default: 10},
+			"kind":  {Type: String, Const: "web"},
+			"status": {
+				Ref:         "#/$defs/Status",
+				Description: "status filter",
+			},
+			"owner": {OneOf: []*Property{{Ref: "#/$defs/User"}, {Type: Null}}},
+			"filters": {
+				Type: Array,
+				Items: &Property{
+					Type: ObjectT,
+					Properties: map[string]*Property{
+						"field": {Type: String, Format: "email"},
+						"user":  {Ref: "#/$defs/User"},
+					},
+					Required: []string{"field"},
+				},
+			},
+		},
+		Required:             []string{"query"},
+		AdditionalProperties: false,
+		Defs: map[string]*Property{
+			"Status": {Type: String, Enum: []any{"open", "closed"}},
+			"User": {
+				Type:       ObjectT,
+				Properties: map[string]*Property{"name": {Type: String, Title: "Name"}},
+				Required:   []string{"name"},
+			},
+		},
+	}
+
+	got, err := schema.ToOpenAIFunction("search", "Searches the index")
+	if err != nil {
+		t.Fatalf("ToOpenAIFunction() error = %v", err)
+	}
+	assertJSONEqual(t, got, `{
+		"name": "search",
+		"description": "Searches the index",
+		"parameters": {
+			"type": "object",
+			"properties": {
+				"query": {"type": "string", "description": "search terms"},
+				"limit": {"type": "integer"},
+				"kind": {"type": "string", "enum": ["web"]},
+				"status": {"type": "string", "enum": ["open", "closed"], "description": "status filter"},
+				"owner": {"anyOf": [
+					{"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]},
+					{"type": "null"}
+				]},
+				"filters": {"type": "array", "items": {
+					"type": "object",
+					"properties": {
+						"field": {"type": "string"},
+						"user": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+					},
+					"required": ["field"]
+				}}
+			},
+			"required": ["query"],
+			"additionalProperties": false
+		}
+	}`)
+
+	if _, err = schema.ToOpenAIFunction("search tools", ""); err == nil {
+		t.Errorf("ToOpenAIFunction() with an invalid name error = nil, wantErr")
+	}
+
+	schema.Defs["User"].Properties["manager"] = &Property{Ref: "#/$defs/User"}
+	if _, err = schema.ToOpenAIFunction("search", ""); err == nil {
+		t.Errorf("ToOpenAIFunction() with a recursive definition error = nil, wantErr")
+	}
+}
+
+func TestInputSchema_ToAnthropicToolSchema(t *testing.T) {
+	type anthropicAddress struct {
+		City    string `json:"city"`
+		Country string `json:"country" enum:"FR,DE,US"`
+	}
+	type anthropicToolReq struct {
+		Query   string           `json:"query" description:"search terms"`
+		Sort    string           `json:"sort,omitempty" enum:"relevance,date" default:"relevance"`
+		Address anthropicAddress `json:"address"`
+	}
+
+	schema, err := GenerateSchemaContext(context.Background(), anthropicToolReq{}, WithSchemaID("https://example.com/search"), WithEmitGoType("x-go-type"))
+	if err != nil {
+		t.Fatalf("GenerateSchemaContext() error = %v", err)
+	}
+	got, err := schema.ToAnthropicToolSchema()
+	if err != nil {
+		t.Fatalf("ToAnthropicToolSchema() error = %v", err)
+	}
+	assertJSONEqual(t, got, `{
+		"type": "object",
+		"properties": {
+			"query": {"type": "string", "description": "search terms"},
+			"sort": {"type": "string", "enum": ["relevance", "date"], "default": "relevance"},
+			"address": {
+				"type": "object",
+				"properties": {
+					"city": {"type": "string"},
+					"country": {"type": "string", "enum": ["FR", "DE", "US"]}
+				},
+				"required": ["city", "country"]
+			}
+		},
+		"required": ["query", "address"]
+	}`)
+
+	empty, err := (&InputSchema{Type: Object}).ToAnthropicToolSchema()
+	if err != nil {
+		t.Fatalf("ToAnthropicToolSchema() error = %v", err)
+	}
+	assertJSONEqual(t, empty, `{"type": "object", "properties": {}}`)
+
+	broken := &InputSchema{Type: Object, Properties: map[string]*Property{"status": {Ref: "#/$defs/Status"}}}
+	if _, err = broken.ToAnthropicToolSchema(); err == nil {
+		t.Errorf("ToAnthropicToolSchema() with an unresolved reference error = nil, wantErr")
+	}
+}
@@ -0,0 +1,327 @@
+package protocol
+
+// SchemaOption configures how a schema is generated from a request struct
+type SchemaOption func(*schemaOptions)
+
+type schemaOptions struct {
+	emptySliceAsEmptyArrayDefault bool
+	objectTypeName                string
+	inlineEnums                   bool
+	maxEnumSize                   int
+	numberPrecision               *int
+	goTypeKeyword                 string
+	lowercaseEnums                bool
+	mirrorRequired                bool
+	schemaID                      string
+	requireDescriptions           bool
+	structuralDedup               bool
+	examplesLimit                 *int
+	normalizeNumbers              bool
+	itemEnumFromMethod            bool
+	minimal                       bool
+	requiredOrder                 RequiredOrder
+	stringifiedIntKeys            bool
+	titleFromFieldName            bool
+	numberParser                  func(string) (float64, error)
+	strictRequired                bool
+	title                         string
+	additionalProperties          *bool
+	enumDescriptionsFromMethod    bool
+	refPrefix                     string
+	strictComposition             bool
+	embedAsAllOf                  bool
+	stripReadOnly                 bool
+	enumFromStringer              bool
+	contextDescriptions           func(path string) (string, bool)
+	sortEnums                     bool
+	maxTotalProperties            int
+}
+
+func newSchemaOptions(opts ...SchemaOption) schemaOptions {
+	var options schemaOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithEmptySliceAsEmptyArrayDefault sets `default: []` on optional slice fields without a default,
+// so that clients know the absence of the field means an empty list.
+func WithEmptySliceAsEmptyArrayDefault() SchemaOption {
+	return func(o *schemaOptions) {
+		o.emptySliceAsEmptyArrayDefault = true
+	}
+}
+
+// WithObjectTypeName confirms the JSON type name emitted for objects.
+// Generation fails unless name is the spec compliant "object" (case insensitive),
+// guarding that the wire format never depends on which internal constant was used.
+func WithObjectTypeName(name string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.objectTypeName = name
+	}
+}
+
+// WithInlineEnums inlines the schemas of enums registered with RegisterEnum into each property
+// instead of referencing a shared definition, for clients that don't follow $ref.
+func WithInlineEnums() SchemaOption {
+	return func(o *schemaOptions) {
+		o.inlineEnums = true
+	}
+}
+
+// WithMaxEnumSize fails the generation when an enum has more than n values,
+// as very large enums bloat schemas and confuse models.
+func WithMaxEnumSize(n int) SchemaOption {
+	return func(o *schemaOptions) {
+		o.maxEnumSize = n
+	}
+}
+
+// WithMaxTotalProperties fails the generation when the schema has more than n properties in total,
+// counting the properties of all its objects and definitions, to bound the size of pathological schemas.
+func WithMaxTotalProperties(n int) SchemaOption {
+	return func(o *schemaOptions) {
+		o.maxTotalProperties = n
+	}
+}
+
+// WithNumberPrecision emits the multipleOf matching the number of decimal places for all float fields
+// without a `precision` tag, e.g. 2 emits multipleOf 0.01 for fixed-decimal values.
+// A negative precision is ignored.
+func WithNumberPrecision(decimals int) SchemaOption {
+	return func(o *schemaOptions) {
+		if decimals >= 0 {
+			o.numberPrecision = &decimals
+		}
+	}
+}
+
+// WithEmitGoType annotates each object schema generated from a named struct with the name of the Go type
+// under the vendor keyword, e.g. WithEmitGoType("x-go-type") emits "x-go-type": "protocol.Address",
+// tracing schemas back to their source types for debugging and tooling.
+func WithEmitGoType(keyword string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.goTypeKeyword = keyword
+	}
+}
+
+// WithLowercaseEnumNormalization lowercases the members of string enums, and the defaults of their properties,
+// for case insensitive APIs. Generation fails if members differ only in case.
+// Inputs are still matched exactly by Validate, so they should be lowercased before,
+// or validated with WithEnumCaseInsensitiveValidation.
+func WithLowercaseEnumNormalization() SchemaOption {
+	return func(o *schemaOptions) {
+		o.lowercaseEnums = true
+	}
+}
+
+// WithMirrorRequiredOnProperty additionally marks each required property with the vendor keyword "x-required": true,
+// for tooling reading requiredness from the property instead of the required array of its object.
+func WithMirrorRequiredOnProperty() SchemaOption {
+	return func(o *schemaOptions) {
+		o.mirrorRequired = true
+	}
+}
+
+// WithSchemaID sets the $id of the generated schema, e.g. "https://example.com/schemas/search.json",
+// taking precedence over the SchemaID method of the request struct.
+func WithSchemaID(id string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.schemaID = id
+	}
+}
+
+// WithRequireDescriptions fails the generation when a property of any object, nested ones included, has no description,
+// for teams enforcing documented tool arguments.
+func WithRequireDescriptions() SchemaOption {
+	return func(o *schemaOptions) {
+		o.requireDescriptions = true
+	}
+}
+
+// WithStructuralDedup emits anonymous struct types occurring several times once in the $defs of the schema,
+// under a name derived from a hash of their structure, and references them from each occurrence.
+// Go identifies anonymous struct types by their fields, tags included, so only identical shapes are merged.
+func WithStructuralDedup() SchemaOption {
+	return func(o *schemaOptions) {
+		o.structuralDedup = true
+	}
+}
+
+// WithExamplesLimit keeps at most n examples per property, from the `examples` tags and SchemaExamples alike,
+// dropping the extra ones to keep schemas small. A negative limit is ignored.
+func WithExamplesLimit(n int) SchemaOption {
+	return func(o *schemaOptions) {
+		if n >= 0 {
+			o.examplesLimit = &n
+		}
+	}
+}
+
+// WithNormalizeNumbers converts numeric defaults to the Go type matching the type of their property,
+// e.g. the int 3 returned by SchemaDefaults for a float field becomes the float64 3.0,
+// and integral float64 defaults of integer fields become ints.
+func WithNormalizeNumbers() SchemaOption {
+	return func(o *schemaOptions) {
+		o.normalizeNumbers = true
+	}
+}
+
+// WithArrayItemEnumFromElementMethod attaches the values listed by the EnumValues method of slice and array element types
+// implementing EnumProvider as the enum of the items, e.g. for a []Status field without an `enum` tag.
+func WithArrayItemEnumFromElementMethod() SchemaOption {
+	return func(o *schemaOptions) {
+		o.itemEnumFromMethod = true
+	}
+}
+
+// WithMinimal reduces the size of the schema without changing the values it accepts:
+// keywords implied by others, e.g. additionalProperties true, minItems 0 or the enum of a const, are omitted
+// and a oneOf with a single alternative is replaced by the alternative.
+func WithMinimal() SchemaOption {
+	return func(o *schemaOptions) {
+		o.minimal = true
+	}
+}
+
+// RequiredOrder is the order of the property names in the required arrays of the generated schemas.
+type RequiredOrder int
+
+const (
+	// RequiredOrderDeclaration lists required properties in the order of the struct fields, the default.
+	RequiredOrderDeclaration RequiredOrder = iota
+	// RequiredOrderSorted lists required properties alphabetically.
+	RequiredOrderSorted
+)
+
+// WithForceRequiredOrder sets the order of the required arrays, e.g. RequiredOrderSorted for stable diffs
+// of schemas whose fields get reordered.
+func WithForceRequiredOrder(order RequiredOrder) SchemaOption {
+	return func(o *schemaOptions) {
+		o.requiredOrder = order
+	}
+}
+
+// WithStringifiedIntKeys accepts maps with integer keys, which encoding/json marshals as objects with decimal string keys,
+// describing the keys with a propertyNames pattern, e.g. ^-?\d+$ for a map[int]string.
+func WithStringifiedIntKeys() SchemaOption {
+	return func(o *schemaOptions) {
+		o.stringifiedIntKeys = true
+	}
+}
+
+// WithTitleFromFieldName sets the title of the properties without a `title` tag to their humanized Go field name,
+// e.g. "Max Retries" for the field MaxRetries.
+func WithTitleFromFieldName() SchemaOption {
+	return func(o *schemaOptions) {
+		o.titleFromFieldName = true
+	}
+}
+
+// WithNumberParser parses the float values of the `enum`, `examples`, `const` and `default` tags
+// and the `minimum` and `maximum` tags with parse instead of strconv.ParseFloat,
+// e.g. to accept defaults written with a decimal comma such as "2,5". Integer values are still parsed as decimal integers.
+func WithNumberParser(parse func(string) (float64, error)) SchemaOption {
+	return func(o *schemaOptions) {
+		o.numberParser = parse
+	}
+}
+
+// WithStrictRequired marks all properties required, regardless of omitempty and pointer types,
+// as expected by strict function calling modes. Properties tagged `required:"false"` stay optional.
+func WithStrictRequired() SchemaOption {
+	return func(o *schemaOptions) {
+		o.strictRequired = true
+	}
+}
+
+// WithTitle sets the title of the generated schema.
+func WithTitle(title string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.title = title
+	}
+}
+
+// WithAdditionalProperties sets additionalProperties on the objects generated from structs,
+// e.g. false to close the objects, rejecting the properties not declared by their fields on validation
+// instead of silently dropping the fields hallucinated by models. By default additionalProperties is omitted.
+// Structs embedding a map keep describing the entries of the map as their additional properties.
+func WithAdditionalProperties(allowed bool) SchemaOption {
+	return func(o *schemaOptions) {
+		o.additionalProperties = &allowed
+	}
+}
+
+// WithEnumDescriptionsFromMethod emits the descriptions of the enum values of field types implementing
+// EnumDescriptionsProvider under the x-enumDescriptions vendor keyword, e.g. {"open": "Awaiting triage"}.
+// Generation fails when a described value is not a member of the enum of the field.
+func WithEnumDescriptionsFromMethod() SchemaOption {
+	return func(o *schemaOptions) {
+		o.enumDescriptionsFromMethod = true
+	}
+}
+
+// WithRefPrefix sets the prefix of the references to definitions, "#/$defs/" by default,
+// e.g. "#/definitions/" for draft-07 consumers or the base URI of a bundle such as "https://example.com/bundle.json#/$defs/".
+// The definitions themselves are still emitted under $defs, the prefix must end with a slash.
+func WithRefPrefix(prefix string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.refPrefix = prefix
+	}
+}
+
+// WithStrictComposition sets unevaluatedProperties to false on the objects composed with allOf,
+// rejecting the properties declared by none of their schemas. additionalProperties false can't close such objects,
+// as it only considers the properties declared next to it.
+func WithStrictComposition() SchemaOption {
+	return func(o *schemaOptions) {
+		o.strictComposition = true
+	}
+}
+
+// WithEmbedAsAllOf describes the objects embedding structs as the allOf of the schemas of the embedded types,
+// emitted once in the $defs of the schema, instead of flattening their properties into the object.
+// This preserves the composition for validators and documentation, e.g. a Base struct shared by several requests.
+// Fields with the `json:",inline"` option are still flattened.
+func WithEmbedAsAllOf() SchemaOption {
+	return func(o *schemaOptions) {
+		o.embedAsAllOf = true
+	}
+}
+
+// WithStripReadOnlyFromInput drops the fields tagged `readOnly:"true"` from the schema,
+// as such fields are set by the server and clients are not expected to send them,
+// e.g. the ID or timestamps of a type shared between the arguments and the result of a tool.
+func WithStripReadOnlyFromInput() SchemaOption {
+	return func(o *schemaOptions) {
+		o.stripReadOnly = true
+	}
+}
+
+// WithEnumFromStringer describes the integer types implementing fmt.Stringer, e.g. the iota constants of a Level type,
+// with the enum of their named values and the names under the x-enumLabels vendor keyword.
+// The values are enumerated from 0 until String returns the "Level(n)" fallback of the stringer tool.
+// Without the option such types are plain integers, see SchemaProvider for the precedence of the rules.
+func WithEnumFromStringer() SchemaOption {
+	return func(o *schemaOptions) {
+		o.enumFromStringer = true
+	}
+}
+
+// WithContextDescriptions calls describe with the JSON pointer path of each property, as passed by InputSchema.Walk,
+// to supply the descriptions known at generation time only, e.g. pulled from a CMS.
+// The descriptions of the tags take precedence, describe only fills the properties without one.
+func WithContextDescriptions(describe func(path string) (string, bool)) SchemaOption {
+	return func(o *schemaOptions) {
+		o.contextDescriptions = describe
+	}
+}
+
+// WithSortEnums sorts the enum values for presentation, numerically for numbers and lexically for strings,
+// the numbers first. Without the option the enums keep the order of their declaration.
+func WithSortEnums() SchemaOption {
+	return func(o *schemaOptions) {
+		o.sortEnums = true
+	}
+}
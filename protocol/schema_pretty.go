@@ -0,0 +1,34 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// PrettyJSON returns the schema as JSON indented with 2 spaces, with the keys of every object sorted,
+// including the names of the properties and the $defs, so that the output is stable for reviews and golden tests.
+// Arrays such as enum and required keep the order of the schema, which generation makes deterministic.
+// HTML characters are not escaped, e.g. the < of a pattern is kept as is.
+func (s *InputSchema) PrettyJSON() ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	// encoding/json sorts the keys of maps but not the fields of structs, hence the generic representation,
+	// whose numbers are kept as written
+	var schema map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&schema); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(schema); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,99 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestInputSchema_PrettyJSON(t *testing.T) {
+	maxItems := 3
+	schema := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"status": {Ref: "#/$defs/Status"},
+			"query":  {Type: String, Pattern: "^[^<>]+$", Description: "search terms"},
+			"filter": {
+				Type: ObjectT,
+				Properties: map[string]*Property{
+					"tags": {Type: Array, Items: &Property{Type: String}, MaxItems: &maxItems},
+					"id":   {Type: Integer, Const: int64(9007199254740993)},
+				},
+				Required: []string{"tags"},
+			},
+		},
+		Required: []string{"query", "status"},
+		Defs: map[string]*Property{
+			"Status":   {Type: String, Enum: []any{"open", "closed", "archived"}},
+			"Priority": {Type: Integer, Enum: []any{3, 1, 2}},
+		},
+	}
+
+	got, err := schema.PrettyJSON()
+	if err != nil {
+		t.Fatalf("PrettyJSON() error = %v", err)
+	}
+	want := `{
+  "$defs": {
+    "Priority": {
+      "enum": [
+        3,
+        1,
+        2
+      ],
+      "type": "integer"
+    },
+    "Status": {
+      "enum": [
+        "open",
+        "closed",
+        "archived"
+      ],
+      "type": "string"
+    }
+  },
+  "properties": {
+    "filter": {
+      "properties": {
+        "id": {
+          "const": 9007199254740993,
+          "type": "integer"
+        },
+        "tags": {
+          "items": {
+            "type": "string"
+          },
+          "maxItems": 3,
+          "type": "array"
+        }
+      },
+      "required": [
+        "tags"
+      ],
+      "type": "object"
+    },
+    "query": {
+      "description": "search terms",
+      "pattern": "^[^<>]+$",
+      "type": "string"
+    },
+    "status": {
+      "$ref": "#/$defs/Status"
+    }
+  },
+  "required": [
+    "query",
+    "status"
+  ],
+  "type": "object"
+}`
+	if string(got) != want {
+		t.Errorf("PrettyJSON() got =\n%s\nwant =\n%s", got, want)
+	}
+
+	again, err := schema.Clone().PrettyJSON()
+	if err != nil {
+		t.Fatalf("PrettyJSON() error = %v", err)
+	}
+	if string(again) != string(got) {
+		t.Errorf("PrettyJSON() of a clone got =\n%s\nwant =\n%s", again, got)
+	}
+}
@@ -0,0 +1,305 @@
+package protocol
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// typeSchemas holds the typeSchema registered for types, keyed by reflect.Type
+var typeSchemas sync.Map
+
+type typeSchema struct {
+	schema func() *Property
+	// definition schemas are shared through $defs instead of being inlined into each property
+	definition bool
+	// variants are the types implementing a union interface
+	variants []reflect.Type
+}
+
+func init() {
+	// Types marshaling themselves to JSON differently from their Go structure.
+	// url.URL is deliberately absent, as encoding/json marshals it as an object.
+	RegisterTypeFormat(timeType, "date-time")
+	// net.IP is either an IPv4 or an IPv6 address, which no single JSON Schema format covers
+	RegisterTypeSchema(reflect.TypeOf(net.IP{}), func() *Property {
+		return &Property{Type: String, Pattern: ipPattern}
+	})
+	RegisterTypeFormat(reflect.TypeOf(uuid.UUID{}), "uuid")
+	RegisterTypeSchema(reflect.TypeOf(big.Int{}), func() *Property {
+		return &Property{Type: Integer}
+	})
+	// big.Rat is marshaled as text, "3/2", or "3" for integers
+	RegisterTypeSchema(reflect.TypeOf(big.Rat{}), func() *Property {
+		return &Property{Type: String, Pattern: `^-?\d+(/\d+)?$`}
+	})
+	// json.RawMessage holds any JSON value, its shape can be declared with the `schemaType` tag
+	RegisterTypeSchema(reflect.TypeOf(json.RawMessage{}), func() *Property {
+		return &Property{}
+	})
+}
+
+// ipPattern matches the dotted IPv4 addresses and the IPv6 addresses, including those embedding an IPv4 address
+const ipPattern = `^(\d{1,3}(\.\d{1,3}){3}|[0-9A-Fa-f.]*:[0-9A-Fa-f:.]*)$`
+
+// namedTypes holds the types registered with RegisterNamedType, keyed by name
+var namedTypes sync.Map
+
+// RegisterNamedType registers t under name for the `schemaType` tag, which replaces the schema of a field
+// with the schema of the named type, e.g. to describe the expected shape of a json.RawMessage field
+// decoded later: `json:"filter" schemaType:"Filter"`. The schema of t is emitted once in the $defs of the schema.
+func RegisterNamedType(name string, t reflect.Type) {
+	namedTypes.Store(name, t)
+	invalidateSchemaCache()
+}
+
+func lookupNamedType(name string) (reflect.Type, bool) {
+	v, ok := namedTypes.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return v.(reflect.Type), true
+}
+
+// RegisterTypeSchema makes the generator describe values of type t with the property returned by schema
+// instead of reflecting on t. Registrations are consulted first and replace any previous one for t,
+// including the defaults registered for standard library types.
+// schema must return a new Property on each call, as the generator mutates it.
+func RegisterTypeSchema(t reflect.Type, schema func() *Property) {
+	storeTypeSchema(t, typeSchema{schema: schema})
+}
+
+// RegisterEnum centralizes the allowed values of the named type t, which must have a string,
+// numeric or boolean underlying type. The generator emits the enum once in the $defs of the schema
+// and references it from each property of type t, unless WithInlineEnums is used.
+func RegisterEnum(t reflect.Type, values ...any) error {
+	dataType, enum, err := enumValuesOf(t, values)
+	if err != nil {
+		return err
+	}
+
+	storeTypeSchema(t, typeSchema{
+		schema: func() *Property {
+			return &Property{Type: dataType, Enum: append([]any(nil), enum...)}
+		},
+		definition: true,
+	})
+	return nil
+}
+
+// enumValuesOf converts values to the plain Go types of the enum values of type t, returning the JSON type of t
+func enumValuesOf(t reflect.Type, values []any) (DataType, []any, error) {
+	dataType, ok := dataTypeOfKind(t.Kind())
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported type %v for enum", t)
+	}
+
+	enum := make([]any, len(values))
+	for i, value := range values {
+		v := reflect.ValueOf(value)
+		if !v.IsValid() {
+			return "", nil, fmt.Errorf("enum value %v is not compatible with type %v", value, t)
+		}
+		// integers are accepted as members of numbers, otherwise the JSON types must match
+		valueType, _ := dataTypeOfKind(v.Kind())
+		if valueType != dataType && (valueType != Integer || dataType != Number) {
+			return "", nil, fmt.Errorf("enum value %v is not compatible with type %v", value, t)
+		}
+		enum[i] = enumValueOf(v.Convert(t))
+	}
+	return dataType, enum, nil
+}
+
+// EnumProvider can be implemented by types with a fixed set of values, e.g. a Status string type,
+// to list them for the generator. See WithArrayItemEnumFromElementMethod.
+type EnumProvider interface {
+	EnumValues() []any
+}
+
+var enumProviderType = reflect.TypeOf((*EnumProvider)(nil)).Elem()
+
+// EnumDescriptionsProvider can be implemented by enum types to describe each of their values,
+// keyed by the values. See WithEnumDescriptionsFromMethod.
+type EnumDescriptionsProvider interface {
+	EnumDescriptions() map[any]string
+}
+
+var enumDescriptionsProviderType = reflect.TypeOf((*EnumDescriptionsProvider)(nil)).Elem()
+
+// SchemaProvider can be implemented by types to describe their values with the returned property
+// instead of having the generator reflect on them, e.g. a type marshaling itself to JSON in a custom way.
+// JSONSchema is called on the zero value and must return a new Property on each call, as the generator mutates it.
+//
+// The schema of a type is resolved with the first of these rules that applies:
+//  1. the schema registered for the type with RegisterTypeSchema, RegisterEnum or RegisterUnion
+//  2. the schema returned by SchemaProvider
+//  3. a string, if the type implements encoding.TextMarshaler but not json.Marshaler, as encoding/json marshals it as text
+//  4. the schema of its kind, e.g. an integer for an int based type, even if it implements fmt.Stringer,
+//     which only contributes the enum of integer types under WithEnumFromStringer
+type SchemaProvider interface {
+	JSONSchema() *Property
+}
+
+var (
+	schemaProviderType  = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	stringerType        = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// implements reports whether values of type t or pointers to them implement the interface iface
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(iface)
+}
+
+// maxStringerEnumSize bounds the values enumerated by WithEnumFromStringer
+const maxStringerEnumSize = 1024
+
+// stringerEnum returns the consecutive values of the integer type t implementing fmt.Stringer which have a name,
+// and their names. The names of the values outside the enum are expected to be the "T(n)" generated by stringer,
+// the values before the first name are skipped, e.g. for enums starting at iota + 1.
+func stringerEnum(t reflect.Type) ([]any, []string) {
+	var (
+		values []any
+		names  []string
+	)
+	fallbackPrefix := t.Name() + "("
+	for i := 0; i < maxStringerEnumSize; i++ {
+		v := reflect.New(t).Elem()
+		// stop before the values overflowing small integer types
+		if t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64 {
+			if v.OverflowUint(uint64(i)) {
+				break
+			}
+			v.SetUint(uint64(i))
+		} else {
+			if v.OverflowInt(int64(i)) {
+				break
+			}
+			v.SetInt(int64(i))
+		}
+		name := v.Interface().(fmt.Stringer).String()
+		if name == "" || strings.HasPrefix(name, fallbackPrefix) {
+			if len(values) > 0 {
+				break
+			}
+			continue
+		}
+		values = append(values, enumValueOf(v))
+		names = append(names, name)
+	}
+	return values, names
+}
+
+// dataTypeOfKind returns the JSON type of the values of the scalar kind k
+func dataTypeOfKind(k reflect.Kind) (DataType, bool) {
+	switch k {
+	case reflect.String:
+		return String, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Integer, true
+	case reflect.Float32, reflect.Float64:
+		return Number, true
+	case reflect.Bool:
+		return Boolean, true
+	default:
+		return "", false
+	}
+}
+
+// RegisterUnion registers the types of variants as the variants of the interface type iface.
+// The generator describes values of iface as the oneOf of its variants, which are emitted once in the $defs
+// of the schema. Each variant must implement iface. Variants may have fields of type iface, e.g. the nodes
+// of a syntax tree, the recursion terminating at the references to their definitions.
+func RegisterUnion(iface reflect.Type, variants ...any) error {
+	if iface.Kind() != reflect.Interface {
+		return fmt.Errorf("union type %v is not an interface", iface)
+	}
+	if len(variants) == 0 {
+		return fmt.Errorf("union %v has no variants", iface)
+	}
+
+	types := make([]reflect.Type, len(variants))
+	for i, variant := range variants {
+		t := reflect.TypeOf(variant)
+		if t == nil || !t.Implements(iface) {
+			return fmt.Errorf("union variant %v does not implement %v", t, iface)
+		}
+		types[i] = t
+	}
+
+	storeTypeSchema(iface, typeSchema{variants: types})
+	return nil
+}
+
+// storeTypeSchema registers ts for t, the cached schemas which may describe t being regenerated
+func storeTypeSchema(t reflect.Type, ts typeSchema) {
+	typeSchemas.Store(t, ts)
+	invalidateSchemaCache()
+}
+
+// unregisterTypeSchema removes the registration of t, e.g. to clean up after tests
+func unregisterTypeSchema(t reflect.Type) {
+	typeSchemas.Delete(t)
+	invalidateSchemaCache()
+}
+
+// unregisterNamedType removes the type registered under name, e.g. to clean up after tests
+func unregisterNamedType(name string) {
+	namedTypes.Delete(name)
+	invalidateSchemaCache()
+}
+
+// enumValueOf converts v to the plain Go type used for enum values parsed from tags
+func enumValueOf(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		return v.Bool()
+	default:
+		return v.Interface()
+	}
+}
+
+// RegisterTypeFormat makes the generator describe values of type t as strings of the given format
+// instead of reflecting on t, e.g. a [16]byte UUID type that marshals itself as text.
+// It also covers the time types of third-party libraries, e.g. a civil.Date struct
+// marshaling itself as "2006-01-02" is registered with the "date" format and a civil.Time with "time".
+func RegisterTypeFormat(t reflect.Type, format string) {
+	RegisterTypeSchema(t, func() *Property {
+		return &Property{Type: String, Format: format}
+	})
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func lookupTypeSchema(t reflect.Type) (typeSchema, bool) {
+	v, ok := typeSchemas.Load(t)
+	if !ok {
+		// types defined from time.Time, e.g. type Timestamp time.Time, are described as time.Time
+		if t.Kind() != reflect.Struct || t == timeType || !t.ConvertibleTo(timeType) {
+			return typeSchema{}, false
+		}
+		if v, ok = typeSchemas.Load(timeType); !ok {
+			return typeSchema{}, false
+		}
+	}
+	return v.(typeSchema), true
+}
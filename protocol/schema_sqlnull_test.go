@@ -0,0 +1,102 @@
+//go:build go1.22
+
+package protocol
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// jsonNull is a sql.Null[T] marshaling itself to JSON as its value or null
+type jsonNull[T any] struct {
+	V     T
+	Valid bool
+}
+
+func (n *jsonNull[T]) Scan(value any) error {
+	var null sql.Null[T]
+	err := null.Scan(value)
+	n.V, n.Valid = null.V, null.Valid
+	return err
+}
+
+func (n jsonNull[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.V)
+}
+
+func (n *jsonNull[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = jsonNull[T]{}
+		return nil
+	}
+	n.Valid = true
+	return json.Unmarshal(data, &n.V)
+}
+
+func TestGenerateSchemaWithGenericSQLNull(t *testing.T) {
+	type genericSQLNullReq struct {
+		Name  jsonNull[string] `json:"name" enum:"alice,bob"`
+		Count jsonNull[int]    `json:"count" default:"1"`
+		Score sql.NullFloat64  `json:"score"`
+		Label string           `json:"label"`
+	}
+
+	got, err := generateSchemaFromReqStruct(genericSQLNullReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	want := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name":  {Type: String, Nullable: true, Enum: []any{"alice", "bob", nil}},
+			"count": {Type: Integer, Nullable: true, Default: 1},
+			// the database/sql wrappers are encoded as objects by encoding/json
+			"score": {
+				Type:       ObjectT,
+				Properties: map[string]*Property{"Float64": {Type: Number}, "Valid": {Type: Boolean}},
+				Required:   []string{"Float64", "Valid"},
+			},
+			"label": {Type: String},
+		},
+		Required: []string{"score", "label"},
+	}
+	if !compareInputSchema(got, want) {
+		t.Errorf("generateSchemaFromReqStruct() got = %v, want %v", got, want)
+	}
+
+	for data, valid := range map[string]bool{
+		`{"label":"x","score":{"Float64":0,"Valid":false},"name":null,"count":null}`:  true,
+		`{"label":"x","score":{"Float64":1.5,"Valid":true},"name":"alice","count":2}`: true,
+		`{"label":"x","score":1.5}`:                                        false,
+		`{"label":"x","score":{"Float64":0,"Valid":false},"name":"carol"}`: false,
+		`{"label":"x","score":{"Float64":0,"Valid":false},"count":"2"}`:    false,
+	} {
+		var v map[string]any
+		if err := json.Unmarshal([]byte(data), &v); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if err := got.Validate(v); (err == nil) != valid {
+			t.Errorf("Validate(%s) error = %v, want valid %v", data, err, valid)
+		}
+	}
+
+	// the arguments accepted by the schema are decoded into the struct
+	var req genericSQLNullReq
+	content := json.RawMessage(`{"label":"x","score":{"Float64":1.5,"Valid":true},"name":"alice","count":null}`)
+	if err = VerifyAndUnmarshal(content, &req); err != nil {
+		t.Fatalf("VerifyAndUnmarshal() error = %v", err)
+	}
+	wantReq := genericSQLNullReq{
+		Name:  jsonNull[string]{V: "alice", Valid: true},
+		Score: sql.NullFloat64{Float64: 1.5, Valid: true},
+		Label: "x",
+	}
+	if !reflect.DeepEqual(req, wantReq) {
+		t.Errorf("VerifyAndUnmarshal() = %+v, want %+v", req, wantReq)
+	}
+}
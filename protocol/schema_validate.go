@@ -1,10 +1,16 @@
 package protocol
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
@@ -22,102 +28,170 @@ func VerifyAndUnmarshal(content json.RawMessage, v any) error {
 		t = t.Elem()
 	}
 
-	typeUID := getTypeUUID(t)
-	schema, ok := schemaCache.Load(typeUID)
-	if !ok {
+	if _, ok := schemaCache.Load(getTypeUUID(t)); !ok {
 		return fmt.Errorf("schema has not been generated，unable to verify: plz use func `pkg.JSONUnmarshal` instead")
 	}
+	schema, err := loadSchema(context.Background(), t)
+	if err != nil {
+		return err
+	}
+
+	var data any
+	if err := pkg.JSONUnmarshal(content, &data); err != nil {
+		return err
+	}
+	if err := schema.Validate(data); err != nil {
+		return err
+	}
+	return pkg.JSONUnmarshal(content, &v)
+}
+
+// ValidateOption configures the validation of InputSchema.Validate.
+type ValidateOption func(*schemaValidator)
+
+// WithEnumCaseInsensitiveValidation accepts string enum values differing only in case, e.g. "ACTIVE" for "active".
+// With normalize, accepted values are replaced in the validated data by the enum value as declared in the schema.
+func WithEnumCaseInsensitiveValidation(normalize bool) ValidateOption {
+	return func(sv *schemaValidator) {
+		sv.enumCaseInsensitive = true
+		sv.normalizeEnums = normalize
+	}
+}
 
-	return verifySchemaAndUnmarshal(Property{
-		Type:       ObjectT,
-		Properties: schema.Properties,
-		Required:   schema.Required,
-	}, content, v)
+// Validate validates the decoded arguments data, e.g. a map[string]any unmarshalled from JSON, against the schema.
+func (s *InputSchema) Validate(data any, opts ...ValidateOption) error {
+	sv := schemaValidator{defs: s.Defs}
+	for _, opt := range opts {
+		opt(&sv)
+	}
+	if !sv.validateInputSchema(s, data) {
+		return errors.New("data validation failed against the provided schema")
+	}
+	return nil
 }
 
 func verifySchemaAndUnmarshal(schema Property, content []byte, v any) error {
+	return schemaValidator{}.verifyAndUnmarshal(schema, content, v)
+}
+
+func validate(schema Property, data any) bool {
+	return schemaValidator{}.validate(schema, data)
+}
+
+// schemaValidator validates data against schemas, resolving $ref against the definitions of the root schema
+type schemaValidator struct {
+	defs                map[string]*Property
+	enumCaseInsensitive bool
+	normalizeEnums      bool
+}
+
+// validateInputSchema validates data against the root schema s,
+// including the dependent schemas of the properties present in data.
+func (sv schemaValidator) validateInputSchema(s *InputSchema, data any) bool {
+	if !sv.validate(*s.object(), data) {
+		return false
+	}
+	dataMap, _ := data.(map[string]any)
+	for name, dependent := range s.DependentSchemas {
+		if _, exists := dataMap[name]; exists && !sv.validateInputSchema(dependent, data) {
+			return false
+		}
+	}
+	return true
+}
+
+func (sv schemaValidator) verifyAndUnmarshal(schema Property, content []byte, v any) error {
 	var data any
 	err := pkg.JSONUnmarshal(content, &data)
 	if err != nil {
 		return err
 	}
-	if !validate(schema, data) {
+	if !sv.validate(schema, data) {
 		return errors.New("data validation failed against the provided schema")
 	}
 	return pkg.JSONUnmarshal(content, &v)
 }
 
-func validate(schema Property, data any) bool {
+func (sv schemaValidator) validate(schema Property, data any) bool {
+	if schema.Nullable && data == nil {
+		return true
+	}
+
+	if schema.Ref != "" {
+		def, ok := sv.resolve(schema.Ref)
+		return ok && sv.validate(*def, data)
+	}
+
+	if schema.Const != nil && !containsEnumValue([]any{schema.Const}, data) {
+		return false
+	}
+
+	for _, sub := range schema.AllOf {
+		if !sv.validate(*sub, data) {
+			return false
+		}
+	}
+
+	if len(schema.OneOf) > 0 {
+		// the variants are probed without normalizing the data, only the accepted one normalizes it
+		probe := sv
+		probe.normalizeEnums = false
+		var matched *Property
+		matches := 0
+		for _, variant := range schema.OneOf {
+			if probe.validate(*variant, data) {
+				matched = variant
+				matches++
+			}
+		}
+		if matches != 1 {
+			return false
+		}
+		if sv.normalizeEnums {
+			sv.validate(*matched, data)
+		}
+		if schema.Type == "" {
+			return true
+		}
+	}
+
 	switch schema.Type {
 	case ObjectT:
-		return validateObject(schema, data)
+		return sv.validateObject(schema, data)
 	case Array:
-		return validateArray(schema, data)
+		return sv.validateArray(schema, data)
 	case String:
 		str, ok := data.(string)
 		if ok {
+			if !validatePattern(str, schema.Pattern) || !validateLength(str, schema) {
+				return false
+			}
 			return validateEnumProperty[string](str, schema.Enum, func(value string, enumValue any) bool {
 				if enumStr, ok := enumValue.(string); ok {
-					return value == enumStr
-				}
-				return false
-			})
-		}
-		return false
-	case Number: // float64 and int
-		if num, ok := data.(float64); ok {
-			return validateEnumProperty[float64](num, schema.Enum, func(value float64, enumValue any) bool {
-				if enumFloat, ok := enumValue.(float64); ok {
-					return value == enumFloat
-				}
-				return false
-			})
-		}
-		if num, ok := data.(int); ok {
-			return validateEnumProperty[int](num, schema.Enum, func(value int, enumValue any) bool {
-				if enumInt, ok := enumValue.(int); ok {
-					return value == enumInt
+					return value == enumStr || sv.enumCaseInsensitive && strings.EqualFold(value, enumStr)
 				}
 				return false
 			})
 		}
 		return false
-	case Boolean:
-		_, ok := data.(bool)
-		return ok
-	case Integer:
-		// Golang unmarshals all numbers as float64, so we need to check if the float64 is an integer
-		if num, ok := data.(float64); ok {
-			if num == float64(int64(num)) {
-				return validateEnumProperty[float64](num, schema.Enum, func(value float64, enumValue any) bool {
-					if enumFloat, ok := enumValue.(float64); ok {
-						return value == enumFloat
-					}
-					if enumInt, ok := enumValue.(int); ok {
-						return value == float64(enumInt)
-					}
-					return false
-				})
-			}
+	case Number, Integer:
+		// Golang unmarshals all numbers as float64, while the defaults parsed from tags hold any integer kind
+		num, ok := toFloat64(data)
+		if !ok || schema.Type == Integer && num != math.Trunc(num) {
 			return false
 		}
-
-		if num, ok := data.(int); ok {
-			return validateEnumProperty[int](num, schema.Enum, func(value int, enumValue any) bool {
-				if enumInt, ok := enumValue.(int); ok {
-					return value == enumInt
-				}
-				return false
-			})
+		if !validateMultipleOf(num, schema.MultipleOf) || !validateBounds(num, schema) {
+			return false
 		}
-
-		if num, ok := data.(int64); ok {
-			return validateEnumProperty[int64](num, schema.Enum, func(value int64, enumValue any) bool {
-				if enumInt, ok := enumValue.(int); ok {
-					return value == int64(enumInt)
-				}
-				if enumInt64, ok := enumValue.(int64); ok {
-					return value == enumInt64
+		return validateEnumProperty[float64](num, schema.Enum, func(value float64, enumValue any) bool {
+			enumNum, ok := toFloat64(enumValue)
+			return ok && value == enumNum
+		})
+	case Boolean:
+		if b, ok := data.(bool); ok {
+			return validateEnumProperty[bool](b, schema.Enum, func(value bool, enumValue any) bool {
+				if enumBool, ok := enumValue.(bool); ok {
+					return value == enumBool
 				}
 				return false
 			})
@@ -125,43 +199,170 @@ func validate(schema Property, data any) bool {
 		return false
 	case Null:
 		return data == nil
+	case "":
+		// a schema without type accepts any value, e.g. the one of json.RawMessage
+		return len(schema.Enum) == 0 || containsEnumValue(schema.Enum, data)
 	default:
 		return false
 	}
 }
 
-func validateObject(schema Property, data any) bool {
+// evaluates reports whether the property name is evaluated by the object schema or its allOf schemas,
+// i.e. declared by their properties or accepted by their additionalProperties, for unevaluatedProperties
+func (sv schemaValidator) evaluates(schema Property, name string) bool {
+	if schema.Ref != "" {
+		def, ok := sv.resolve(schema.Ref)
+		if !ok {
+			return false
+		}
+		schema = *def
+	}
+	if _, declared := schema.Properties[name]; declared {
+		return true
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties != false {
+		return true
+	}
+	for _, sub := range schema.AllOf {
+		if sv.evaluates(*sub, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the definition referenced by ref, definitions are looked up by the last segment of ref
+func (sv schemaValidator) resolve(ref string) (*Property, bool) {
+	def, ok := sv.defs[ref[strings.LastIndex(ref, "/")+1:]]
+	return def, ok
+}
+
+func (sv schemaValidator) validateObject(schema Property, data any) bool {
 	dataMap, ok := data.(map[string]any)
 	if !ok {
 		return false
 	}
+	if len(schema.Enum) > 0 && !containsEnumValue(schema.Enum, data) {
+		return false
+	}
 	for _, field := range schema.Required {
 		if _, exists := dataMap[field]; !exists {
 			return false
 		}
 	}
+	if schema.PropertyNames != nil {
+		for key := range dataMap {
+			if !sv.validate(*schema.PropertyNames, key) {
+				return false
+			}
+		}
+	}
 	for key, valueSchema := range schema.Properties {
 		value, exists := dataMap[key]
-		if exists && !validate(*valueSchema, value) {
+		if !exists {
+			continue
+		}
+		if !sv.validate(*valueSchema, value) {
 			return false
 		}
+		if normalized, changed := sv.normalize(*valueSchema, value); changed {
+			dataMap[key] = normalized
+		}
+	}
+	for key, value := range dataMap {
+		if schema.UnevaluatedProperties != nil && !*schema.UnevaluatedProperties && !sv.evaluates(schema, key) {
+			return false
+		}
+		if _, declared := schema.Properties[key]; declared {
+			continue
+		}
+		switch additional := schema.AdditionalProperties.(type) {
+		case bool:
+			if !additional {
+				return false
+			}
+		case *Property:
+			if !sv.validate(*additional, value) {
+				return false
+			}
+		}
 	}
 	return true
 }
 
-func validateArray(schema Property, data any) bool {
+func (sv schemaValidator) validateArray(schema Property, data any) bool {
 	dataArray, ok := data.([]any)
 	if !ok {
 		return false
 	}
-	for _, item := range dataArray {
-		if !validate(*schema.Items, item) {
+	for i, item := range dataArray {
+		if !sv.validate(*schema.Items, item) {
 			return false
 		}
+		if normalized, changed := sv.normalize(*schema.Items, item); changed {
+			dataArray[i] = normalized
+		}
 	}
 	return true
 }
 
+// normalize returns the enum value as declared in the schema for a valid string value
+// differing only in case, if enabled by WithEnumCaseInsensitiveValidation, and whether it differs from value,
+// so that the data is only written to when it is normalized.
+func (sv schemaValidator) normalize(schema Property, value any) (any, bool) {
+	str, ok := value.(string)
+	if !ok || !sv.normalizeEnums {
+		return value, false
+	}
+	if schema.Ref != "" {
+		def, ok := sv.resolve(schema.Ref)
+		if !ok {
+			return value, false
+		}
+		schema = *def
+	}
+	for _, enumValue := range schema.Enum {
+		if enumValue == value {
+			return value, false
+		}
+	}
+	for _, enumValue := range schema.Enum {
+		if enumStr, ok := enumValue.(string); ok && strings.EqualFold(str, enumStr) {
+			return enumStr, enumStr != str
+		}
+	}
+	return value, false
+}
+
+// validateLength checks the number of characters of str is within the minLength and maxLength of the schema
+func validateLength(str string, schema Property) bool {
+	length := utf8.RuneCountInString(str)
+	return (schema.MinLength == nil || length >= *schema.MinLength) && (schema.MaxLength == nil || length <= *schema.MaxLength)
+}
+
+// validatePattern checks str matches the regular expression pattern, an invalid pattern matching nothing
+func validatePattern(str, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, err := regexp.MatchString(pattern, str)
+	return err == nil && matched
+}
+
+// validateBounds checks num is within the inclusive minimum and maximum of the schema
+func validateBounds(num float64, schema Property) bool {
+	return (schema.Minimum == nil || num >= *schema.Minimum) && (schema.Maximum == nil || num <= *schema.Maximum)
+}
+
+// validateMultipleOf checks num is a multiple of multipleOf, tolerating floating point rounding
+func validateMultipleOf(num float64, multipleOf *float64) bool {
+	if multipleOf == nil || *multipleOf <= 0 {
+		return true
+	}
+	quotient := num / *multipleOf
+	return math.Abs(quotient-math.Round(quotient)) < 1e-9
+}
+
 func validateEnumProperty[T any](data T, enum []any, compareFunc func(T, any) bool) bool {
 	for _, enumValue := range enum {
 		if compareFunc(data, enumValue) {
@@ -170,3 +371,203 @@ func validateEnumProperty[T any](data T, enum []any, compareFunc func(T, any) bo
 	}
 	return len(enum) == 0
 }
+
+// ArgumentViolation is a violation of a schema by the arguments of a tool call,
+// Path being the JSON pointer of the offending value in the arguments, e.g. "/items/0/quantity".
+type ArgumentViolation struct {
+	Path    string
+	Message string
+}
+
+// ArgumentsError lists every violation of a schema by the arguments of a tool call, see ValidateArguments.
+type ArgumentsError struct {
+	Violations []ArgumentViolation
+}
+
+func (e *ArgumentsError) Error() string {
+	violations := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		violations[i] = v.Path + ": " + v.Message
+	}
+	return "invalid arguments: " + strings.Join(violations, "; ")
+}
+
+// ValidateArguments validates the raw arguments of a tool call against its schema, so that servers can reject
+// malformed calls before invoking their handlers. Empty arguments are validated as an empty object.
+// Unlike Validate, the returned *ArgumentsError lists every violation instead of failing on the first one,
+// which lets callers relay precise feedback to the model.
+func ValidateArguments(schema *InputSchema, raw json.RawMessage) error {
+	if len(raw) == 0 {
+		raw = json.RawMessage("{}")
+	}
+	var data any
+	if err := pkg.JSONUnmarshal(raw, &data); err != nil {
+		return err
+	}
+
+	c := &violationCollector{sv: schemaValidator{defs: schema.Defs}}
+	c.collectInputSchema(schema, data)
+	if len(c.violations) > 0 {
+		return &ArgumentsError{Violations: c.violations}
+	}
+	return nil
+}
+
+// violationCollector descends into the values failing the validation to collect the violations of their parts
+type violationCollector struct {
+	sv         schemaValidator
+	violations []ArgumentViolation
+}
+
+func (c *violationCollector) add(path, format string, args ...any) {
+	if path == "" {
+		path = "/"
+	}
+	c.violations = append(c.violations, ArgumentViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *violationCollector) collectInputSchema(s *InputSchema, data any) {
+	c.collect("", *s.object(), data)
+	dataMap, _ := data.(map[string]any)
+	for _, name := range sortedDependentNames(s.DependentSchemas) {
+		if _, exists := dataMap[name]; exists {
+			c.collectInputSchema(s.DependentSchemas[name], data)
+		}
+	}
+}
+
+func (c *violationCollector) collect(path string, schema Property, data any) {
+	if c.sv.validate(schema, data) {
+		return
+	}
+	if schema.Ref != "" {
+		def, ok := c.sv.resolve(schema.Ref)
+		if !ok {
+			c.add(path, "unresolved reference %s", schema.Ref)
+			return
+		}
+		c.collect(path, *def, data)
+		return
+	}
+	if len(schema.OneOf) > 0 {
+		c.add(path, "must match exactly one of %d alternatives", len(schema.OneOf))
+		return
+	}
+
+	before := len(c.violations)
+	for _, sub := range schema.AllOf {
+		c.collect(path, *sub, data)
+	}
+	switch value := data.(type) {
+	case map[string]any:
+		if schema.Type == ObjectT {
+			c.collectObject(path, schema, value)
+		}
+	case []any:
+		if schema.Type == Array && schema.Items != nil {
+			for i, item := range value {
+				c.collect(fmt.Sprintf("%s/%d", path, i), *schema.Items, item)
+			}
+		}
+	}
+	if len(c.violations) == before {
+		c.add(path, "%s", c.describe(schema, data))
+	}
+}
+
+func (c *violationCollector) collectObject(path string, schema Property, data map[string]any) {
+	for _, name := range schema.Required {
+		if _, exists := data[name]; !exists {
+			c.add(path+"/"+name, "missing required property")
+		}
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	additional, _ := schema.AdditionalProperties.(*Property)
+	for _, key := range keys {
+		if schema.PropertyNames != nil && !c.sv.validate(*schema.PropertyNames, key) {
+			c.add(path+"/"+key, "property name %q is not allowed", key)
+		}
+		if valueSchema, declared := schema.Properties[key]; declared {
+			c.collect(path+"/"+key, *valueSchema, data[key])
+		} else if additional != nil {
+			c.collect(path+"/"+key, *additional, data[key])
+		} else if schema.AdditionalProperties == false {
+			c.add(path+"/"+key, "property is not allowed")
+		}
+		if schema.UnevaluatedProperties != nil && !*schema.UnevaluatedProperties && !c.sv.evaluates(schema, key) {
+			c.add(path+"/"+key, "property is not allowed")
+		}
+	}
+}
+
+// describe explains why the value data, whose parts are valid, is not valid against schema
+func (c *violationCollector) describe(schema Property, data any) string {
+	if schema.Const != nil && !containsEnumValue([]any{schema.Const}, data) {
+		return fmt.Sprintf("must be %v", schema.Const)
+	}
+	if !c.sv.validate(Property{Type: schema.Type, Nullable: schema.Nullable}, data) {
+		expected := string(schema.Type)
+		if schema.Nullable {
+			expected += " or null"
+		}
+		return fmt.Sprintf("expected %s, got %s", expected, jsonTypeOf(data))
+	}
+	if len(schema.Enum) > 0 && !c.sv.validate(Property{Type: schema.Type, Enum: schema.Enum}, data) {
+		return fmt.Sprintf("must be one of %v", schema.Enum)
+	}
+
+	switch value := data.(type) {
+	case string:
+		length := utf8.RuneCountInString(value)
+		switch {
+		case !validatePattern(value, schema.Pattern):
+			return fmt.Sprintf("must match the pattern %s", schema.Pattern)
+		case schema.MinLength != nil && length < *schema.MinLength:
+			return fmt.Sprintf("must be at least %d characters long", *schema.MinLength)
+		case schema.MaxLength != nil && length > *schema.MaxLength:
+			return fmt.Sprintf("must be at most %d characters long", *schema.MaxLength)
+		}
+	case float64:
+		switch {
+		case schema.Minimum != nil && value < *schema.Minimum:
+			return fmt.Sprintf("must be greater than or equal to %v", *schema.Minimum)
+		case schema.Maximum != nil && value > *schema.Maximum:
+			return fmt.Sprintf("must be less than or equal to %v", *schema.Maximum)
+		case !validateMultipleOf(value, schema.MultipleOf):
+			return fmt.Sprintf("must be a multiple of %v", *schema.MultipleOf)
+		}
+	}
+	return "does not match the schema"
+}
+
+// jsonTypeOf returns the JSON type of the decoded JSON value v
+func jsonTypeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return string(Null)
+	case bool:
+		return string(Boolean)
+	case string:
+		return string(String)
+	case []any:
+		return string(Array)
+	case map[string]any:
+		return string(ObjectT)
+	default:
+		return string(Number)
+	}
+}
+
+func sortedDependentNames(dependents map[string]*InputSchema) []string {
+	names := make([]string, 0, len(dependents))
+	for name := range dependents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
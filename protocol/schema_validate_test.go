@@ -2,7 +2,10 @@ package protocol
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -33,6 +36,8 @@ func Test_Validate(t *testing.T) {
 		{"", args{data: 4, schema: Property{Type: Number, Enum: []any{1, 2, 3}}}, false},
 		{"", args{data: false, schema: Property{Type: Boolean}}, true},
 		{"", args{data: 123, schema: Property{Type: Boolean}}, false},
+		{"", args{data: true, schema: Property{Type: Boolean, Enum: []any{true}}}, true},
+		{"", args{data: false, schema: Property{Type: Boolean, Enum: []any{true}}}, false},
 		{"", args{data: nil, schema: Property{Type: Null}}, true},
 		{"", args{data: 0, schema: Property{Type: Null}}, false},
 		// array
@@ -332,7 +337,7 @@ func TestVerifyAndUnmarshal(t *testing.T) {
 		Name   string `json:"name"`
 		Age    int    `json:"age,omitempty"`
 		Active bool   `json:"active"`
-	}{})), anonymousStructSchema)
+	}{})), cachedSchema{schema: anonymousStructSchema, generation: atomic.LoadUint64(&registryGeneration)})
 
 	type args struct {
 		content json.RawMessage
@@ -462,3 +467,183 @@ func TestVerifyAndUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestInputSchemaValidateEnumCaseInsensitive(t *testing.T) {
+	schema := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"status": {Type: String, Enum: []any{"active", "inactive"}},
+			"tags":   {Type: Array, Items: &Property{Type: String, Enum: []any{"Red", "Green"}}},
+			"level":  {Ref: "#/$defs/Level"},
+		},
+		Required: []string{"status"},
+		Defs: map[string]*Property{
+			"Level": {Type: String, Enum: []any{"low", "high"}},
+		},
+	}
+	newData := func() map[string]any {
+		return map[string]any{"status": "ACTIVE", "tags": []any{"red", "GREEN"}, "level": "High"}
+	}
+
+	if err := schema.Validate(newData()); err == nil {
+		t.Errorf("Validate() error = nil, want case sensitive rejection by default")
+	}
+	if err := schema.Validate(map[string]any{"status": "active", "tags": []any{"Red"}, "level": "low"}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	data := newData()
+	if err := schema.Validate(data, WithEnumCaseInsensitiveValidation(false)); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !reflect.DeepEqual(data, newData()) {
+		t.Errorf("Validate() without normalize modified data = %v", data)
+	}
+
+	data = newData()
+	if err := schema.Validate(data, WithEnumCaseInsensitiveValidation(true)); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	want := map[string]any{"status": "active", "tags": []any{"Red", "Green"}, "level": "high"}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Validate() normalized data = %v, want %v", data, want)
+	}
+
+	if err := schema.Validate(map[string]any{"status": "deleted"}, WithEnumCaseInsensitiveValidation(true)); err == nil {
+		t.Errorf("Validate() error = nil, want rejection of non-member")
+	}
+}
+
+func TestInputSchemaValidateDoesNotWriteData(t *testing.T) {
+	closed := false
+	schema := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"status": {Type: String, Enum: []any{"active", "inactive"}},
+			"tags":   {Type: Array, Items: &Property{Type: String, Enum: []any{"Red", "Green"}}},
+			"shape": {OneOf: []*Property{
+				// the variant rejected for its additional property would normalize the color
+				{Type: ObjectT, Properties: map[string]*Property{"color": {Type: String, Enum: []any{"RED"}}}, AdditionalProperties: closed},
+				{Type: ObjectT, Properties: map[string]*Property{"color": {Type: String}}},
+			}},
+		},
+	}
+
+	// concurrent validations of the same data only read it, which the race detector checks
+	data := map[string]any{"status": "active", "tags": []any{"Red", "Green"}, "shape": map[string]any{"color": "red", "size": 1.0}}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := schema.Validate(data); err != nil {
+					t.Errorf("Validate() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := schema.Validate(data, WithEnumCaseInsensitiveValidation(true)); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if color := data["shape"].(map[string]any)["color"]; color != "red" {
+		t.Errorf("Validate() color = %v, want red as accepted by the second variant, not normalized by the rejected one", color)
+	}
+}
+
+func TestValidateArguments(t *testing.T) {
+	type validateArgumentsItem struct {
+		SKU      string `json:"sku" pattern:"^[A-Z]{3}-\\d+$"`
+		Quantity int    `json:"quantity" minimum:"1" maximum:"99"`
+	}
+	type validateArgumentsReq struct {
+		Customer string                  `json:"customer" minLength:"2"`
+		Priority string                  `json:"priority" enum:"low,high"`
+		Note     string                  `json:"note,omitempty"`
+		Items    []validateArgumentsItem `json:"items"`
+	}
+	schema, err := GenerateSchema(validateArgumentsReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+
+	if err = ValidateArguments(schema, json.RawMessage(`{"customer":"Ada","priority":"low","items":[{"sku":"ABC-1","quantity":2}]}`)); err != nil {
+		t.Errorf("ValidateArguments() of valid arguments error = %v", err)
+	}
+
+	err = ValidateArguments(schema, json.RawMessage(`{"customer":"A","priority":"urgent","note":3,"items":[{"sku":"abc","quantity":0},{"quantity":1.5}]}`))
+	var argumentsErr *ArgumentsError
+	if !errors.As(err, &argumentsErr) {
+		t.Fatalf("ValidateArguments() error = %v, want *ArgumentsError", err)
+	}
+	want := []ArgumentViolation{
+		{Path: "/customer", Message: "must be at least 2 characters long"},
+		{Path: "/items/0/quantity", Message: "must be greater than or equal to 1"},
+		{Path: "/items/0/sku", Message: "must match the pattern ^[A-Z]{3}-\\d+$"},
+		{Path: "/items/1/sku", Message: "missing required property"},
+		{Path: "/items/1/quantity", Message: "expected integer, got number"},
+		{Path: "/note", Message: "expected string, got number"},
+		{Path: "/priority", Message: "must be one of [low high]"},
+	}
+	if !reflect.DeepEqual(argumentsErr.Violations, want) {
+		t.Errorf("ValidateArguments() violations = %v, want %v", argumentsErr.Violations, want)
+	}
+
+	err = ValidateArguments(schema, nil)
+	if !errors.As(err, &argumentsErr) || len(argumentsErr.Violations) != 3 {
+		t.Errorf("ValidateArguments() of empty arguments error = %v, want the 3 missing required properties", err)
+	}
+}
+
+func TestValidateClosedObjects(t *testing.T) {
+	type closedObjectsAddress struct {
+		City string `json:"city"`
+	}
+	type closedObjectsReq struct {
+		Name    string               `json:"name"`
+		Address closedObjectsAddress `json:"address,omitempty"`
+	}
+	schema, err := GenerateSchema(closedObjectsReq{}, WithAdditionalProperties(false))
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+
+	tests := []struct {
+		data string
+		want []ArgumentViolation
+	}{
+		{data: `{"name":"Ada","address":{"city":"London"}}`},
+		{data: `{"name":"Ada","age":36}`, want: []ArgumentViolation{{Path: "/age", Message: "property is not allowed"}}},
+		{data: `{"name":"Ada","address":{"city":"London","zip":"N1"}}`, want: []ArgumentViolation{{Path: "/address/zip", Message: "property is not allowed"}}},
+	}
+	for _, tt := range tests {
+		var data map[string]any
+		if err = json.Unmarshal([]byte(tt.data), &data); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		if err = schema.Validate(data); (err == nil) != (tt.want == nil) {
+			t.Errorf("Validate(%s) error = %v, want valid %v", tt.data, err, tt.want == nil)
+		}
+
+		err = ValidateArguments(schema, json.RawMessage(tt.data))
+		var argumentsErr *ArgumentsError
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("ValidateArguments(%s) error = %v", tt.data, err)
+			}
+		} else if !errors.As(err, &argumentsErr) || !reflect.DeepEqual(argumentsErr.Violations, tt.want) {
+			t.Errorf("ValidateArguments(%s) error = %v, want violations %v", tt.data, err, tt.want)
+		}
+	}
+
+	open, err := GenerateSchema(closedObjectsReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	if err = open.Validate(map[string]any{"name": "Ada", "age": 36.0}); err != nil {
+		t.Errorf("Validate() of an open schema with an undeclared property error = %v", err)
+	}
+}
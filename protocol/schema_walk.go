@@ -0,0 +1,133 @@
+package protocol
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Walk calls fn for every property of the schema in a depth-first, deterministic order.
+// The path passed to fn is the JSON pointer of the property in the arguments
+// (e.g. "/user/info/age"), the items of an array are addressed with "*" (e.g. "/tags/*")
+// and the schemas of a oneOf or allOf by their index (e.g. "/shape/oneOf/0").
+// The other subschemas are addressed by their keyword, e.g. "/labels/additionalProperties" for the values of a map,
+// "/labels/propertyNames" for its keys, "/payload/contentSchema" and "/dependentSchemas/name".
+// The definitions are walked last, under the "/$defs" path.
+// Walk stops and returns the first error returned by fn, which may modify the properties in place.
+func (s *InputSchema) Walk(fn func(path string, p *Property) error) error {
+	if err := s.walk("", fn); err != nil {
+		return err
+	}
+	return walkProperties("/$defs", s.Defs, fn)
+}
+
+// walk walks the subschemas of s but its definitions, which are only held by the root schema
+func (s *InputSchema) walk(path string, fn func(string, *Property) error) error {
+	if err := walkProperties(path, s.Properties, fn); err != nil {
+		return err
+	}
+	for i, sub := range s.AllOf {
+		if err := walkProperty(fmt.Sprintf("%s/allOf/%d", path, i), sub, fn); err != nil {
+			return err
+		}
+	}
+	if additional, ok := s.AdditionalProperties.(*Property); ok {
+		if err := walkProperty(path+"/additionalProperties", additional, fn); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(s.DependentSchemas))
+	for name := range s.DependentSchemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if dependent := s.DependentSchemas[name]; dependent != nil {
+			if err := dependent.walk(path+"/dependentSchemas/"+name, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func walkProperties(path string, properties map[string]*Property, fn func(string, *Property) error) error {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := walkProperty(path+"/"+name, properties[name], fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkProperty(path string, p *Property, fn func(string, *Property) error) error {
+	if p == nil {
+		return nil
+	}
+	if err := fn(path, p); err != nil {
+		return err
+	}
+	if err := walkProperty(path+"/*", p.Items, fn); err != nil {
+		return err
+	}
+	for i, variant := range p.OneOf {
+		if err := walkProperty(fmt.Sprintf("%s/oneOf/%d", path, i), variant, fn); err != nil {
+			return err
+		}
+	}
+	for i, sub := range p.AllOf {
+		if err := walkProperty(fmt.Sprintf("%s/allOf/%d", path, i), sub, fn); err != nil {
+			return err
+		}
+	}
+	if additional, ok := p.AdditionalProperties.(*Property); ok {
+		if err := walkProperty(path+"/additionalProperties", additional, fn); err != nil {
+			return err
+		}
+	}
+	if err := walkProperty(path+"/propertyNames", p.PropertyNames, fn); err != nil {
+		return err
+	}
+	if err := walkProperty(path+"/contentSchema", p.ContentSchema, fn); err != nil {
+		return err
+	}
+	return walkProperties(path, p.Properties, fn)
+}
+
+// StripDescriptions recursively removes the descriptive keywords from the schema,
+// which is useful to save tokens in constrained contexts. Like Walk, it modifies the schema in place;
+// the schemas returned by GenerateSchema are copies, so stripping them doesn't affect later generations.
+func (s *InputSchema) StripDescriptions() {
+	s.Title = ""
+	for _, dependent := range s.DependentSchemas {
+		if dependent != nil {
+			dependent.Title = ""
+		}
+	}
+	_ = s.Walk(func(_ string, p *Property) error {
+		p.Title = ""
+		p.Description = ""
+		p.Examples = nil
+		delete(p.Extensions, "x-enumDescriptions")
+		return nil
+	})
+}
+
+// AllEnums returns the enums of the schema by the path of their property as passed by Walk,
+// e.g. for building pickers in clients. Enums of definitions are returned under their "/$defs" path.
+func (s *InputSchema) AllEnums() map[string][]any {
+	enums := make(map[string][]any)
+	_ = s.Walk(func(path string, p *Property) error {
+		if len(p.Enum) > 0 {
+			enums[path] = p.Enum
+		}
+		return nil
+	})
+	return enums
+}
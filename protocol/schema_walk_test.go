@@ -0,0 +1,221 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInputSchema_Walk(t *testing.T) {
+	schema := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name": {Type: String},
+			"tags": {Type: Array, Items: &Property{Type: String}},
+			"user": {
+				Type: ObjectT,
+				Properties: map[string]*Property{
+					"info": {
+						Type:       ObjectT,
+						Properties: map[string]*Property{"age": {Type: Integer}},
+					},
+				},
+			},
+		},
+	}
+
+	var paths []string
+	if err := schema.Walk(func(path string, _ *Property) error {
+		paths = append(paths, path)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+
+	want := []string{"/name", "/tags", "/tags/*", "/user", "/user/info", "/user/info/age"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Walk() paths = %v, want %v", paths, want)
+	}
+}
+
+func TestInputSchema_WalkSubschemas(t *testing.T) {
+	schema := &InputSchema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"labels": {
+				Type:                 ObjectT,
+				PropertyNames:        &Property{Type: String, Pattern: "^[a-z]+$"},
+				AdditionalProperties: &Property{Type: String},
+			},
+			"payload": {Type: String, ContentMediaType: "application/json", ContentSchema: &Property{Type: ObjectT}},
+			"strict":  {Type: ObjectT, AdditionalProperties: false},
+		},
+		AdditionalProperties: &Property{Type: Integer},
+		DependentSchemas: map[string]*InputSchema{
+			"payload": {Properties: map[string]*Property{"encoding": {Type: String}}},
+		},
+	}
+
+	var paths []string
+	if err := schema.Walk(func(path string, _ *Property) error {
+		paths = append(paths, path)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+
+	want := []string{
+		"/labels", "/labels/additionalProperties", "/labels/propertyNames",
+		"/payload", "/payload/contentSchema",
+		"/strict",
+		"/additionalProperties",
+		"/dependentSchemas/payload/encoding",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Walk() paths = %v, want %v", paths, want)
+	}
+}
+
+func TestInputSchema_StripDescriptions(t *testing.T) {
+	type stripDescriptionsReq struct {
+		Name  string `json:"name" description:"name" examples:"alice,bob"`
+		Items []struct {
+			Label string `json:"label" description:"label"`
+		} `json:"items" description:"items"`
+		User struct {
+			Info struct {
+				Age int `json:"age" description:"age"`
+			} `json:"info" description:"info"`
+		} `json:"user" description:"user"`
+	}
+
+	schema, err := generateSchemaFromReqStruct(stripDescriptionsReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+	schema.StripDescriptions()
+
+	var count int
+	_ = schema.Walk(func(path string, p *Property) error {
+		count++
+		if p.Description != "" || len(p.Examples) > 0 {
+			t.Errorf("StripDescriptions() left description %q and examples %v at %s", p.Description, p.Examples, path)
+		}
+		return nil
+	})
+	if count != 7 {
+		t.Errorf("StripDescriptions() walked %d properties, want 7", count)
+	}
+}
+
+type stripNote string
+
+func TestInputSchema_StripDescriptionsOfSubschemas(t *testing.T) {
+	noteType := reflect.TypeOf(stripNote(""))
+	RegisterTypeSchema(noteType, func() *Property {
+		return &Property{
+			Type:        String,
+			Title:       "Note",
+			Description: "note",
+			Examples:    []any{"hello"},
+			Extensions:  map[string]any{"x-enumDescriptions": map[string]string{"hello": "greeting"}},
+		}
+	})
+	defer unregisterTypeSchema(noteType)
+
+	type stripSubschemasReq struct {
+		Status enumDescriptionsStatus `json:"status" enum:"open,closed"`
+		Notes  map[string]stripNote   `json:"notes" title:"Notes"`
+	}
+
+	schema, err := GenerateSchema(stripSubschemasReq{}, WithTitle("Request"), WithEnumDescriptionsFromMethod())
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	schema.StripDescriptions()
+
+	if schema.Title != "" {
+		t.Errorf("StripDescriptions() left the title %q of the schema", schema.Title)
+	}
+	var count int
+	_ = schema.Walk(func(path string, p *Property) error {
+		count++
+		if p.Title != "" || p.Description != "" || len(p.Examples) > 0 || len(p.Extensions) > 0 {
+			t.Errorf("StripDescriptions() left %+v at %s", p, path)
+		}
+		return nil
+	})
+	if count != 3 {
+		t.Errorf("StripDescriptions() walked %d properties, want 3", count)
+	}
+}
+
+func TestInputSchema_StripDescriptionsKeepsGeneratedSchemas(t *testing.T) {
+	type stripCachedReq struct {
+		Name string `json:"name" description:"name"`
+	}
+
+	schema, err := GenerateSchema(stripCachedReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	schema.StripDescriptions()
+
+	again, err := GenerateSchema(stripCachedReq{})
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	if p := again.Properties["name"]; p == nil || p.Description != "name" {
+		t.Errorf("GenerateSchema() after StripDescriptions() name = %+v, want its description", p)
+	}
+}
+
+func TestInputSchema_AllEnums(t *testing.T) {
+	type allEnumsReq struct {
+		Status string `json:"status" enum:"open,closed"`
+		Labels []struct {
+			Kind string `json:"kind" enum:"bug,feature"`
+		} `json:"labels"`
+		Filter struct {
+			Priority int    `json:"priority" enum:"1,2,3"`
+			Query    string `json:"query"`
+		} `json:"filter"`
+	}
+
+	schema, err := generateSchemaFromReqStruct(allEnumsReq{})
+	if err != nil {
+		t.Fatalf("generateSchemaFromReqStruct() error = %v", err)
+	}
+
+	want := map[string][]any{
+		"/status":          {"open", "closed"},
+		"/labels/*/kind":   {"bug", "feature"},
+		"/filter/priority": {1, 2, 3},
+	}
+	if got := schema.AllEnums(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllEnums() = %v, want %v", got, want)
+	}
+}
+
+type allEnumsStatus string
+
+func TestInputSchema_AllEnumsOfMapValues(t *testing.T) {
+	statusType := reflect.TypeOf(allEnumsStatus(""))
+	if err := RegisterEnum(statusType, "open", "closed"); err != nil {
+		t.Fatalf("RegisterEnum() error = %v", err)
+	}
+	defer unregisterTypeSchema(statusType)
+
+	type allEnumsMapReq struct {
+		Statuses map[string]allEnumsStatus `json:"statuses"`
+	}
+
+	schema, err := GenerateSchema(allEnumsMapReq{}, WithInlineEnums())
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+
+	want := map[string][]any{"/statuses/additionalProperties": {"open", "closed"}}
+	if got := schema.AllEnums(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AllEnums() = %v, want %v", got, want)
+	}
+}
@@ -85,7 +85,7 @@ func (t *Tool) MarshalJSON() ([]byte, error) {
 		m["inputSchema"] = t.InputSchema
 	}
 
-	if t.OutputSchema.Properties != nil {
+	if t.OutputSchema.Type != "" || t.OutputSchema.Properties != nil {
 		m["outputSchema"] = t.OutputSchema
 	}
 
@@ -103,9 +103,43 @@ const Object InputSchemaType = "object"
 
 // InputSchema represents a JSON Schema object defining the expected parameters for a tool
 type InputSchema struct {
+	// ID identifies the schema for bundling and referencing it across documents, emitted as $id
+	ID string `json:"$id,omitempty"`
+	// Title is a short human readable name of the arguments, for user interfaces
+	Title      string               `json:"title,omitempty"`
 	Type       InputSchemaType      `json:"type"`
 	Properties map[string]*Property `json:"properties,omitempty"`
 	Required   []string             `json:"required,omitempty"`
+	// AdditionalProperties is either a bool or a *Property describing the values of properties not listed in Properties
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+	// AllOf holds the schemas the arguments must also be valid against, UnevaluatedProperties false rejecting
+	// the properties declared neither by the schema nor by its AllOf schemas
+	AllOf                 []*Property `json:"allOf,omitempty"`
+	UnevaluatedProperties *bool       `json:"unevaluatedProperties,omitempty"`
+	// Defs holds the definitions referenced by the properties through $ref
+	Defs map[string]*Property `json:"$defs,omitempty"`
+	// DependentSchemas holds the subschemas applied to the arguments when the property of their key is present
+	DependentSchemas map[string]*InputSchema `json:"dependentSchemas,omitempty"`
+}
+
+// object returns the root object of the schema as a property, without its definitions and dependent schemas
+func (s *InputSchema) object() *Property {
+	return &Property{
+		Type:                  ObjectT,
+		Properties:            s.Properties,
+		Required:              s.Required,
+		AdditionalProperties:  s.AdditionalProperties,
+		AllOf:                 s.AllOf,
+		UnevaluatedProperties: s.UnevaluatedProperties,
+	}
+}
+
+// MarshalJSON always emits the spec compliant "object" type, whichever constant Type was set from.
+func (s InputSchema) MarshalJSON() ([]byte, error) {
+	type alias InputSchema
+	temp := alias(s)
+	temp.Type = InputSchemaType(ObjectT)
+	return json.Marshal(temp)
 }
 
 // OutputSchema represents a Optional JSON Schema object defining expected output structure for a tool
@@ -168,7 +202,9 @@ func (r *CallToolRequest) MarshalJSON() ([]byte, error) {
 // CallToolResult represents the response to a tool call
 type CallToolResult struct {
 	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
+	// StructuredContent holds the result as a JSON object conforming to the OutputSchema of the tool, if it has one
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface for CallToolResult
@@ -246,6 +282,48 @@ func NewTool(name string, description string, inputReqStruct interface{}) (*Tool
 	}, nil
 }
 
+// NewToolWithOutputSchema creates a tool whose input and output schemas are generated from the request and result structs,
+// the results of its calls carrying the result struct as their structured content.
+func NewToolWithOutputSchema(name string, description string, inputReqStruct, outputResultStruct interface{}) (*Tool, error) {
+	tool, err := NewTool(name, description, inputReqStruct)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := generateSchemaFromReqStruct(outputResultStruct)
+	if err != nil {
+		return nil, fmt.Errorf("output schema: %w", err)
+	}
+	tool.OutputSchema = OutputSchema(*schema)
+	return tool, nil
+}
+
+// ValidateStructuredContent validates the structured content of the result of a call against the output schema of the tool.
+// Results of tools without output schema and error results are not checked.
+func (t *Tool) ValidateStructuredContent(result *CallToolResult) error {
+	if t.OutputSchema.Type == "" || result.IsError {
+		return nil
+	}
+	if result.StructuredContent == nil {
+		return fmt.Errorf("tool %s has an output schema but its result has no structured content", t.Name)
+	}
+
+	// the schema describes the JSON of the content, which may be any Go value
+	content, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	if err = pkg.JSONUnmarshal(content, &data); err != nil {
+		return err
+	}
+	schema := InputSchema(t.OutputSchema)
+	if err = schema.Validate(data); err != nil {
+		return fmt.Errorf("structured content of tool %s: %w", t.Name, err)
+	}
+	return nil
+}
+
 func NewToolWithRawSchema(name, description string, schema json.RawMessage) *Tool {
 	return &Tool{
 		Name:           name,
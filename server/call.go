@@ -63,7 +63,8 @@ func (server *Server) SendProgressNotification(ctx context.Context, notify *prot
 	}
 	notify.ProgressToken = progressToken
 
-	if err = server.sendMsgWithNotification(ctx, "", protocol.NotificationProgress, notify); err != nil {
+	sessionID, _ := GetSessionIDFromCtx(ctx)
+	if err = server.sendMsgWithNotification(ctx, sessionID, protocol.NotificationProgress, notify); err != nil {
 		return err
 	}
 
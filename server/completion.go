@@ -0,0 +1,26 @@
+package server
+
+import (
+	"context"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// CompleterFunc suggests the values of an argument starting from partial, the value typed so far
+type CompleterFunc func(ctx context.Context, partial string) ([]string, error)
+
+// RegisterPromptArgumentCompleter registers the completer of the argument of the prompt,
+// answering the completion/complete requests referencing the prompt.
+func (server *Server) RegisterPromptArgumentCompleter(prompt, argument string, completer CompleterFunc) {
+	server.completers.Store(completerKey(protocol.RefPrompt, prompt, argument), completer)
+}
+
+// RegisterResourceTemplateCompleter registers the completer of the variable of the resource template uriTemplate,
+// answering the completion/complete requests referencing the template.
+func (server *Server) RegisterResourceTemplateCompleter(uriTemplate, variable string, completer CompleterFunc) {
+	server.completers.Store(completerKey(protocol.RefResource, uriTemplate, variable), completer)
+}
+
+func completerKey(refType, ref, argument string) string {
+	return refType + "\x00" + ref + "\x00" + argument
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
 
 	"github.com/yosida95/uritemplate/v3"
 
@@ -50,7 +51,7 @@ func (server *Server) handleRequestWithListPrompts(rawParams json.RawMessage) (*
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	var request *protocol.ListPromptsRequest
+	request := &protocol.ListPromptsRequest{}
 	if len(rawParams) > 0 {
 		if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
 			return nil, err
@@ -95,7 +96,7 @@ func (server *Server) handleRequestWithListResources(rawParams json.RawMessage)
 	if server.capabilities.Resources == nil {
 		return nil, pkg.ErrServerNotSupport
 	}
-	var request *protocol.ListResourcesRequest
+	request := &protocol.ListResourcesRequest{}
 	if len(rawParams) > 0 {
 		if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
 			return nil, err
@@ -125,7 +126,7 @@ func (server *Server) handleRequestWithListResourceTemplates(rawParams json.RawM
 		return nil, pkg.ErrServerNotSupport
 	}
 
-	var request *protocol.ListResourceTemplatesRequest
+	request := &protocol.ListResourceTemplatesRequest{}
 	if len(rawParams) > 0 {
 		if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
 			return nil, err
@@ -162,20 +163,14 @@ func (server *Server) handleRequestWithReadResource(ctx context.Context, rawPara
 	var handler ResourceHandlerFunc
 	if entry, ok := server.resources.Load(request.URI); ok {
 		handler = entry.handler
-	}
-
-	server.resourceTemplates.Range(func(_ string, entry *resourceTemplateEntry) bool {
-		if !matchesTemplate(request.URI, entry.resourceTemplate.URITemplateParsed) {
-			return true
-		}
+	} else if entry, ok := server.matchResourceTemplate(request.URI); ok {
 		handler = entry.handler
 		matchedVars := entry.resourceTemplate.URITemplateParsed.Match(request.URI)
 		request.Arguments = make(map[string]interface{})
 		for name, value := range matchedVars {
 			request.Arguments[name] = value.V
 		}
-		return false
-	})
+	}
 
 	if handler == nil {
 		return nil, fmt.Errorf("missing resource, resourceName=%s", request.URI)
@@ -184,7 +179,7 @@ func (server *Server) handleRequestWithReadResource(ctx context.Context, rawPara
 }
 
 func (server *Server) handleRequestWithSubscribeResourceChange(sessionID string, rawParams json.RawMessage) (*protocol.SubscribeResult, error) {
-	if server.capabilities.Resources == nil && !server.capabilities.Resources.Subscribe {
+	if server.capabilities.Resources == nil || !server.capabilities.Resources.Subscribe {
 		return nil, pkg.ErrServerNotSupport
 	}
 
@@ -202,7 +197,7 @@ func (server *Server) handleRequestWithSubscribeResourceChange(sessionID string,
 }
 
 func (server *Server) handleRequestWithUnSubscribeResourceChange(sessionID string, rawParams json.RawMessage) (*protocol.UnsubscribeResult, error) {
-	if server.capabilities.Resources == nil && !server.capabilities.Resources.Subscribe {
+	if server.capabilities.Resources == nil || !server.capabilities.Resources.Subscribe {
 		return nil, pkg.ErrServerNotSupport
 	}
 
@@ -219,6 +214,60 @@ func (server *Server) handleRequestWithUnSubscribeResourceChange(sessionID strin
 	return protocol.NewUnsubscribeResult(), nil
 }
 
+func (server *Server) handleRequestWithComplete(ctx context.Context, rawParams json.RawMessage) (*protocol.CompleteResult, error) {
+	if server.capabilities.Completions == nil {
+		return nil, pkg.ErrServerNotSupport
+	}
+
+	var request *protocol.CompleteRequest
+	if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, protocol.NewInvalidParamsError("completion/complete: params are required")
+	}
+	refData, err := json.Marshal(request.Ref)
+	if err != nil {
+		return nil, err
+	}
+	var ref struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+		URI  string `json:"uri"`
+	}
+	if err = pkg.JSONUnmarshal(refData, &ref); err != nil {
+		return nil, err
+	}
+
+	var key string
+	switch ref.Type {
+	case protocol.RefPrompt:
+		key = completerKey(ref.Type, ref.Name, request.Argument.Name)
+	case protocol.RefResource:
+		key = completerKey(ref.Type, ref.URI, request.Argument.Name)
+	default:
+		return nil, protocol.NewInvalidParamsError(fmt.Sprintf("unknown completion reference type %q", ref.Type))
+	}
+
+	// Arguments without a completer have no suggestions
+	completer, ok := server.completers.Load(key)
+	if !ok {
+		return protocol.NewCompleteResult([]string{}, false, 0), nil
+	}
+	values, err := completer(ctx, request.Argument.Value)
+	if err != nil {
+		return nil, err
+	}
+	total := len(values)
+	if total > protocol.MaxCompletionValues {
+		values = values[:protocol.MaxCompletionValues]
+	}
+	if values == nil {
+		values = []string{}
+	}
+	return protocol.NewCompleteResult(values, total > len(values), total), nil
+}
+
 func (server *Server) handleRequestWithListTools(ctx context.Context, rawParams json.RawMessage) (*protocol.ListToolsResult, error) {
 	if server.capabilities.Tools == nil {
 		return nil, pkg.ErrServerNotSupport
@@ -265,7 +314,43 @@ func (server *Server) handleRequestWithCallTool(ctx context.Context, rawParams j
 		return nil, fmt.Errorf("missing tool, toolName=%s", request.Name)
 	}
 
-	return entry.handler(ctx, request)
+	result, err := entry.handler(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		if err = entry.tool.ValidateStructuredContent(result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (server *Server) handleRequestWithSetLoggingLevel(sessionID string, rawParams json.RawMessage) (*protocol.SetLoggingLevelResult, error) {
+	if server.capabilities.Logging == nil {
+		return nil, pkg.ErrServerNotSupport
+	}
+
+	var request *protocol.SetLoggingLevelRequest
+	if err := pkg.JSONUnmarshal(rawParams, &request); err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, protocol.NewInvalidParamsError("logging/setLevel: params are required")
+	}
+	switch request.Level {
+	case protocol.LogDebug, protocol.LogInfo, protocol.LogNotice, protocol.LogWarning,
+		protocol.LogError, protocol.LogCritical, protocol.LogAlert, protocol.LogEmergency:
+	default:
+		return nil, protocol.NewInvalidParamsError(fmt.Sprintf("logging/setLevel: invalid level %q", request.Level))
+	}
+
+	s, ok := server.sessionManager.GetSession(sessionID)
+	if !ok {
+		return nil, pkg.ErrLackSession
+	}
+	s.SetLoggingLevel(request.Level)
+	return protocol.NewSetLoggingLevelResult(true), nil
 }
 
 func (server *Server) handleNotifyWithInitialized(sessionID string, rawParams json.RawMessage) error {
@@ -315,3 +400,29 @@ func (server *Server) handleNotifyWithCancelled(sessionID string, rawParams json
 func matchesTemplate(uri string, template *uritemplate.Template) bool {
 	return template.Regexp().MatchString(uri)
 }
+
+// matchResourceTemplate returns the most specific of the resource templates matching uri, the one with the most
+// literal characters, then the fewest variables, ties being broken by the lexical order of the templates.
+func (server *Server) matchResourceTemplate(uri string) (*resourceTemplateEntry, bool) {
+	var matched *resourceTemplateEntry
+	server.resourceTemplates.Range(func(_ string, entry *resourceTemplateEntry) bool {
+		if matchesTemplate(uri, entry.resourceTemplate.URITemplateParsed) && (matched == nil || moreSpecificTemplate(entry, matched)) {
+			matched = entry
+		}
+		return true
+	})
+	return matched, matched != nil
+}
+
+var templateExpression = regexp.MustCompile(`\{[^}]*\}`)
+
+func moreSpecificTemplate(a, b *resourceTemplateEntry) bool {
+	rawA, rawB := a.resourceTemplate.URITemplate, b.resourceTemplate.URITemplate
+	if literalA, literalB := len(templateExpression.ReplaceAllString(rawA, "")), len(templateExpression.ReplaceAllString(rawB, "")); literalA != literalB {
+		return literalA > literalB
+	}
+	if varsA, varsB := len(a.resourceTemplate.URITemplateParsed.Varnames()), len(b.resourceTemplate.URITemplateParsed.Varnames()); varsA != varsB {
+		return varsA < varsB
+	}
+	return rawA < rawB
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// ProgressReporter reports the progress of a request whose _meta carries a progressToken,
+// with notifications/progress sent on the session of the request. Handlers get it with GetProgressReporterFromCtx.
+type ProgressReporter struct {
+	server    *Server
+	ctx       context.Context
+	sessionID string
+	token     protocol.ProgressToken
+
+	mu       sync.Mutex
+	done     bool
+	progress float64
+	reported bool
+}
+
+type progressReporterKey struct{}
+
+func setProgressReporterToCtx(ctx context.Context, reporter *ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterKey{}, reporter)
+}
+
+// GetProgressReporterFromCtx returns the ProgressReporter of the request handled with ctx.
+// The reporter of a request without progressToken discards the reports, so handlers can report unconditionally.
+func GetProgressReporterFromCtx(ctx context.Context) *ProgressReporter {
+	if reporter, ok := ctx.Value(progressReporterKey{}).(*ProgressReporter); ok {
+		return reporter
+	}
+	return &ProgressReporter{}
+}
+
+// Enabled reports whether the client asked for the progress of the request
+func (r *ProgressReporter) Enabled() bool {
+	return r.token != nil
+}
+
+// Report sends the progress of the request, which must increase with each report, out of total, 0 if unknown.
+// The reports fail once the request is answered.
+func (r *ProgressReporter) Report(progress, total float64, message string) error {
+	if !r.Enabled() {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done {
+		return errors.New("progress reported after the end of the request")
+	}
+	if r.reported && progress <= r.progress {
+		return fmt.Errorf("progress %v does not increase from %v", progress, r.progress)
+	}
+	notify := protocol.NewProgressNotification(progress, total, message)
+	notify.ProgressToken = r.token
+	if err := r.server.sendMsgWithNotification(r.ctx, r.sessionID, protocol.NotificationProgress, notify); err != nil {
+		return err
+	}
+	r.progress, r.reported = progress, true
+	return nil
+}
+
+// close stops the reports before the response of the request is sent
+func (r *ProgressReporter) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done = true
+}
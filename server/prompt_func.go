@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// PromptMessagesFunc returns the messages of a prompt registered with RegisterPromptFunc from its arguments
+type PromptMessagesFunc func(ctx context.Context, args map[string]string) ([]*protocol.PromptMessage, error)
+
+// RegisterPromptFunc registers the prompt name taking the arguments args, whose messages are returned by handler:
+//
+//	server.RegisterPromptFunc("review", "Review code", []*protocol.PromptArgument{{Name: "code", Required: true}},
+//		func(ctx context.Context, args map[string]string) ([]*protocol.PromptMessage, error) {
+//			return []*protocol.PromptMessage{{Role: protocol.RoleUser, Content: &protocol.TextContent{Type: "text", Text: args["code"]}}}, nil
+//		})
+//
+// A prompts/get lacking a required argument fails with an invalid params error without calling handler.
+func (server *Server) RegisterPromptFunc(name, description string, args []*protocol.PromptArgument, handler PromptMessagesFunc) error {
+	if name == "" {
+		return fmt.Errorf("prompt name is required")
+	}
+	if handler == nil {
+		return fmt.Errorf("prompt %s: handler is required", name)
+	}
+	names := make(map[string]bool, len(args))
+	for _, arg := range args {
+		if arg == nil || arg.Name == "" {
+			return fmt.Errorf("prompt %s: argument name is required", name)
+		}
+		if names[arg.Name] {
+			return fmt.Errorf("prompt %s: duplicate argument %s", name, arg.Name)
+		}
+		names[arg.Name] = true
+	}
+
+	prompt := &protocol.Prompt{Name: name, Description: description, Arguments: args}
+	server.RegisterPrompt(prompt, func(ctx context.Context, req *protocol.GetPromptRequest) (*protocol.GetPromptResult, error) {
+		arguments := req.Arguments
+		if arguments == nil {
+			arguments = map[string]string{}
+		}
+		for _, arg := range args {
+			if _, ok := arguments[arg.Name]; arg.Required && !ok {
+				return nil, protocol.NewInvalidParamsError(fmt.Sprintf("prompt %s: missing required argument %s", name, arg.Name))
+			}
+		}
+
+		messages, err := handler(ctx, arguments)
+		if err != nil {
+			return nil, err
+		}
+		if messages == nil {
+			messages = []*protocol.PromptMessage{}
+		}
+		return protocol.NewGetPromptResult(messages, description), nil
+	})
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/tidwall/gjson"
 
@@ -20,6 +21,125 @@ func (server *Server) receive(ctx context.Context, sessionID string, msg []byte)
 		return nil, pkg.ErrLackSession
 	}
 
+	if protocol.IsBatchMessage(msg) {
+		return server.receiveBatch(ctx, sessionID, msg)
+	}
+	return server.receiveMessage(ctx, sessionID, msg)
+}
+
+// receiveBatch dispatches the messages of a JSON-RPC batch concurrently and replies with a single array
+// holding the responses to its requests, in the order of the requests.
+// Notifications and responses are not replied to, so a batch without requests gets no reply at all.
+// The messages sent to the client while handling the requests, e.g. progress notifications, are not delayed.
+func (server *Server) receiveBatch(ctx context.Context, sessionID string, batch []byte) (<-chan []byte, error) {
+	var msgs []json.RawMessage
+	if err := pkg.JSONUnmarshal(batch, &msgs); err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return replyMessage(protocol.NewJSONRPCErrorResponse(nil, protocol.InvalidRequest, "empty batch"))
+	}
+
+	// replies holds the response to each message of the batch, nil for the messages not replied to
+	replies := make([]json.RawMessage, len(msgs))
+	outputMsgChs := make([]<-chan []byte, len(msgs))
+	expectReply := false
+	for i, msg := range msgs {
+		outputMsgCh, err := server.receiveMessage(ctx, sessionID, msg)
+		if err != nil {
+			// invalid requests are answered with an error, failed notifications and responses are only logged
+			isRequest := gjson.GetBytes(msg, "id").Exists() && gjson.GetBytes(msg, "method").Exists()
+			if isRequest || !gjson.ParseBytes(msg).IsObject() {
+				if replies[i], err = batchErrorReply(msg, err); err != nil {
+					return nil, err
+				}
+				expectReply = true
+				continue
+			}
+			server.logger.Warnf("receive batch message:%s error: %s", msg, err.Error())
+			continue
+		}
+		if outputMsgCh != nil {
+			outputMsgChs[i] = outputMsgCh
+			expectReply = true
+		}
+	}
+	if !expectReply {
+		return nil, nil
+	}
+
+	ch := make(chan []byte, 5)
+	go func() {
+		defer pkg.Recover()
+		defer close(ch)
+
+		var wg sync.WaitGroup
+		for i, outputMsgCh := range outputMsgChs {
+			if outputMsgCh == nil {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, outputMsgCh <-chan []byte) {
+				defer pkg.Recover()
+				defer wg.Done()
+
+				for msg := range outputMsgCh {
+					// the response to the request is part of the batch reply, the requests and notifications to the client are forwarded
+					if !gjson.GetBytes(msg, "method").Exists() {
+						replies[i] = msg
+						continue
+					}
+					ch <- msg
+				}
+			}(i, outputMsgCh)
+		}
+		wg.Wait()
+
+		responses := make([]json.RawMessage, 0, len(replies))
+		for _, reply := range replies {
+			if reply != nil {
+				responses = append(responses, reply)
+			}
+		}
+		if len(responses) == 0 { // all the requests were cancelled
+			return
+		}
+		message, err := json.Marshal(responses)
+		if err != nil {
+			server.logger.Errorf("receive json marshal batch responses error: %s", err.Error())
+			return
+		}
+		ch <- message
+	}()
+	return ch, nil
+}
+
+// batchErrorReply returns the error response to the message of a batch which failed to be received
+func batchErrorReply(msg json.RawMessage, err error) (json.RawMessage, error) {
+	var id protocol.RequestID
+	if r := gjson.GetBytes(msg, "id"); r.Exists() {
+		id = r.Value()
+	}
+	code := protocol.InternalError
+	if errors.Is(err, pkg.ErrRequestInvalid) || errors.Is(err, pkg.ErrJSONUnmarshal) {
+		code = protocol.InvalidRequest
+	}
+	return json.Marshal(protocol.NewJSONRPCErrorResponse(id, code, err.Error()))
+}
+
+// replyMessage returns a channel holding the single message v
+func replyMessage(v any) (<-chan []byte, error) {
+	message, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan []byte, 1)
+	ch <- message
+	close(ch)
+	return ch, nil
+}
+
+func (server *Server) receiveMessage(ctx context.Context, sessionID string, msg []byte) (<-chan []byte, error) {
 	if !gjson.GetBytes(msg, "id").Exists() {
 		notify := &protocol.JSONRPCNotification{}
 		if err := pkg.JSONUnmarshal(msg, &notify); err != nil {
@@ -56,13 +176,13 @@ func (server *Server) receive(ctx context.Context, sessionID string, msg []byte)
 		return nil, pkg.ErrRequestInvalid
 	}
 
-	// if sessionID != "" && req.Method != protocol.Initialize && req.Method != protocol.Ping {
-	// 	if s, ok := server.sessionManager.GetSession(sessionID); !ok {
-	// 		return nil, pkg.ErrLackSession
-	// 	} else if !s.GetReady() {
-	// 		return nil, pkg.ErrSessionHasNotInitialized
-	// 	}
-	// }
+	// until the client has sent notifications/initialized, the session only accepts initialize and ping requests
+	if req.Method != protocol.Initialize && req.Method != protocol.Ping {
+		if s, ok := server.sessionManager.GetSession(sessionID); ok && !s.GetReady() {
+			message := fmt.Sprintf("%s: method=%s", pkg.ErrSessionHasNotInitialized, req.Method)
+			return replyMessage(protocol.NewJSONRPCErrorResponse(req.ID, protocol.InvalidRequest, message))
+		}
+	}
 
 	server.inFlyRequest.Add(1)
 
@@ -77,21 +197,30 @@ func (server *Server) receive(ctx context.Context, sessionID string, msg []byte)
 		defer server.inFlyRequest.Done()
 		defer close(ch)
 
+		// The requests in flight are cancelled by notifications/cancelled, except initialize which can't be cancelled
 		if s, ok := server.sessionManager.GetSession(sessionID); ok && req.Method != protocol.Initialize {
 			var cancel context.CancelFunc
 			ctx, cancel = context.WithCancel(ctx)
+			defer cancel()
 			requestID := fmt.Sprint(req.ID)
 			s.GetClientReqID2cancelFunc().Set(requestID, cancel)
 			defer s.GetClientReqID2cancelFunc().Remove(requestID)
 		}
 
+		var progressToken protocol.ProgressToken
 		if r := gjson.GetBytes(req.RawParams, fmt.Sprintf("_meta.%s", protocol.ProgressTokenKey)); r.Exists() {
-			ctx = setProgressTokenToCtx(ctx, r.Value())
+			progressToken = r.Value()
+			ctx = setProgressTokenToCtx(ctx, progressToken)
 		}
 
 		ctx = setSendChanToCtx(ctx, ch)
 
+		reporter := &ProgressReporter{server: server, ctx: ctx, sessionID: sessionID, token: progressToken}
+		ctx = setProgressReporterToCtx(ctx, reporter)
+
 		resp := server.receiveRequest(ctx, sessionID, req)
+		reporter.close()
+		// cancelled requests are not answered
 		if errors.Is(ctx.Err(), context.Canceled) {
 			return
 		}
@@ -142,11 +271,22 @@ func (server *Server) receiveRequest(ctx context.Context, sessionID string, requ
 		result, err = server.handleRequestWithListTools(ctx, request.RawParams)
 	case protocol.ToolsCall:
 		result, err = server.handleRequestWithCallTool(ctx, request.RawParams)
+	case protocol.CompletionComplete:
+		result, err = server.handleRequestWithComplete(ctx, request.RawParams)
+	case protocol.LoggingSetLevel:
+		result, err = server.handleRequestWithSetLoggingLevel(sessionID, request.RawParams)
 	default:
 		err = fmt.Errorf("%w: method=%s", pkg.ErrMethodNotSupport, request.Method)
 	}
 
 	if err != nil {
+		var rpcErr *protocol.RPCError
+		if errors.As(err, &rpcErr) {
+			resp := protocol.NewJSONRPCErrorResponse(request.ID, rpcErr.Code, rpcErr.Message)
+			resp.Error.Data = rpcErr.Data
+			return resp
+		}
+
 		var code int
 		switch {
 		case errors.Is(err, pkg.ErrMethodNotSupport):
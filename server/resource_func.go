@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// ResourceReaderFunc returns the current content of a resource registered with RegisterResourceFunc
+type ResourceReaderFunc func(ctx context.Context) ([]byte, error)
+
+// RegisterResourceFunc registers the resource uri whose content is returned by reader:
+//
+//	server.RegisterResourceFunc("file:///config.json", "config.json", "application/json", func(ctx context.Context) ([]byte, error) {
+//		return os.ReadFile("config.json")
+//	})
+//
+// The content is read on each resources/read, as a text content for textual MIME types, and as a blob otherwise.
+// When the content changes, NotifyResourceUpdated notifies the sessions subscribed to the resource.
+func (server *Server) RegisterResourceFunc(uri, name, mimeType string, reader ResourceReaderFunc) error {
+	if uri == "" {
+		return fmt.Errorf("resource %s: uri is required", name)
+	}
+	if reader == nil {
+		return fmt.Errorf("resource %s: reader is required", uri)
+	}
+
+	resource := &protocol.Resource{URI: uri, Name: name, MimeType: mimeType}
+	server.RegisterResource(resource, func(ctx context.Context, _ *protocol.ReadResourceRequest) (*protocol.ReadResourceResult, error) {
+		data, err := reader(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("read resource %s: %w", uri, err)
+		}
+		return protocol.NewReadResourceResult([]protocol.ResourceContents{resourceContents(uri, mimeType, data)}), nil
+	})
+	return nil
+}
+
+// ResourceTemplateReaderFunc returns the content of the resource matching a template registered with
+// RegisterResourceTemplateFunc, vars holding the values of the variables of the template
+type ResourceTemplateReaderFunc func(ctx context.Context, vars map[string]string) ([]byte, error)
+
+// RegisterResourceTemplateFunc registers the RFC 6570 URI template uriTemplate, e.g. "file:///{path}",
+// whose resources are read by reader from the values of the variables extracted from the URI:
+//
+//	server.RegisterResourceTemplateFunc("file:///{path}", "files", "text/plain", func(ctx context.Context, vars map[string]string) ([]byte, error) {
+//		return os.ReadFile(vars["path"])
+//	})
+//
+// The values of list variables are joined with commas. Registered resources take precedence over the templates,
+// and a URI matching several templates is read by the most specific one.
+func (server *Server) RegisterResourceTemplateFunc(uriTemplate, name, mimeType string, reader ResourceTemplateReaderFunc) error {
+	if reader == nil {
+		return fmt.Errorf("resource template %s: reader is required", uriTemplate)
+	}
+
+	template := &protocol.ResourceTemplate{URITemplate: uriTemplate, Name: name, MimeType: mimeType}
+	return server.RegisterResourceTemplate(template, func(ctx context.Context, req *protocol.ReadResourceRequest) (*protocol.ReadResourceResult, error) {
+		vars := make(map[string]string, len(req.Arguments))
+		for varName, value := range req.Arguments {
+			switch value := value.(type) {
+			case []string:
+				vars[varName] = strings.Join(value, ",")
+			case string:
+				vars[varName] = value
+			default:
+				vars[varName] = fmt.Sprint(value)
+			}
+		}
+		data, err := reader(ctx, vars)
+		if err != nil {
+			return nil, fmt.Errorf("read resource %s: %w", req.URI, err)
+		}
+		return protocol.NewReadResourceResult([]protocol.ResourceContents{resourceContents(req.URI, mimeType, data)}), nil
+	})
+}
+
+// NotifyResourceUpdated sends notifications/resources/updated to the sessions subscribed to the resource uri
+func (server *Server) NotifyResourceUpdated(ctx context.Context, uri string) error {
+	return server.SendNotification4ResourcesUpdated(ctx, protocol.NewResourceUpdatedNotification(uri))
+}
+
+// resourceContents returns data as a text content if mimeType is textual, or unknown and data is valid UTF-8,
+// otherwise as a blob content
+func resourceContents(uri, mimeType string, data []byte) protocol.ResourceContents {
+	if isTextMimeType(mimeType) || (mimeType == "" && utf8.Valid(data)) {
+		return &protocol.TextResourceContents{URI: uri, MimeType: mimeType, Text: string(data)}
+	}
+	return &protocol.BlobResourceContents{URI: uri, MimeType: mimeType, Blob: data}
+}
+
+func isTextMimeType(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/yaml", "application/x-yaml", "application/toml":
+		return true
+	}
+	return false
+}
@@ -83,6 +83,7 @@ type Server struct {
 	prompts           pkg.SyncMap[*promptEntry]
 	resources         pkg.SyncMap[*resourceEntry]
 	resourceTemplates pkg.SyncMap[*resourceTemplateEntry]
+	completers        pkg.SyncMap[CompleterFunc]
 
 	sessionManager *session.Manager
 
@@ -108,9 +109,11 @@ func NewServer(t transport.ServerTransport, opts ...Option) (*Server, error) {
 	server := &Server{
 		transport: t,
 		capabilities: &protocol.ServerCapabilities{
-			Prompts:   &protocol.PromptsCapability{ListChanged: true},
-			Resources: &protocol.ResourcesCapability{ListChanged: true, Subscribe: true},
-			Tools:     &protocol.ToolsCapability{ListChanged: true},
+			Prompts:     &protocol.PromptsCapability{ListChanged: true},
+			Resources:   &protocol.ResourcesCapability{ListChanged: true, Subscribe: true},
+			Tools:       &protocol.ToolsCapability{ListChanged: true},
+			Completions: &protocol.CompletionsCapability{},
+			Logging:     &protocol.LoggingCapability{},
 		},
 		inShutdown:   pkg.NewAtomicBool(),
 		serverInfo:   &protocol.Implementation{},
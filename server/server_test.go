@@ -5,9 +5,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -709,6 +712,8 @@ func TestServerHandleForPage(t *testing.T) {
 					t.Fatal(err)
 				}
 
+				totalResq = totalResq + len(respStruct.Result.Tools) + len(respStruct.Result.Prompts) +
+					len(respStruct.Result.Resources) + len(respStruct.Result.ResourceTemplates)
 				if respStruct.Result.NextCursor == "" {
 					break
 				}
@@ -723,8 +728,6 @@ func TestServerHandleForPage(t *testing.T) {
 					tt.request = protocol.ListResourcesRequest{Cursor: protocol.Cursor(respStruct.Result.NextCursor)}
 				}
 				req = protocol.NewJSONRPCRequest(uuid, tt.method, tt.request)
-				totalResq = totalResq + len(respStruct.Result.Tools) + len(respStruct.Result.Prompts) +
-					len(respStruct.Result.Resources) + len(respStruct.Result.ResourceTemplates)
 			}
 			if total != totalResq {
 				t.Fatalf("totalResq: %d, total: %d", totalResq, total)
@@ -1003,3 +1006,925 @@ func TestNoMiddleware(t *testing.T) {
 	}
 	s.RegisterTool(testTool, testHandler)
 }
+
+func TestServerBatch(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+	outScan := bufio.NewScanner(reader2)
+
+	server, err := NewServer(transport.NewMockServerTransport(reader1, writer2))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	testServerInit(t, server, writer1, outScan)
+
+	tests := []struct {
+		name  string
+		batch string
+		want  string
+	}{
+		{
+			name: "mixed batch with errors",
+			batch: `[{"jsonrpc":"2.0","id":1,"method":"ping"},` +
+				`{"jsonrpc":"2.0","method":"notifications/initialized"},` +
+				`{"jsonrpc":"2.0","id":"two","method":"unknown/method"},` +
+				`{"jsonrpc":"1.0","id":3,"method":"ping"},` +
+				`42,` +
+				`{"jsonrpc":"2.0","id":4,"method":"tools/list"}]`,
+			want: `[{"jsonrpc":"2.0","id":1,"result":{}},` +
+				`{"jsonrpc":"2.0","id":"two","error":{"code":-32601}},` +
+				`{"jsonrpc":"2.0","id":3,"error":{"code":-32600}},` +
+				`{"jsonrpc":"2.0","id":null,"error":{"code":-32600}},` +
+				`{"jsonrpc":"2.0","id":4,"result":{"tools":[]}}]`,
+		},
+		{
+			name:  "empty batch",
+			batch: `[]`,
+			want:  `{"jsonrpc":"2.0","id":null,"error":{"code":-32600}}`,
+		},
+		{
+			// the notifications are not replied to, the next line is the response to the following ping
+			name:  "notifications only",
+			batch: `[{"jsonrpc":"2.0","method":"notifications/initialized"}]` + "\n" + `{"jsonrpc":"2.0","id":5,"method":"ping"}`,
+			want:  `{"jsonrpc":"2.0","id":5,"result":{}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := writer1.Write([]byte(tt.batch + "\n")); err != nil {
+				t.Fatalf("in Write: %+v", err)
+			}
+			if !outScan.Scan() {
+				t.Fatalf("outScan: %+v", outScan.Err())
+			}
+
+			var got, want any
+			if err := pkg.JSONUnmarshal(outScan.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := pkg.JSONUnmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			// the error messages are not compared
+			stripErrorMessages(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("reply = %s, want %s", outScan.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+// warnLogger records the warnings logged by the server
+type warnLogger struct {
+	pkg.Logger
+	mu       sync.Mutex
+	warnings []string
+}
+
+func (l *warnLogger) Warnf(format string, a ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.warnings = append(l.warnings, fmt.Sprintf(format, a...))
+}
+
+func (l *warnLogger) Warnings() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.warnings...)
+}
+
+func TestServerBatchLogsFailedNotifications(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+	outScan := bufio.NewScanner(reader2)
+
+	logger := &warnLogger{Logger: pkg.DefaultLogger}
+	server, err := NewServer(transport.NewMockServerTransport(reader1, writer2), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	testServerInit(t, server, writer1, outScan)
+
+	batch := `[{"jsonrpc":"2.0","method":"notifications/unknown"},{"jsonrpc":"2.0","id":1,"method":"ping"}]`
+	if _, err = writer1.Write([]byte(batch + "\n")); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	if want := `[{"jsonrpc":"2.0","id":1,"result":{}}]`; outScan.Text() != want {
+		t.Errorf("reply = %s, want %s", outScan.Text(), want)
+	}
+
+	warnings := logger.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "notifications/unknown") {
+		t.Errorf("warnings = %q, want the failed notification", warnings)
+	}
+}
+
+func stripErrorMessages(v any) {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			stripErrorMessages(e)
+		}
+	case map[string]any:
+		if e, ok := v["error"].(map[string]any); ok {
+			delete(e, "message")
+		}
+	}
+}
+
+func TestServerRPCError(t *testing.T) {
+	reader1, writer1 := io.Pipe()
+	reader2, writer2 := io.Pipe()
+	outScan := bufio.NewScanner(reader2)
+
+	server, err := NewServer(transport.NewMockServerTransport(reader1, writer2))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	tool, err := protocol.NewTool("weather", "weather", currentTimeReq{})
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+	}
+	server.RegisterTool(tool, func(context.Context, *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		rpcErr := protocol.NewInvalidParamsError("unknown timezone")
+		rpcErr.Data = map[string]interface{}{"path": "/timezone"}
+		return nil, fmt.Errorf("weather: %w", rpcErr)
+	})
+	go func() {
+		if err := server.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	testServerInit(t, server, writer1, outScan)
+
+	req := protocol.NewJSONRPCRequest(1, protocol.ToolsCall, protocol.NewCallToolRequest("weather", map[string]interface{}{"timezone": "Mars"}))
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = writer1.Write(append(reqBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+
+	resp := &protocol.JSONRPCResponse{}
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), resp); err != nil {
+		t.Fatal(err)
+	}
+	want := protocol.NewRPCError(protocol.InvalidParams, "unknown timezone", map[string]interface{}{"path": "/timezone"})
+	if !reflect.DeepEqual(resp.Error, want) {
+		t.Errorf("response error = %+v, want %+v", resp.Error, want)
+	}
+}
+
+type weatherReq struct {
+	City  string `json:"city" description:"city name"`
+	Units string `json:"units,omitempty" enum:"metric,imperial"`
+}
+
+type weatherResult struct {
+	City        string  `json:"city"`
+	Temperature float64 `json:"temperature"`
+}
+
+func TestServerRegisterToolFunc(t *testing.T) {
+	s, err := NewServer(transport.NewMockServerTransport(io.NopCloser(bytes.NewReader(nil)), io.Discard))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	if err = s.RegisterToolFunc("weather", "Get the weather", func(_ context.Context, req weatherReq) (weatherResult, error) {
+		return weatherResult{City: req.City, Temperature: 21.5}, nil
+	}); err != nil {
+		t.Fatalf("RegisterToolFunc: %+v", err)
+	}
+	if err = s.RegisterToolFunc("greet", "Greet", func(_ context.Context, req *weatherReq) (string, error) {
+		if req.City == "" {
+			return "", fmt.Errorf("no city")
+		}
+		return "hello " + req.City, nil
+	}); err != nil {
+		t.Fatalf("RegisterToolFunc: %+v", err)
+	}
+
+	entry, ok := s.tools.Load("weather")
+	if !ok {
+		t.Fatal("tool weather is not registered")
+	}
+	if got := entry.tool.InputSchema.Required; !reflect.DeepEqual(got, []string{"city"}) {
+		t.Errorf("weather required = %v, want [city]", got)
+	}
+	if got := entry.tool.InputSchema.Properties["units"].Enum; !reflect.DeepEqual(got, []any{"metric", "imperial"}) {
+		t.Errorf("weather units enum = %v, want [metric imperial]", got)
+	}
+	if got := entry.tool.OutputSchema.Required; !reflect.DeepEqual(got, []string{"city", "temperature"}) {
+		t.Errorf("weather output required = %v, want [city temperature]", got)
+	}
+
+	result, err := entry.handler(context.Background(), protocol.NewCallToolRequestWithRawArguments("weather", json.RawMessage(`{"city":"Paris"}`)))
+	if err != nil {
+		t.Fatalf("call weather: %+v", err)
+	}
+	want := protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: `{"city":"Paris","temperature":21.5}`}}, false)
+	want.StructuredContent = weatherResult{City: "Paris", Temperature: 21.5}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("call weather = %+v, want %+v", result, want)
+	}
+
+	var rpcErr *protocol.RPCError
+	if _, err = entry.handler(context.Background(), protocol.NewCallToolRequest("weather", map[string]interface{}{"units": "kelvin"})); !errors.As(err, &rpcErr) ||
+		rpcErr.Code != protocol.InvalidParams {
+		t.Errorf("call weather with invalid arguments error = %v, want an InvalidParams error", err)
+	}
+
+	greet, _ := s.tools.Load("greet")
+	result, err = greet.handler(context.Background(), protocol.NewCallToolRequest("greet", map[string]interface{}{"city": "Oslo"}))
+	if err != nil {
+		t.Fatalf("call greet: %+v", err)
+	}
+	if text := result.Content[0].(*protocol.TextContent).Text; text != "hello Oslo" {
+		t.Errorf("call greet = %q, want %q", text, "hello Oslo")
+	}
+
+	invalid := map[string]any{
+		"not a func":          "handler",
+		"missing context":     func(weatherReq) (string, error) { return "", nil },
+		"non struct argument": func(context.Context, string) (string, error) { return "", nil },
+		"no error":            func(context.Context, weatherReq) (string, string) { return "", "" },
+		"single return":       func(context.Context, weatherReq) error { return nil },
+	}
+	for name, handler := range invalid {
+		if err := s.RegisterToolFunc("invalid", "", handler); err == nil {
+			t.Errorf("RegisterToolFunc() with %s error = nil, wantErr", name)
+		}
+	}
+	if _, ok := s.tools.Load("invalid"); ok {
+		t.Errorf("RegisterToolFunc() registered a tool with an invalid handler")
+	}
+}
+
+func TestServerToolOutputSchema(t *testing.T) {
+	s, err := NewServer(transport.NewMockServerTransport(io.NopCloser(bytes.NewReader(nil)), io.Discard))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	tool, err := protocol.NewToolWithOutputSchema("weather", "Get the weather", weatherReq{}, weatherResult{})
+	if err != nil {
+		t.Fatalf("NewToolWithOutputSchema: %+v", err)
+	}
+	data, err := json.Marshal(tool)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if !bytes.Contains(data, []byte(`"outputSchema":{"type":"object","properties":{"city":{"type":"string"},"temperature":{"type":"number"}}`)) {
+		t.Errorf("tool JSON %s does not contain the output schema", data)
+	}
+
+	var structured interface{}
+	s.RegisterTool(tool, func(context.Context, *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		result := protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "sunny"}}, false)
+		result.StructuredContent = structured
+		return result, nil
+	})
+
+	call := func() error {
+		params, err := json.Marshal(protocol.NewCallToolRequest("weather", map[string]interface{}{"city": "Paris"}))
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		_, err = s.handleRequestWithCallTool(context.Background(), params)
+		return err
+	}
+
+	structured = map[string]interface{}{"city": "Paris", "temperature": 21.5}
+	if err = call(); err != nil {
+		t.Errorf("call with a valid structured content error = %v", err)
+	}
+	structured = weatherResult{City: "Paris"}
+	if err = call(); err != nil {
+		t.Errorf("call with a valid structured struct error = %v", err)
+	}
+	structured = map[string]interface{}{"city": "Paris", "temperature": "warm"}
+	if err = call(); err == nil {
+		t.Errorf("call with an invalid structured content error = nil, wantErr")
+	}
+	structured = nil
+	if err = call(); err == nil {
+		t.Errorf("call without structured content error = nil, wantErr")
+	}
+}
+
+func TestServerRegisterResourceFunc(t *testing.T) {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	outScan := bufio.NewScanner(outReader)
+
+	s, err := NewServer(transport.NewMockServerTransport(inReader, outWriter))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	config := []byte(`{"debug":true}`)
+	if err = s.RegisterResourceFunc("file:///config.json", "config.json", "application/json", func(context.Context) ([]byte, error) {
+		return config, nil
+	}); err != nil {
+		t.Fatalf("RegisterResourceFunc: %+v", err)
+	}
+	if err = s.RegisterResourceFunc("file:///logo.png", "logo.png", "image/png", func(context.Context) ([]byte, error) {
+		return []byte{0x89, 'P', 'N', 'G'}, nil
+	}); err != nil {
+		t.Fatalf("RegisterResourceFunc: %+v", err)
+	}
+	if err = s.RegisterResourceFunc("file:///nil.txt", "nil.txt", "text/plain", nil); err == nil {
+		t.Errorf("RegisterResourceFunc() without reader error = nil, wantErr")
+	}
+
+	read := func(uri string) protocol.ResourceContents {
+		params, err := json.Marshal(protocol.NewReadResourceRequest(uri))
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		result, err := s.handleRequestWithReadResource(context.Background(), params)
+		if err != nil {
+			t.Fatalf("read %s: %+v", uri, err)
+		}
+		return result.Contents[0]
+	}
+	if got, want := read("file:///config.json"), (&protocol.TextResourceContents{
+		URI: "file:///config.json", MimeType: "application/json", Text: `{"debug":true}`,
+	}); !reflect.DeepEqual(got, want) {
+		t.Errorf("read config.json = %+v, want %+v", got, want)
+	}
+	if got, want := read("file:///logo.png"), (&protocol.BlobResourceContents{
+		URI: "file:///logo.png", MimeType: "image/png", Blob: []byte{0x89, 'P', 'N', 'G'},
+	}); !reflect.DeepEqual(got, want) {
+		t.Errorf("read logo.png = %+v, want %+v", got, want)
+	}
+
+	go func() {
+		if err := s.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+	testServerInit(t, s, inWriter, outScan)
+
+	req := protocol.NewJSONRPCRequest("subscribe", protocol.ResourcesSubscribe, protocol.NewSubscribeRequest("file:///config.json"))
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	if _, err = inWriter.Write(append(reqBytes, "\n"...)); err != nil {
+		t.Fatalf("in Write: %+v", err)
+	}
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+
+	go func() {
+		if err := s.NotifyResourceUpdated(context.Background(), "file:///logo.png"); err != nil {
+			t.Errorf("NotifyResourceUpdated: %+v", err)
+		}
+		if err := s.NotifyResourceUpdated(context.Background(), "file:///config.json"); err != nil {
+			t.Errorf("NotifyResourceUpdated: %+v", err)
+		}
+	}()
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	var notify protocol.JSONRPCNotification
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), &notify); err != nil {
+		t.Fatal(err)
+	}
+	if notify.Method != protocol.NotificationResourcesUpdated {
+		t.Fatalf("notification method = %s, want %s", notify.Method, protocol.NotificationResourcesUpdated)
+	}
+	var updated protocol.ResourceUpdatedNotification
+	if err = pkg.JSONUnmarshal(notify.RawParams, &updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.URI != "file:///config.json" {
+		t.Errorf("updated uri = %s, want file:///config.json, the only subscribed resource", updated.URI)
+	}
+}
+
+func TestServerRegisterResourceTemplateFunc(t *testing.T) {
+	s, err := NewServer(transport.NewMockServerTransport(io.NopCloser(bytes.NewReader(nil)), io.Discard))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	templateReader := func(prefix string) ResourceTemplateReaderFunc {
+		return func(_ context.Context, vars map[string]string) ([]byte, error) {
+			return []byte(prefix + fmt.Sprint(vars)), nil
+		}
+	}
+	for uriTemplate, prefix := range map[string]string{
+		"file:///{path}":           "file ",
+		"file:///docs/{name}":      "doc ",
+		"file:///{dir}/{name}":     "dir ",
+		"users://{id}/posts{?ids}": "posts ",
+	} {
+		if err = s.RegisterResourceTemplateFunc(uriTemplate, uriTemplate, "text/plain", templateReader(prefix)); err != nil {
+			t.Fatalf("RegisterResourceTemplateFunc(%s): %+v", uriTemplate, err)
+		}
+	}
+	if err = s.RegisterResourceFunc("file:///docs/index", "index", "text/plain", func(context.Context) ([]byte, error) {
+		return []byte("index"), nil
+	}); err != nil {
+		t.Fatalf("RegisterResourceFunc: %+v", err)
+	}
+	if err = s.RegisterResourceTemplateFunc("file:///{", "invalid", "text/plain", templateReader("")); err == nil {
+		t.Errorf("RegisterResourceTemplateFunc() with an invalid template error = nil, wantErr")
+	}
+
+	params, err := json.Marshal(protocol.NewListResourceTemplatesRequest())
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	templates, err := s.handleRequestWithListResourceTemplates(params)
+	if err != nil {
+		t.Fatalf("list resource templates: %+v", err)
+	}
+	if len(templates.ResourceTemplates) != 4 {
+		t.Errorf("listed %d resource templates, want 4", len(templates.ResourceTemplates))
+	}
+
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "file:///docs/readme", want: "doc map[name:readme]"},
+		{uri: "file:///docs/index", want: "index"},
+		{uri: "file:///src/main.go", want: "dir map[dir:src name:main.go]"},
+		{uri: "file:///notes", want: "file map[path:notes]"},
+		{uri: "users://42/posts?ids=1,2", want: "posts map[id:42 ids:1,2]"},
+	}
+	for _, tt := range tests {
+		// the matching must not depend on the iteration order of the templates
+		for i := 0; i < 10; i++ {
+			params, err := json.Marshal(protocol.NewReadResourceRequest(tt.uri))
+			if err != nil {
+				t.Fatalf("json Marshal: %+v", err)
+			}
+			result, err := s.handleRequestWithReadResource(context.Background(), params)
+			if err != nil {
+				t.Fatalf("read %s: %+v", tt.uri, err)
+			}
+			want := &protocol.TextResourceContents{URI: tt.uri, MimeType: "text/plain", Text: tt.want}
+			if got := result.Contents[0]; !reflect.DeepEqual(got, want) {
+				t.Fatalf("read %s = %+v, want %+v", tt.uri, got, want)
+			}
+		}
+	}
+}
+
+func TestServerRegisterPromptFunc(t *testing.T) {
+	s, err := NewServer(transport.NewMockServerTransport(io.NopCloser(bytes.NewReader(nil)), io.Discard))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	args := []*protocol.PromptArgument{
+		{Name: "code", Description: "the code to review", Required: true},
+		{Name: "language", Description: "the language of the code"},
+	}
+	if err = s.RegisterPromptFunc("review", "Review code", args, func(_ context.Context, args map[string]string) ([]*protocol.PromptMessage, error) {
+		return []*protocol.PromptMessage{
+			{Role: protocol.RoleUser, Content: &protocol.TextContent{Type: "text", Text: "Review this " + args["language"] + " code: " + args["code"]}},
+			{Role: protocol.RoleAssistant, Content: &protocol.TextContent{Type: "text", Text: "Sure."}},
+		}, nil
+	}); err != nil {
+		t.Fatalf("RegisterPromptFunc: %+v", err)
+	}
+
+	listParams, err := json.Marshal(protocol.NewListPromptsRequest())
+	if err != nil {
+		t.Fatalf("json Marshal: %+v", err)
+	}
+	list, err := s.handleRequestWithListPrompts(listParams)
+	if err != nil {
+		t.Fatalf("list prompts: %+v", err)
+	}
+	wantPrompts := []*protocol.Prompt{{Name: "review", Description: "Review code", Arguments: args}}
+	if !reflect.DeepEqual(list.Prompts, wantPrompts) {
+		t.Errorf("list prompts = %+v, want %+v", list.Prompts, wantPrompts)
+	}
+
+	get := func(arguments map[string]string) (*protocol.GetPromptResult, error) {
+		params, err := json.Marshal(protocol.NewGetPromptRequest("review", arguments))
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		return s.handleRequestWithGetPrompt(context.Background(), params)
+	}
+	result, err := get(map[string]string{"code": "x := 1", "language": "Go"})
+	if err != nil {
+		t.Fatalf("get prompt: %+v", err)
+	}
+	want := protocol.NewGetPromptResult([]*protocol.PromptMessage{
+		{Role: protocol.RoleUser, Content: &protocol.TextContent{Type: "text", Text: "Review this Go code: x := 1"}},
+		{Role: protocol.RoleAssistant, Content: &protocol.TextContent{Type: "text", Text: "Sure."}},
+	}, "Review code")
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("get prompt = %+v, want %+v", result, want)
+	}
+
+	var rpcErr *protocol.RPCError
+	if _, err = get(map[string]string{"language": "Go"}); !errors.As(err, &rpcErr) || rpcErr.Code != protocol.InvalidParams {
+		t.Errorf("get prompt without the required argument error = %v, want an InvalidParams error", err)
+	}
+
+	noMessages := func(context.Context, map[string]string) ([]*protocol.PromptMessage, error) {
+		return nil, nil
+	}
+	if err = s.RegisterPromptFunc("duplicate", "", []*protocol.PromptArgument{{Name: "a"}, {Name: "a"}}, noMessages); err == nil {
+		t.Errorf("RegisterPromptFunc() with duplicate arguments error = nil, wantErr")
+	}
+	if err = s.RegisterPromptFunc("nil", "", nil, nil); err == nil {
+		t.Errorf("RegisterPromptFunc() without handler error = nil, wantErr")
+	}
+}
+
+func TestServerComplete(t *testing.T) {
+	s, err := NewServer(transport.NewMockServerTransport(io.NopCloser(bytes.NewReader(nil)), io.Discard))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	languages := []string{"go", "python", "rust", "ruby"}
+	s.RegisterPromptArgumentCompleter("review", "language", func(_ context.Context, partial string) ([]string, error) {
+		values := make([]string, 0)
+		for _, language := range languages {
+			if strings.HasPrefix(language, partial) {
+				values = append(values, language)
+			}
+		}
+		return values, nil
+	})
+	s.RegisterResourceTemplateCompleter("file:///{path}", "path", func(_ context.Context, partial string) ([]string, error) {
+		values := make([]string, 0, 150)
+		for i := 0; i < 150; i++ {
+			values = append(values, fmt.Sprintf("%s%d.txt", partial, i))
+		}
+		return values, nil
+	})
+	s.RegisterPromptArgumentCompleter("review", "failing", func(context.Context, string) ([]string, error) {
+		return nil, fmt.Errorf("completion failed")
+	})
+
+	complete := func(argument, value string, ref interface{}) (*protocol.CompleteResult, error) {
+		params, err := json.Marshal(protocol.NewCompleteRequest(argument, value, ref))
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		return s.handleRequestWithComplete(context.Background(), params)
+	}
+
+	result, err := complete("language", "ru", protocol.PromptReference{Type: protocol.RefPrompt, Name: "review"})
+	if err != nil {
+		t.Fatalf("complete prompt argument: %+v", err)
+	}
+	if want := protocol.NewCompleteResult([]string{"rust", "ruby"}, false, 2); !reflect.DeepEqual(result, want) {
+		t.Errorf("complete prompt argument = %+v, want %+v", result.Completion, want.Completion)
+	}
+
+	result, err = complete("path", "log", protocol.ResourceReference{Type: protocol.RefResource, URI: "file:///{path}"})
+	if err != nil {
+		t.Fatalf("complete resource template variable: %+v", err)
+	}
+	if c := result.Completion; len(c.Values) != protocol.MaxCompletionValues || !c.HasMore || c.Total != 150 || c.Values[0] != "log0.txt" {
+		t.Errorf("complete resource template variable = %d values, hasMore %v, total %d", len(c.Values), c.HasMore, c.Total)
+	}
+
+	result, err = complete("unknown", "x", protocol.PromptReference{Type: protocol.RefPrompt, Name: "review"})
+	if err != nil {
+		t.Fatalf("complete argument without completer: %+v", err)
+	}
+	if want := protocol.NewCompleteResult([]string{}, false, 0); !reflect.DeepEqual(result, want) {
+		t.Errorf("complete argument without completer = %+v, want %+v", result.Completion, want.Completion)
+	}
+
+	if _, err = complete("failing", "", protocol.PromptReference{Type: protocol.RefPrompt, Name: "review"}); err == nil {
+		t.Errorf("complete with a failing completer error = nil, wantErr")
+	}
+	var rpcErr *protocol.RPCError
+	if _, err = complete("language", "", protocol.PromptReference{Type: "ref/unknown", Name: "review"}); !errors.As(err, &rpcErr) ||
+		rpcErr.Code != protocol.InvalidParams {
+		t.Errorf("complete with an unknown reference error = %v, want an InvalidParams error", err)
+	}
+	if _, err = s.handleRequestWithComplete(context.Background(), json.RawMessage(`null`)); !errors.As(err, &rpcErr) ||
+		rpcErr.Code != protocol.InvalidParams {
+		t.Errorf("complete with null params error = %v, want an InvalidParams error", err)
+	}
+}
+
+func TestServerProgressReporter(t *testing.T) {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	outScan := bufio.NewScanner(outReader)
+
+	s, err := NewServer(transport.NewMockServerTransport(inReader, outWriter))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	reporters := make(chan *ProgressReporter, 2)
+	tool, err := protocol.NewTool("slow", "slow", struct{}{})
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+	}
+	s.RegisterTool(tool, func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		reporter := GetProgressReporterFromCtx(ctx)
+		reporters <- reporter
+		if err := reporter.Report(1, 2, "halfway"); err != nil {
+			return nil, err
+		}
+		if err := reporter.Report(1, 2, "again"); err == nil && reporter.Enabled() {
+			return nil, fmt.Errorf("report without progress error = nil, wantErr")
+		}
+		if err := reporter.Report(2, 2, "done"); err != nil {
+			return nil, err
+		}
+		return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "ok"}}, false), nil
+	})
+
+	go func() {
+		if err := s.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+	testServerInit(t, s, inWriter, outScan)
+
+	call := func(id string, meta map[string]interface{}) {
+		request := protocol.NewCallToolRequest("slow", map[string]interface{}{})
+		request.Meta = meta
+		reqBytes, err := json.Marshal(protocol.NewJSONRPCRequest(id, protocol.ToolsCall, request))
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		if _, err = inWriter.Write(append(reqBytes, "\n"...)); err != nil {
+			t.Fatalf("in Write: %+v", err)
+		}
+	}
+	next := func() map[string]interface{} {
+		if !outScan.Scan() {
+			t.Fatalf("outScan: %+v", outScan.Err())
+		}
+		var msg map[string]interface{}
+		if err := pkg.JSONUnmarshal(outScan.Bytes(), &msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	call("with-token", map[string]interface{}{protocol.ProgressTokenKey: "tok"})
+	for _, want := range []map[string]interface{}{
+		{"progressToken": "tok", "progress": float64(1), "total": float64(2), "message": "halfway"},
+		{"progressToken": "tok", "progress": float64(2), "total": float64(2), "message": "done"},
+	} {
+		msg := next()
+		if msg["method"] != string(protocol.NotificationProgress) || !reflect.DeepEqual(msg["params"], want) {
+			t.Errorf("notification = %v, want the progress %v", msg, want)
+		}
+	}
+	if msg := next(); msg["id"] != "with-token" || msg["error"] != nil {
+		t.Errorf("response = %v, want the result of with-token", msg)
+	}
+	if err = (<-reporters).Report(3, 2, "late"); err == nil {
+		t.Errorf("Report() after the response error = nil, wantErr")
+	}
+
+	call("without-token", nil)
+	if msg := next(); msg["id"] != "without-token" || msg["error"] != nil {
+		t.Errorf("response = %v, want the result of without-token without notifications", msg)
+	}
+	if reporter := <-reporters; reporter.Enabled() {
+		t.Errorf("Enabled() without progressToken = true, want false")
+	}
+}
+
+func TestServerCancellation(t *testing.T) {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	outScan := bufio.NewScanner(outReader)
+
+	s, err := NewServer(transport.NewMockServerTransport(inReader, outWriter))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	tool, err := protocol.NewTool("slow", "slow", struct{}{})
+	if err != nil {
+		t.Fatalf("NewTool: %+v", err)
+	}
+	s.RegisterTool(tool, func(ctx context.Context, _ *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			cancelled <- ctx.Err()
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			cancelled <- nil
+			return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: "ok"}}, false), nil
+		}
+	})
+
+	go func() {
+		if err := s.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+	testServerInit(t, s, inWriter, outScan)
+
+	write := func(msg interface{}) {
+		msgBytes, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		if _, err = inWriter.Write(append(msgBytes, "\n"...)); err != nil {
+			t.Fatalf("in Write: %+v", err)
+		}
+	}
+
+	write(protocol.NewJSONRPCRequest("slow", protocol.ToolsCall, protocol.NewCallToolRequest("slow", map[string]interface{}{})))
+	<-started
+	write(protocol.NewJSONRPCNotification(protocol.NotificationCancelled, protocol.NewCancelledNotification("slow", "no longer needed")))
+	if err = <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("handler ctx error = %v, want %v", err, context.Canceled)
+	}
+
+	// the cancelled request isn't answered, so the next message is the response to the ping
+	write(protocol.NewJSONRPCRequest("ping", protocol.Ping, protocol.NewPingRequest()))
+	if !outScan.Scan() {
+		t.Fatalf("outScan: %+v", outScan.Err())
+	}
+	var msg map[string]interface{}
+	if err = pkg.JSONUnmarshal(outScan.Bytes(), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg["id"] != "ping" {
+		t.Errorf("response = %v, want the response to ping", msg)
+	}
+}
+
+func TestServerInitializeHandshake(t *testing.T) {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	outScan := bufio.NewScanner(outReader)
+
+	s, err := NewServer(transport.NewMockServerTransport(inReader, outWriter),
+		WithServerInfo(protocol.Implementation{Name: "test", Version: "1.0.0"}))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	go func() {
+		if err := s.Run(); err != nil {
+			t.Errorf("server start: %+v", err)
+		}
+	}()
+
+	write := func(msg interface{}) {
+		msgBytes, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		if _, err = inWriter.Write(append(msgBytes, "\n"...)); err != nil {
+			t.Fatalf("in Write: %+v", err)
+		}
+	}
+	next := func() *protocol.JSONRPCResponse {
+		if !outScan.Scan() {
+			t.Fatalf("outScan: %+v", outScan.Err())
+		}
+		resp := &protocol.JSONRPCResponse{}
+		if err := pkg.JSONUnmarshal(outScan.Bytes(), resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	listTools := func(id string) *protocol.JSONRPCResponse {
+		write(protocol.NewJSONRPCRequest(id, protocol.ToolsList, protocol.NewListToolsRequest()))
+		return next()
+	}
+
+	if resp := listTools("before-initialize"); resp.Error == nil || resp.Error.Code != protocol.InvalidRequest {
+		t.Errorf("tools/list before initialize = %+v, want an invalid request error", resp)
+	}
+
+	write(protocol.NewJSONRPCRequest("initialize", protocol.Initialize, &protocol.InitializeRequest{
+		ClientInfo:      &protocol.Implementation{Name: "client", Version: "0.1.0"},
+		Capabilities:    &protocol.ClientCapabilities{Roots: &protocol.RootsCapability{ListChanged: true}},
+		ProtocolVersion: "1999-01-01",
+	}))
+	resp := next()
+	if resp.Error != nil {
+		t.Fatalf("initialize error = %+v", resp.Error)
+	}
+	var result protocol.InitializeResult
+	if err = pkg.JSONUnmarshal(resp.RawResult, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.ProtocolVersion != protocol.Version {
+		t.Errorf("negotiated version = %s, want the latest version %s", result.ProtocolVersion, protocol.Version)
+	}
+	if !reflect.DeepEqual(result.ServerInfo, s.serverInfo) || !reflect.DeepEqual(result.Capabilities, s.capabilities) {
+		t.Errorf("initialize result = %+v, want the server info and capabilities", result)
+	}
+
+	if resp := listTools("before-initialized"); resp.Error == nil || resp.Error.Code != protocol.InvalidRequest {
+		t.Errorf("tools/list before notifications/initialized = %+v, want an invalid request error", resp)
+	}
+	write(protocol.NewJSONRPCRequest("ping", protocol.Ping, protocol.NewPingRequest()))
+	if resp := next(); resp.Error != nil {
+		t.Errorf("ping before notifications/initialized error = %+v, want nil", resp.Error)
+	}
+
+	write(protocol.NewJSONRPCNotification(protocol.NotificationInitialized, protocol.NewInitializedNotification()))
+	if resp := listTools("after-initialized"); resp.Error != nil {
+		t.Errorf("tools/list after notifications/initialized error = %+v, want nil", resp.Error)
+	}
+}
+
+func TestServerInitializeNegotiatesVersion(t *testing.T) {
+	s, err := NewServer(transport.NewMockServerTransport(io.NopCloser(bytes.NewReader(nil)), io.Discard))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "2024-11-05", want: "2024-11-05"},
+		{version: protocol.Version, want: protocol.Version},
+		{version: "2099-01-01", want: protocol.Version},
+		{version: "", want: protocol.Version},
+	}
+	for _, tt := range tests {
+		rawParams, err := json.Marshal(&protocol.InitializeRequest{ProtocolVersion: tt.version})
+		if err != nil {
+			t.Fatalf("json Marshal: %+v", err)
+		}
+		result, err := s.handleRequestWithInitialize(context.Background(), "", rawParams)
+		if err != nil {
+			t.Fatalf("handleRequestWithInitialize(%q) error = %+v", tt.version, err)
+		}
+		if result.ProtocolVersion != tt.want {
+			t.Errorf("handleRequestWithInitialize(%q) version = %s, want %s", tt.version, result.ProtocolVersion, tt.want)
+		}
+	}
+}
+
+func TestServerSetLoggingLevel(t *testing.T) {
+	s, err := NewServer(transport.NewMockServerTransport(io.NopCloser(bytes.NewReader(nil)), io.Discard))
+	if err != nil {
+		t.Fatalf("NewServer: %+v", err)
+	}
+	sessionID := s.sessionManager.CreateSession(context.Background())
+	state, _ := s.sessionManager.GetSession(sessionID)
+
+	if _, err = s.handleRequestWithSetLoggingLevel(sessionID, json.RawMessage(`{"level":"warning"}`)); err != nil {
+		t.Fatalf("handleRequestWithSetLoggingLevel() error = %+v", err)
+	}
+	if level := state.GetLoggingLevel(); level != protocol.LogWarning {
+		t.Errorf("GetLoggingLevel() = %q, want %q", level, protocol.LogWarning)
+	}
+
+	for _, rawParams := range []string{`{"level":"verbose"}`, `null`} {
+		var rpcErr *protocol.RPCError
+		if _, err = s.handleRequestWithSetLoggingLevel(sessionID, json.RawMessage(rawParams)); !errors.As(err, &rpcErr) ||
+			rpcErr.Code != protocol.InvalidParams {
+			t.Errorf("handleRequestWithSetLoggingLevel(%s) error = %v, want an invalid params error", rawParams, err)
+		}
+	}
+	if level := state.GetLoggingLevel(); level != protocol.LogWarning {
+		t.Errorf("GetLoggingLevel() after invalid requests = %q, want %q", level, protocol.LogWarning)
+	}
+
+	s.capabilities.Logging = nil
+	if _, err = s.handleRequestWithSetLoggingLevel(sessionID, json.RawMessage(`{"level":"debug"}`)); !errors.Is(err, pkg.ErrServerNotSupport) {
+		t.Errorf("handleRequestWithSetLoggingLevel() without the capability error = %v, want %v", err, pkg.ErrServerNotSupport)
+	}
+}
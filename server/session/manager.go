@@ -2,6 +2,7 @@ package session
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
@@ -19,6 +20,10 @@ type Manager struct {
 
 	detection   func(ctx context.Context, sessionID string) error
 	maxIdleTime time.Duration
+
+	// closeHooksMu guards closeHooks, the functions called with the ID of each closed session
+	closeHooksMu sync.RWMutex
+	closeHooks   []func(sessionID string)
 }
 
 func NewManager(detection func(ctx context.Context, sessionID string) error, genSessionID func(ctx context.Context) string) *Manager {
@@ -38,6 +43,15 @@ func (m *Manager) SetLogger(logger pkg.Logger) {
 	m.logger = logger
 }
 
+// OnSessionClose registers hook to be called with the ID of each session once it is closed,
+// whether by the client, on expiry or on shutdown, e.g. to release the resources a transport holds for the session.
+func (m *Manager) OnSessionClose(hook func(sessionID string)) {
+	m.closeHooksMu.Lock()
+	defer m.closeHooksMu.Unlock()
+
+	m.closeHooks = append(m.closeHooks, hook)
+}
+
 func (m *Manager) CreateSession(ctx context.Context) string {
 	sessionID := m.genSessionID(ctx)
 	state := NewState()
@@ -106,6 +120,12 @@ func (m *Manager) CloseSession(sessionID string) {
 	}
 	state.Close()
 	m.closedSessions.Store(sessionID, struct{}{})
+
+	m.closeHooksMu.RLock()
+	defer m.closeHooksMu.RUnlock()
+	for _, hook := range m.closeHooks {
+		hook(sessionID)
+	}
 }
 
 func (m *Manager) CloseAllSessions() {
@@ -127,7 +147,7 @@ func (m *Manager) StartHeartbeatAndCleanInvalidSessions() {
 		case <-ticker.C:
 			now := time.Now()
 			m.activeSessions.Range(func(sessionID string, state *State) bool {
-				if m.maxIdleTime != 0 && now.Sub(state.lastActiveAt) > m.maxIdleTime {
+				if m.maxIdleTime != 0 && now.Sub(state.getLastActiveAt()) > m.maxIdleTime {
 					m.logger.Infof("session expire, session id: %v", sessionID)
 					m.CloseSession(sessionID)
 					return true
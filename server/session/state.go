@@ -16,7 +16,8 @@ import (
 var ErrQueueNotOpened = errors.New("queue has not been opened")
 
 type State struct {
-	lastActiveAt time.Time
+	lastActiveAtMu sync.Mutex
+	lastActiveAt   time.Time
 
 	mu       sync.RWMutex
 	sendChan chan []byte
@@ -34,6 +35,9 @@ type State struct {
 	// subscribed resources
 	subscribedResources cmap.ConcurrentMap[string, struct{}]
 
+	// minimum level of the log messages set by logging/setLevel
+	loggingLevel atomic.Value
+
 	receivedInitRequest *pkg.AtomicBool
 	ready               *pkg.AtomicBool
 	closed              *pkg.AtomicBool
@@ -76,6 +80,16 @@ func (s *State) GetReady() bool {
 	return s.ready.Load()
 }
 
+func (s *State) SetLoggingLevel(level protocol.LoggingLevel) {
+	s.loggingLevel.Store(level)
+}
+
+// GetLoggingLevel returns the level set by the client with logging/setLevel, empty if it has not set one
+func (s *State) GetLoggingLevel() protocol.LoggingLevel {
+	level, _ := s.loggingLevel.Load().(protocol.LoggingLevel)
+	return level
+}
+
 func (s *State) IncRequestID() int64 {
 	return atomic.AddInt64(&s.requestID, 1)
 }
@@ -104,9 +118,19 @@ func (s *State) Close() {
 }
 
 func (s *State) updateLastActiveAt() {
+	s.lastActiveAtMu.Lock()
+	defer s.lastActiveAtMu.Unlock()
+
 	s.lastActiveAt = time.Now()
 }
 
+func (s *State) getLastActiveAt() time.Time {
+	s.lastActiveAtMu.Lock()
+	defer s.lastActiveAtMu.Unlock()
+
+	return s.lastActiveAt
+}
+
 func (s *State) openMessageQueueForSend() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
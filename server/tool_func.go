@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterToolFunc registers a tool whose input schema is generated from the arguments of handler,
+// a func(context.Context, Args) (Result, error) where Args is a struct or a pointer to a struct:
+//
+//	server.RegisterToolFunc("current_time", "Get the current time", func(ctx context.Context, req TimeReq) (string, error) {
+//		...
+//	})
+//
+// The arguments of each call are validated against the schema and unmarshalled into Args.
+// Result becomes the result of the call: a *protocol.CallToolResult is returned as is,
+// protocol.Content values are its content, a string is a text content and any other value its JSON as a text content.
+// A struct Result, or pointer to a struct, is also the structured content of the result,
+// described by the output schema of the tool generated from Result.
+func (server *Server) RegisterToolFunc(name, description string, handler any, middlewares ...ToolMiddleware) error {
+	fn := reflect.ValueOf(handler)
+	if !fn.IsValid() || fn.Kind() != reflect.Func || fn.IsNil() {
+		return fmt.Errorf("tool %s: handler must be a func(context.Context, Args) (Result, error), got %T", name, handler)
+	}
+	fnType := fn.Type()
+	if fnType.NumIn() != 2 || fnType.In(0) != contextType || fnType.NumOut() != 2 || fnType.Out(1) != errorType {
+		return fmt.Errorf("tool %s: handler must be a func(context.Context, Args) (Result, error), got %v", name, fnType)
+	}
+
+	argType := fnType.In(1)
+	structType := argType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("tool %s: the arguments of the handler must be a struct, got %v", name, argType)
+	}
+
+	var (
+		tool *protocol.Tool
+		err  error
+	)
+	zeroArgs := reflect.New(structType).Elem().Interface()
+	resultType, structured := structuredResultType(fnType.Out(0))
+	if structured {
+		tool, err = protocol.NewToolWithOutputSchema(name, description, zeroArgs, reflect.New(resultType).Elem().Interface())
+	} else {
+		tool, err = protocol.NewTool(name, description, zeroArgs)
+	}
+	if err != nil {
+		return fmt.Errorf("tool %s: %w", name, err)
+	}
+
+	server.RegisterTool(tool, func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		arguments := req.RawArguments
+		if len(arguments) == 0 {
+			var err error
+			if arguments, err = json.Marshal(req.Arguments); err != nil {
+				return nil, err
+			}
+		}
+		if string(arguments) == "null" {
+			arguments = json.RawMessage("{}")
+		}
+
+		args := reflect.New(structType)
+		if err := protocol.VerifyAndUnmarshal(arguments, args.Interface()); err != nil {
+			return nil, protocol.NewInvalidParamsError(err.Error())
+		}
+		if argType.Kind() != reflect.Ptr {
+			args = args.Elem()
+		}
+
+		out := fn.Call([]reflect.Value{reflect.ValueOf(ctx), args})
+		if err, _ := out[1].Interface().(error); err != nil {
+			return nil, err
+		}
+		result, err := toolResult(out[0].Interface())
+		if err != nil {
+			return nil, err
+		}
+		if structured && !(out[0].Kind() == reflect.Ptr && out[0].IsNil()) {
+			result.StructuredContent = out[0].Interface()
+		}
+		return result, nil
+	}, middlewares...)
+	return nil
+}
+
+var callToolResultType = reflect.TypeOf(protocol.CallToolResult{})
+
+// structuredResultType returns the struct type of the results of the handlers of RegisterToolFunc
+// which are structured content, i.e. structs and pointers to structs other than protocol.CallToolResult.
+func structuredResultType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct && t != callToolResultType
+}
+
+// toolResult converts the result of the handler of a tool registered with RegisterToolFunc to the result of the call
+func toolResult(result any) (*protocol.CallToolResult, error) {
+	switch result := result.(type) {
+	case *protocol.CallToolResult:
+		if result == nil {
+			return protocol.NewCallToolResult([]protocol.Content{}, false), nil
+		}
+		return result, nil
+	case protocol.CallToolResult:
+		return &result, nil
+	case []protocol.Content:
+		return protocol.NewCallToolResult(result, false), nil
+	case protocol.Content:
+		return protocol.NewCallToolResult([]protocol.Content{result}, false), nil
+	case string:
+		return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: result}}, false), nil
+	default:
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("marshal tool result: %w", err)
+		}
+		return protocol.NewCallToolResult([]protocol.Content{&protocol.TextContent{Type: "text", Text: string(data)}}, false), nil
+	}
+}
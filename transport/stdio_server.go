@@ -8,39 +8,64 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/pkg"
 )
 
 type StdioServerTransportOption func(*stdioServerTransport)
 
+// defaultStdioDrainTimeout bounds the wait for the responses in flight once stdin reached EOF
+const defaultStdioDrainTimeout = 10 * time.Second
+
 func WithStdioServerOptionLogger(log pkg.Logger) StdioServerTransportOption {
 	return func(t *stdioServerTransport) {
 		t.logger = log
 	}
 }
 
+// WithStdioServerOptionDrainTimeout sets how long Run waits for the responses in flight once stdin reached EOF,
+// so that a handler which never returns doesn't keep Run from returning.
+func WithStdioServerOptionDrainTimeout(timeout time.Duration) StdioServerTransportOption {
+	return func(t *stdioServerTransport) {
+		t.drainTimeout = timeout
+	}
+}
+
 type stdioServerTransport struct {
 	receiver serverReceiver
 	reader   io.ReadCloser
 	writer   io.Writer
+	// writeMu serializes the writes of the messages, so that concurrent responses and notifications don't interleave
+	writeMu sync.Mutex
+	// inFlySend tracks the responses being written, which are flushed before Shutdown returns
+	inFlySend sync.WaitGroup
 
 	sessionManager sessionManager
 	sessionID      string
 
 	logger pkg.Logger
 
+	drainTimeout time.Duration
+
 	cancel          context.CancelFunc
 	receiveShutDone chan struct{}
+	// shutdownDone is closed when Shutdown returns, releasing Run from waiting for the responses in flight
+	shutdownDone chan struct{}
+	shutdownOnce sync.Once
 }
 
 func NewStdioServerTransport(opts ...StdioServerTransportOption) ServerTransport {
 	t := &stdioServerTransport{
 		reader: os.Stdin,
 		writer: os.Stdout,
-		logger: pkg.DefaultLogger,
+		logger: pkg.StderrLogger,
+
+		drainTimeout: defaultStdioDrainTimeout,
 
 		receiveShutDone: make(chan struct{}),
+		shutdownDone:    make(chan struct{}),
 	}
 
 	for _, opt := range opts {
@@ -56,12 +81,35 @@ func (t *stdioServerTransport) Run() error {
 	t.sessionID = t.sessionManager.CreateSession(context.Background())
 
 	t.startReceive(ctx)
-
 	close(t.receiveShutDone)
+
+	// Stdin reached EOF or the transport is shutting down, the pending responses are written before returning,
+	// unless they are not done within the drain timeout or Shutdown returns first
+	timer := time.NewTimer(t.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-t.sendDone():
+	case <-timer.C:
+		t.logger.Warnf("stdio server transport: responses still in flight after %v, exiting without them", t.drainTimeout)
+	case <-t.shutdownDone:
+	}
 	return nil
 }
 
+// sendDone returns a channel closed once the responses in flight are written
+func (t *stdioServerTransport) sendDone() <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		t.inFlySend.Wait()
+		close(done)
+	}()
+	return done
+}
+
 func (t *stdioServerTransport) Send(_ context.Context, _ string, msg Message) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
 	if _, err := t.writer.Write(append(msg, mcpMessageDelimiter)); err != nil {
 		return fmt.Errorf("failed to write: %w", err)
 	}
@@ -77,6 +125,8 @@ func (t *stdioServerTransport) SetSessionManager(m sessionManager) {
 }
 
 func (t *stdioServerTransport) Shutdown(userCtx context.Context, serverCtx context.Context) error {
+	defer t.shutdownOnce.Do(func() { close(t.shutdownDone) })
+
 	t.cancel()
 
 	if err := t.reader.Close(); err != nil {
@@ -85,8 +135,14 @@ func (t *stdioServerTransport) Shutdown(userCtx context.Context, serverCtx conte
 
 	select {
 	case <-t.receiveShutDone:
-		return nil
 	case <-serverCtx.Done():
+	case <-userCtx.Done():
+		return userCtx.Err()
+	}
+
+	// Flush the responses still being written
+	select {
+	case <-t.sendDone():
 		return nil
 	case <-userCtx.Done():
 		return userCtx.Err()
@@ -99,13 +155,20 @@ func (t *stdioServerTransport) startReceive(ctx context.Context) {
 	for {
 		line, err := s.ReadBytes('\n')
 		if err != nil {
-			if errors.Is(err, io.ErrClosedPipe) || // This error occurs during unit tests, suppressing it here
-				errors.Is(err, io.EOF) {
-				return
+			// The last message may not be terminated by a newline before EOF
+			if errors.Is(err, io.EOF) && len(bytes.TrimSpace(line)) > 0 && ctx.Err() == nil {
+				t.receive(ctx, line)
 			}
-			t.logger.Errorf("client receive unexpected error reading input: %v", err)
+			if !errors.Is(err, io.ErrClosedPipe) && // This error occurs during unit tests, suppressing it here
+				!errors.Is(err, io.EOF) && ctx.Err() == nil {
+				t.logger.Errorf("server receive unexpected error reading input: %v", err)
+			}
+			return
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			continue
 		}
-		line = bytes.TrimRight(line, "\n")
 
 		select {
 		case <-ctx.Done():
@@ -127,8 +190,10 @@ func (t *stdioServerTransport) receive(ctx context.Context, line []byte) {
 		return
 	}
 
+	t.inFlySend.Add(1)
 	go func() {
 		defer pkg.Recover()
+		defer t.inFlySend.Done()
 
 		for msg := range outputMsgCh {
 			if e := t.Send(context.Background(), t.sessionID, msg); e != nil {
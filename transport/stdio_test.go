@@ -1,6 +1,10 @@
 package transport
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -8,6 +12,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -89,3 +95,128 @@ func compileMockStdioServerTr(outputPath string) error {
 
 	return nil
 }
+
+func TestStdioServerTransportEOF(t *testing.T) {
+	reader, writer := io.Pipe()
+	var out bytes.Buffer
+
+	server := NewStdioServerTransport().(*stdioServerTransport)
+	server.reader = reader
+	server.writer = &out
+	server.SetSessionManager(newMockSessionManager())
+
+	release := make(chan struct{})
+	server.SetReceiver(ServerReceiverF(func(_ context.Context, _ string, msg []byte) (<-chan []byte, error) {
+		ch := make(chan []byte)
+		go func() {
+			defer close(ch)
+			<-release
+			// concurrent responses and notifications are written as whole lines
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					if err := server.Send(context.Background(), "", Message(fmt.Sprintf(`{"n":%d}`, i))); err != nil {
+						t.Errorf("Send failed: %v", err)
+					}
+				}(i)
+			}
+			wg.Wait()
+			ch <- msg
+		}()
+		return ch, nil
+	}))
+
+	runDone := make(chan error, 1)
+	go func() {
+		runDone <- server.Run()
+	}()
+
+	// the last message is not terminated by a newline
+	if _, err := writer.Write([]byte(`{"id":1,"method":"ping"}`)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	select {
+	case <-runDone:
+		t.Fatal("Run returned before the in-flight response was written")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	select {
+	case err := <-runDone:
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after EOF")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 11 {
+		t.Fatalf("got %d lines, want 11: %q", len(lines), out.String())
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Errorf("line %q is not a JSON message", line)
+		}
+	}
+	if lines[10] != `{"id":1,"method":"ping"}` {
+		t.Errorf("last line = %q, want the response", lines[10])
+	}
+}
+
+func TestStdioServerTransportRunReturnsWithHangingHandler(t *testing.T) {
+	received := make(chan struct{}, 2)
+	newServer := func(reader io.ReadCloser, drainTimeout time.Duration) (*stdioServerTransport, chan error) {
+		server := NewStdioServerTransport(WithStdioServerOptionDrainTimeout(drainTimeout)).(*stdioServerTransport)
+		server.reader = reader
+		server.writer = io.Discard
+		server.SetSessionManager(newMockSessionManager())
+		// the handler never returns, its response channel is never closed
+		server.SetReceiver(ServerReceiverF(func(context.Context, string, []byte) (<-chan []byte, error) {
+			received <- struct{}{}
+			return make(chan []byte), nil
+		}))
+
+		runDone := make(chan error, 1)
+		go func() {
+			runDone <- server.Run()
+		}()
+		return server, runDone
+	}
+	waitRun := func(runDone chan error, after string) {
+		select {
+		case err := <-runDone:
+			if err != nil {
+				t.Errorf("Run failed: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Run did not return after %s", after)
+		}
+	}
+
+	// at EOF, Run waits for the drain timeout at most
+	_, runDone := newServer(io.NopCloser(strings.NewReader(`{"id":1,"method":"ping"}`+"\n")), 50*time.Millisecond)
+	waitRun(runDone, "the drain timeout")
+	<-received
+
+	// Run returns along with Shutdown, even if the drain timeout is not reached
+	reader, writer := io.Pipe()
+	server, runDone := newServer(reader, time.Hour)
+	if _, err := writer.Write([]byte(`{"id":1,"method":"ping"}` + "\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	<-received
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := server.Shutdown(ctx, context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	waitRun(runDone, "Shutdown")
+}
@@ -17,7 +17,7 @@ import (
 
 const sessionIDHeader = "Mcp-Session-Id"
 
-// const eventIDHeader = "Last-Event-ID"
+const lastEventIDHeader = "Last-Event-ID"
 
 type StreamableHTTPClientTransportOption func(*streamableHTTPClientTransport)
 
@@ -52,6 +52,8 @@ type streamableHTTPClientTransport struct {
 	serverURL *url.URL
 	receiver  clientReceiver
 	sessionID *pkg.AtomicString
+	// lastEventID is the ID of the last event received on the SSE stream, sent back to resume the stream on reconnection
+	lastEventID *pkg.AtomicString
 
 	// options
 	logger         pkg.Logger
@@ -75,6 +77,7 @@ func NewStreamableHTTPClientTransport(serverURL string, opts ...StreamableHTTPCl
 		cancel:         cancel,
 		serverURL:      parsedURL,
 		sessionID:      pkg.NewAtomicString(),
+		lastEventID:    pkg.NewAtomicString(),
 		logger:         pkg.DefaultLogger,
 		receiveTimeout: time.Second * 30,
 		client:         http.DefaultClient,
@@ -141,8 +144,10 @@ func (t *streamableHTTPClientTransport) Send(ctx context.Context, msg Message) e
 	}
 
 	// Handle session ID if provided in response
-	if respSessionID := resp.Header.Get(sessionIDHeader); respSessionID != "" {
+	if respSessionID := resp.Header.Get(sessionIDHeader); respSessionID != "" && respSessionID != t.sessionID.Load() {
 		t.sessionID.Store(respSessionID)
+		// the events of a new session are numbered from the start
+		t.lastEventID.Store("")
 	}
 
 	contentType := resp.Header.Get("Content-Type")
@@ -194,6 +199,9 @@ func (t *streamableHTTPClientTransport) startSSEStream() {
 
 			req.Header.Set("Accept", "text/event-stream")
 			req.Header.Set(sessionIDHeader, sessionID)
+			if lastEventID := t.lastEventID.Load(); lastEventID != "" {
+				req.Header.Set(lastEventIDHeader, lastEventID)
+			}
 
 			resp, err := t.client.Do(req)
 			if err != nil {
@@ -265,6 +273,8 @@ func (t *streamableHTTPClientTransport) handleSSEStream(reader io.ReadCloser) {
 
 		if strings.HasPrefix(line, "data:") {
 			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		} else if strings.HasPrefix(line, "id:") {
+			t.lastEventID.Store(strings.TrimSpace(strings.TrimPrefix(line, "id:")))
 		}
 	}
 }
@@ -0,0 +1,81 @@
+package transport
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/ThinkInAIXYZ/go-mcp/pkg"
+)
+
+// defaultReplayBufferSize is the number of events kept per session for the clients resuming their SSE stream
+const defaultReplayBufferSize = 256
+
+type streamEvent struct {
+	id  uint64
+	msg []byte
+}
+
+// sessionEvents holds the last events sent on the SSE stream of a session, numbered from 1
+type sessionEvents struct {
+	mu     sync.Mutex
+	lastID uint64
+	events []streamEvent
+}
+
+// eventStore numbers the events of the SSE streams and keeps the most recent ones of each session,
+// so that a client reconnecting with the Last-Event-ID header receives the events it missed.
+type eventStore struct {
+	size     int
+	sessions pkg.SyncMap[*sessionEvents]
+}
+
+func newEventStore(size int) *eventStore {
+	return &eventStore{size: size}
+}
+
+// append stores msg as the next event of the session and returns its ID
+func (s *eventStore) append(sessionID string, msg []byte) string {
+	events, _ := s.sessions.LoadOrStore(sessionID, &sessionEvents{})
+
+	events.mu.Lock()
+	defer events.mu.Unlock()
+
+	events.lastID++
+	events.events = append(events.events, streamEvent{id: events.lastID, msg: msg})
+	if len(events.events) > s.size {
+		events.events = append(events.events[:0], events.events[len(events.events)-s.size:]...)
+	}
+	return strconv.FormatUint(events.lastID, 10)
+}
+
+// after returns the events of the session following the event lastEventID, in order,
+// or false if lastEventID is unknown or some of the following events are no longer buffered.
+func (s *eventStore) after(sessionID, lastEventID string) ([]streamEvent, bool) {
+	lastID, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	events, ok := s.sessions.Load(sessionID)
+	if !ok {
+		return nil, lastID == 0
+	}
+
+	events.mu.Lock()
+	defer events.mu.Unlock()
+
+	if lastID > events.lastID {
+		return nil, false
+	}
+	if lastID == events.lastID {
+		return nil, true
+	}
+	if len(events.events) == 0 || events.events[0].id > lastID+1 {
+		return nil, false
+	}
+	missed := events.events[lastID+1-events.events[0].id:]
+	return append([]streamEvent(nil), missed...), true
+}
+
+func (s *eventStore) remove(sessionID string) {
+	s.sessions.Delete(sessionID)
+}
@@ -77,6 +77,9 @@ type streamableHTTPServerTransport struct {
 
 	sessionManager sessionManager
 
+	// events buffers the events of the SSE streams for the clients resuming them with Last-Event-ID
+	events *eventStore
+
 	// options
 	logger      pkg.Logger
 	mcpEndpoint string // The single MCP endpoint path
@@ -93,6 +96,11 @@ func (h *StreamableHTTPHandler) HandleMCP() http.Handler {
 	})
 }
 
+// ServeHTTP handles incoming MCP requests, so that the handler can be mounted directly, e.g. http.Handle("/mcp", handler)
+func (h *StreamableHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.transport.handleMCPEndpoint(w, r)
+}
+
 // NewStreamableHTTPServerTransportAndHandler returns transport without starting the HTTP server,
 // and returns a Handler for users to start their own HTTP server externally
 // eg:
@@ -109,6 +117,7 @@ func NewStreamableHTTPServerTransportAndHandler(
 		ctx:       ctx,
 		cancel:    cancel,
 		stateMode: Stateless,
+		events:    newEventStore(defaultReplayBufferSize),
 		logger:    pkg.DefaultLogger,
 	}
 
@@ -126,6 +135,7 @@ func NewStreamableHTTPServerTransport(addr string, opts ...StreamableHTTPServerT
 		ctx:         ctx,
 		cancel:      cancel,
 		stateMode:   Stateless,
+		events:      newEventStore(defaultReplayBufferSize),
 		logger:      pkg.DefaultLogger,
 		mcpEndpoint: "/mcp", // Default MCP endpoint
 	}
@@ -178,6 +188,10 @@ func (t *streamableHTTPServerTransport) SetReceiver(receiver serverReceiver) {
 
 func (t *streamableHTTPServerTransport) SetSessionManager(manager sessionManager) {
 	t.sessionManager = manager
+	// the events of the sessions closed without an open SSE stream, e.g. on expiry, are no longer resumable
+	if notifier, ok := manager.(sessionCloseNotifier); ok {
+		notifier.OnSessionClose(t.events.remove)
+	}
 }
 
 func (t *streamableHTTPServerTransport) handleMCPEndpoint(w http.ResponseWriter, r *http.Request) {
@@ -332,10 +346,26 @@ func (t *streamableHTTPServerTransport) handleGet(w http.ResponseWriter, r *http
 	w.WriteHeader(http.StatusOK)
 	flusher.Flush()
 
+	// Resume the stream after the last event received by the client
+	if lastEventID := r.Header.Get(lastEventIDHeader); lastEventID != "" {
+		missed, ok := t.events.after(sessionID, lastEventID)
+		if !ok {
+			t.logger.Warnf("cannot resume sse stream after event %s, sessionID=%s", lastEventID, sessionID)
+		}
+		for _, event := range missed {
+			if _, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", event.id, event.msg); err != nil {
+				t.logger.Errorf("Failed to replay message: %v", err)
+				return
+			}
+		}
+		flusher.Flush()
+	}
+
 	for {
 		msg, err := t.sessionManager.DequeueMessageForSend(r.Context(), sessionID)
 		if err != nil {
 			if errors.Is(err, pkg.ErrSendEOF) {
+				t.events.remove(sessionID)
 				return
 			}
 			t.logger.Debugf("sse connect dequeueMessage err: %+v, sessionID=%s", err.Error(), sessionID)
@@ -344,7 +374,9 @@ func (t *streamableHTTPServerTransport) handleGet(w http.ResponseWriter, r *http
 
 		t.logger.Debugf("Sending message: %s", string(msg))
 
-		if _, err = fmt.Fprintf(w, "data: %s\n\n", msg); err != nil {
+		// The event is buffered before being written, a client missing it can get it back by resuming the stream
+		eventID := t.events.append(sessionID, msg)
+		if _, err = fmt.Fprintf(w, "id: %s\ndata: %s\n\n", eventID, msg); err != nil {
 			t.logger.Errorf("Failed to write message: %v", err)
 			continue
 		}
@@ -353,13 +385,14 @@ func (t *streamableHTTPServerTransport) handleGet(w http.ResponseWriter, r *http
 }
 
 func (t *streamableHTTPServerTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
-	sessionID := r.Header.Get("Mcp-Session-Id")
+	sessionID := r.Header.Get(sessionIDHeader)
 	if sessionID == "" {
 		t.writeError(w, http.StatusBadRequest, "Missing session ID")
 		return
 	}
 
 	t.sessionManager.CloseSession(sessionID)
+	t.events.remove(sessionID)
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -371,7 +404,7 @@ func (t *streamableHTTPServerTransport) writeError(w http.ResponseWriter, code i
 	}
 
 	resp := protocol.NewJSONRPCErrorResponse(nil, protocol.InternalError, message)
-	bytes, err := json.Marshal(resp)
+	data, err := json.Marshal(resp)
 	if err != nil {
 		t.logger.Errorf("streamableHTTPServerTransport writeError json.Marshal: %v", err)
 		return
@@ -379,7 +412,7 @@ func (t *streamableHTTPServerTransport) writeError(w http.ResponseWriter, code i
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	if _, err := w.Write(bytes); err != nil {
+	if _, err := w.Write(data); err != nil {
 		t.logger.Errorf("streamableHTTPServerTransport writeError Write: %v", err)
 	}
 }
@@ -1,8 +1,17 @@
 package transport
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/ThinkInAIXYZ/go-mcp/server/session"
 )
 
 func TestStreamableHTTP(t *testing.T) {
@@ -29,3 +38,100 @@ func TestStreamableHTTP(t *testing.T) {
 
 	testTransport(t, client, svr)
 }
+
+func TestStreamableHTTPResumption(t *testing.T) {
+	svr, handler, err := NewStreamableHTTPServerTransportAndHandler(WithStreamableHTTPServerTransportAndHandlerOptionStateMode(Stateful))
+	if err != nil {
+		t.Fatalf("NewStreamableHTTPServerTransportAndHandler failed: %v", err)
+	}
+	manager := newMockSessionManager()
+	svr.SetSessionManager(manager)
+	sessionID := manager.CreateSession(context.Background())
+
+	httpSvr := httptest.NewServer(handler)
+	defer httpSvr.Close()
+
+	// stream opens the SSE stream of the session, returning its reader once the server is ready to send
+	stream := func(ctx context.Context, lastEventID string) *bufio.Reader {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpSvr.URL, nil)
+		if err != nil {
+			t.Fatalf("NewRequest failed: %v", err)
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set(sessionIDHeader, sessionID)
+		if lastEventID != "" {
+			req.Header.Set(lastEventIDHeader, lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		return bufio.NewReader(resp.Body)
+	}
+	readEvent := func(r *bufio.Reader) string {
+		var event []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("ReadString failed: %v", err)
+			}
+			if line = strings.TrimRight(line, "\n"); line == "" {
+				return strings.Join(event, "\n")
+			}
+			event = append(event, line)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := stream(ctx, "")
+	for i, msg := range []string{`{"n":1}`, `{"n":2}`} {
+		go func(msg string) {
+			if err := svr.Send(context.Background(), sessionID, Message(msg)); err != nil {
+				t.Errorf("Send failed: %v", err)
+			}
+		}(msg)
+		want := fmt.Sprintf("id: %d\ndata: %s", i+1, msg)
+		if got := readEvent(r); got != want {
+			t.Errorf("event = %q, want %q", got, want)
+		}
+	}
+	cancel()
+
+	// the client only processed the first event before the connection was lost
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	r = stream(ctx, "1")
+	if got, want := readEvent(r), "id: 2\ndata: {\"n\":2}"; got != want {
+		t.Errorf("replayed event = %q, want %q", got, want)
+	}
+	go func() {
+		if err := svr.Send(context.Background(), sessionID, Message(`{"n":3}`)); err != nil {
+			t.Errorf("Send failed: %v", err)
+		}
+	}()
+	if got, want := readEvent(r), "id: 3\ndata: {\"n\":3}"; got != want {
+		t.Errorf("event = %q, want %q", got, want)
+	}
+}
+
+func TestStreamableHTTPReleasesEventsOfClosedSessions(t *testing.T) {
+	svr, _, err := NewStreamableHTTPServerTransportAndHandler(WithStreamableHTTPServerTransportAndHandlerOptionStateMode(Stateful))
+	if err != nil {
+		t.Fatalf("NewStreamableHTTPServerTransportAndHandler failed: %v", err)
+	}
+	transport := svr.(*streamableHTTPServerTransport)
+	manager := session.NewManager(nil, func(context.Context) string { return uuid.NewString() })
+	transport.SetSessionManager(manager)
+
+	// the session expires or is closed by the server without an open SSE stream
+	sessionID := manager.CreateSession(context.Background())
+	transport.events.append(sessionID, []byte(`{"jsonrpc":"2.0","method":"notifications/message"}`))
+	manager.CloseSession(sessionID)
+
+	if _, ok := transport.events.sessions.Load(sessionID); ok {
+		t.Errorf("events of the closed session %s are still buffered", sessionID)
+	}
+}
@@ -100,3 +100,9 @@ type sessionManager interface {
 	CloseSession(sessionID string)
 	CloseAllSessions()
 }
+
+// sessionCloseNotifier is implemented by the session managers calling hooks on the closed sessions,
+// which the transports holding resources per session use to release them
+type sessionCloseNotifier interface {
+	OnSessionClose(hook func(sessionID string))
+}